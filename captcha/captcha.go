@@ -1,12 +1,10 @@
 package captcha
 
 import (
-    "bytes"
     crand "crypto/rand"
     "errors"
     "image"
     "image/color"
-    "image/png"
     mrand "math/rand"
 )
 
@@ -48,25 +46,82 @@ func GenerateCodeString(length int, alphabet string) (string, error) {
 // 参数 noiseLines: 干扰线数量（建议 2~8）
 // 参数 noiseDots: 干扰点数量（建议 50~300）
 // 返回值: PNG编码的图片字节与错误；若包含非数字字符或尺寸过小则返回错误
-// 关键步骤：将每个数字以7段数码管绘制到各自格子内，加入随机抖动与干扰线/点后编码为PNG。
+// 关键步骤：将每个数字以7段数码管绘制到各自格子内，加入随机抖动与干扰线/点后编码为PNG（Config API 的薄封装，不加波纹）。
 func GenerateDigitCodeImagePNG(code string, width, height int, noiseLines, noiseDots int) ([]byte, error) {
+    return NewConfig(WithSize(width, height), WithNoise(noiseLines, noiseDots), WithWave(-1, 0)).GenerateDigits(code)
+}
+
+// BuildAlphabet 生成验证码字符集（支持多字符集与易混淆字符剔除）
+// 参数 includeUpper: 是否包含大写字母 A-Z
+// 参数 includeLower: 是否包含小写字母 a-z
+// 参数 includeDigits: 是否包含数字 0-9
+// 参数 excludeAmbiguous: 是否剔除易混淆字符（如 'O','0','I','1','l'）
+// 参数 customExclude: 自定义需要剔除的字符集合（字符串形式，出现的字符将被移除）
+// 返回值: 构建后的字符集字符串（按加入顺序去重、剔除）
+// 关键步骤：聚合所选集合→应用剔除集合→去重保序，便于与 GenerateCodeString 搭配使用。
+func BuildAlphabet(includeUpper, includeLower, includeDigits bool, excludeAmbiguous bool, customExclude string) string {
+    // 关键步骤：聚合所需字符集合
+    var sb []rune
+    if includeUpper { sb = append(sb, []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")...) }
+    if includeLower { sb = append(sb, []rune("abcdefghijklmnopqrstuvwxyz")...) }
+    if includeDigits { sb = append(sb, []rune("0123456789")...) }
+    // 若均未选择，默认使用大写字母
+    if len(sb) == 0 { sb = append(sb, []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")...) }
+
+    // 关键步骤：构建剔除集合（包含自定义与易混集合）
+    exclude := map[rune]bool{}
+    for _, r := range []rune(customExclude) { exclude[r] = true }
+    if excludeAmbiguous {
+        for _, r := range []rune{'O','0','I','1','l'} { exclude[r] = true }
+    }
+
+    // 关键步骤：去重保序并应用剔除
+    used := map[rune]bool{}
+    out := make([]rune, 0, len(sb))
+    for _, r := range sb {
+        if exclude[r] { continue }
+        if !used[r] {
+            used[r] = true
+            out = append(out, r)
+        }
+    }
+    return string(out)
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// checkDigitCaptchaArgs 校验数字图片验证码参数
+// 参数 code: 数字验证码字符串
+// 参数 width,height: 图片尺寸（像素）
+// 返回值: 参数非法时返回错误
+func checkDigitCaptchaArgs(code string, width, height int) error {
     if code == "" {
-        return nil, errors.New("验证码内容不能为空")
+        return errors.New("验证码内容不能为空")
     }
     for _, ch := range code {
         if ch < '0' || ch > '9' {
-            return nil, errors.New("仅支持数字验证码图片（0-9）")
+            return errors.New("仅支持数字验证码图片（0-9）")
         }
     }
     if width < 30 || height < 20 {
-        return nil, errors.New("图片尺寸过小，至少需30x20")
+        return errors.New("图片尺寸过小，至少需30x20")
     }
+    return nil
+}
 
-    // 关键步骤：使用默认随机源用于抖动与干扰（Go 1.20 起无需调用 Seed）
-
+// renderDigitFrame 绘制一帧数字验证码图像（7段数码管样式）
+// 参数 code: 数字验证码字符串（已校验）
+// 参数 c: 已归一化的渲染配置（尺寸、背景、干扰点、波纹与随机源）
+// 参数 cols: 每个数字的颜色
+// 参数 lines: 干扰线
+// 参数 wavePhase: 波纹相位（弧度；振幅为0时不加波纹）
+// 返回值: 绘制完成的图像
+// 关键步骤：将每个数字以7段数码管绘制到各自格子内（每次调用重新随机抖动），再绘制干扰线/点与波纹。
+func renderDigitFrame(code string, c *Config, cols []color.RGBA, lines []noiseLine, wavePhase float64) *image.RGBA {
+    width, height := c.Width, c.Height
     // 关键步骤：创建并填充背景
     img := image.NewRGBA(image.Rect(0, 0, width, height))
-    fillRect(img, 0, 0, width, height, color.RGBA{255, 255, 255, 255})
+    c.Background.paint(img)
 
     // 关键步骤：每个字符占据一个格子
     n := len(code)
@@ -78,85 +133,87 @@ func GenerateDigitCodeImagePNG(code string, width, height int, noiseLines, noise
 
     for i, ch := range []byte(code) {
         // 关键步骤：为每个字符设置格子与轻微抖动
-        left := i*cellW + padX + mrand.Intn(maxInt(1, thick)) - thick/2
-        right := (i+1)*cellW - padX + mrand.Intn(maxInt(1, thick)) - thick/2
-        top := padY + mrand.Intn(maxInt(1, thick)) - thick/2
-        bottom := height - padY + mrand.Intn(maxInt(1, thick)) - thick/2
+        left := i*cellW + padX + c.Rand.Intn(maxInt(1, thick)) - thick/2
+        right := (i+1)*cellW - padX + c.Rand.Intn(maxInt(1, thick)) - thick/2
+        top := padY + c.Rand.Intn(maxInt(1, thick)) - thick/2
+        bottom := height - padY + c.Rand.Intn(maxInt(1, thick)) - thick/2
         if right-left < thick*6 { // 保证足够绘制空间
             right = left + thick*6
         }
         if bottom-top < thick*6 {
             bottom = top + thick*6
         }
-        // 关键步骤：生成段颜色（较深色）
-        col := color.RGBA{uint8(mrand.Intn(120)), uint8(mrand.Intn(120)), uint8(mrand.Intn(120)), 255}
-        drawDigit7Seg(img, int(ch-'0'), left, top, right, bottom, thick, col)
+        drawDigit7Seg(img, int(ch-'0'), left, top, right, bottom, thick, cols[i])
     }
 
-    // 关键步骤：绘制干扰线
-    for i := 0; i < noiseLines; i++ {
-        x0 := mrand.Intn(width)
-        y0 := mrand.Intn(height)
-        x1 := mrand.Intn(width)
-        y1 := mrand.Intn(height)
-        lc := color.RGBA{uint8(150 + mrand.Intn(105)), uint8(150 + mrand.Intn(105)), uint8(150 + mrand.Intn(105)), 255}
-        drawLine(img, x0, y0, x1, y1, lc)
+    // 关键步骤：绘制干扰线与干扰点
+    drawNoiseLines(img, lines)
+    drawNoiseDots(c.Rand, img, c.NoiseDots)
+    if c.WaveAmplitude > 0 {
+        img = applyWaveX(img, c.WaveAmplitude, c.WaveFrequency, wavePhase)
     }
+    return img
+}
 
-    // 关键步骤：绘制干扰点
-    for i := 0; i < noiseDots; i++ {
-        x := mrand.Intn(width)
-        y := mrand.Intn(height)
-        dc := color.RGBA{uint8(mrand.Intn(255)), uint8(mrand.Intn(255)), uint8(mrand.Intn(255)), 255}
-        img.Set(x, y, dc)
-    }
+// noiseLine 干扰线（端点与颜色）
+type noiseLine struct {
+    x0, y0, x1, y1 int
+    col            color.RGBA
+}
 
-    // 关键步骤：编码为PNG字节
-    var buf bytes.Buffer
-    if err := png.Encode(&buf, img); err != nil {
-        return nil, err
+// randomNoiseLines 生成随机干扰线（浅色）
+// 参数 rng: 随机源
+// 参数 n: 数量
+// 参数 width,height: 图片尺寸
+// 返回值: 干扰线列表
+func randomNoiseLines(rng *mrand.Rand, n, width, height int) []noiseLine {
+    lines := make([]noiseLine, 0, maxInt(0, n))
+    for i := 0; i < n; i++ {
+        lines = append(lines, noiseLine{
+            x0: rng.Intn(width), y0: rng.Intn(height),
+            x1: rng.Intn(width), y1: rng.Intn(height),
+            col: color.RGBA{uint8(150 + rng.Intn(105)), uint8(150 + rng.Intn(105)), uint8(150 + rng.Intn(105)), 255},
+        })
     }
-    return buf.Bytes(), nil
+    return lines
 }
 
-// BuildAlphabet 生成验证码字符集（支持多字符集与易混淆字符剔除）
-// 参数 includeUpper: 是否包含大写字母 A-Z
-// 参数 includeLower: 是否包含小写字母 a-z
-// 参数 includeDigits: 是否包含数字 0-9
-// 参数 excludeAmbiguous: 是否剔除易混淆字符（如 'O','0','I','1','l'）
-// 参数 customExclude: 自定义需要剔除的字符集合（字符串形式，出现的字符将被移除）
-// 返回值: 构建后的字符集字符串（按加入顺序去重、剔除）
-// 关键步骤：聚合所选集合→应用剔除集合→去重保序，便于与 GenerateCodeString 搭配使用。
-func BuildAlphabet(includeUpper, includeLower, includeDigits bool, excludeAmbiguous bool, customExclude string) string {
-    // 关键步骤：聚合所需字符集合
-    var sb []rune
-    if includeUpper { sb = append(sb, []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")...) }
-    if includeLower { sb = append(sb, []rune("abcdefghijklmnopqrstuvwxyz")...) }
-    if includeDigits { sb = append(sb, []rune("0123456789")...) }
-    // 若均未选择，默认使用大写字母
-    if len(sb) == 0 { sb = append(sb, []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")...) }
-
-    // 关键步骤：构建剔除集合（包含自定义与易混集合）
-    exclude := map[rune]bool{}
-    for _, r := range []rune(customExclude) { exclude[r] = true }
-    if excludeAmbiguous {
-        for _, r := range []rune{'O','0','I','1','l'} { exclude[r] = true }
+// randomGlyphColors 为每个字符生成随机深色
+// 参数 rng: 随机源
+// 参数 n: 字符数量
+// 返回值: 颜色列表
+func randomGlyphColors(rng *mrand.Rand, n int) []color.RGBA {
+    cols := make([]color.RGBA, n)
+    for i := range cols {
+        cols[i] = color.RGBA{uint8(rng.Intn(120)), uint8(rng.Intn(120)), uint8(rng.Intn(120)), 255}
     }
+    return cols
+}
 
-    // 关键步骤：去重保序并应用剔除
-    used := map[rune]bool{}
-    out := make([]rune, 0, len(sb))
-    for _, r := range sb {
-        if exclude[r] { continue }
-        if !used[r] {
-            used[r] = true
-            out = append(out, r)
-        }
+// drawNoiseLines 绘制干扰线
+// 参数 img: 目标图像
+// 参数 lines: 干扰线列表
+// 返回值: 无
+func drawNoiseLines(img *image.RGBA, lines []noiseLine) {
+    for _, l := range lines {
+        drawLine(img, l.x0, l.y0, l.x1, l.y1, l.col)
     }
-    return string(out)
 }
 
-// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+// drawNoiseDots 绘制随机颜色的干扰点
+// 参数 rng: 随机源
+// 参数 img: 目标图像
+// 参数 n: 干扰点数量
+// 返回值: 无
+func drawNoiseDots(rng *mrand.Rand, img *image.RGBA, n int) {
+    b := img.Bounds()
+    for i := 0; i < n; i++ {
+        x := rng.Intn(b.Dx())
+        y := rng.Intn(b.Dy())
+        dc := color.RGBA{uint8(rng.Intn(255)), uint8(rng.Intn(255)), uint8(rng.Intn(255)), 255}
+        img.Set(x, y, dc)
+    }
+}
 
 // drawDigit7Seg 绘制一个7段数码管数字到指定矩形区域
 // 参数 img: 目标图像
@@ -261,4 +318,15 @@ func maxInt(a, b int) int { if a > b { return a } ; return b }
 // absInt 返回整数绝对值
 // 参数 v: 整数
 // 返回值: 绝对值
-func absInt(v int) int { if v < 0 { return -v } ; return v }
\ No newline at end of file
+func absInt(v int) int { if v < 0 { return -v } ; return v }
+
+// randOrNew 返回给定随机源，为 nil 时新建一个随机播种的随机源
+// 参数 rng: 随机源
+// 返回值: 非 nil 的随机源
+// 关键步骤：每次生成独立创建随机源，避免多个协程共享同一个非并发安全的 *rand.Rand
+func randOrNew(rng *mrand.Rand) *mrand.Rand {
+    if rng != nil {
+        return rng
+    }
+    return mrand.New(mrand.NewSource(mrand.Int63()))
+}
@@ -0,0 +1,219 @@
+package captcha
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "math"
+    mrand "math/rand"
+    "time"
+)
+
+// 本文件提供音频验证码（WAV，16位单声道PCM），作为图片验证码的无障碍替代方案。
+// 默认使用内置合成的普通话数字语音（见 captcha_voice.go），可通过 AudioOptions.Samples 按数字替换为真人录音。
+// 每个数字单独做随机变速变调、音量与噪声强度抖动，数字之间插入随机长度的静音，
+// 同一数字多次出现时波形也各不相同，增加模板匹配与自动识别的难度。
+
+// AudioOptions 音频验证码选项
+// 结构体字段解释：
+// - SampleRate: 采样率（Hz）；<=0 时默认 8000
+// - Samples: 各数字的 PCM 样本（16位单声道，采样率需与 SampleRate 一致）；可选，未提供的数字使用内置合成语音
+// - NoiseLevel: 背景噪声强度（相对满幅，0~1）；0 时默认 0.05，<0 表示不加噪声；每个数字在此基础上随机浮动 ±50%
+// - MinGap, MaxGap: 数字之间随机静音时长范围；均<=0 时默认 300ms~700ms
+// - Rand: 随机源（静音时长、合成嗓音、变速、音量与噪声抖动）；为 nil 时每次生成随机播种，指定后输出可复现
+type AudioOptions struct {
+    SampleRate int
+    Samples    map[rune][]int16
+    NoiseLevel float64
+    MinGap     time.Duration
+    MaxGap     time.Duration
+    Rand       *mrand.Rand
+}
+
+// GenerateDigitAudioWAV 将数字验证码生成WAV音频
+// 参数 code: 数字验证码字符串（仅支持'0'-'9'，可用 GenerateCodeString(n, "0123456789") 生成并与图片共用）
+// 参数 opt: 音频选项
+// 返回值: WAV编码的音频字节与错误；包含非数字字符时返回错误
+// 关键步骤：首尾与数字之间插入随机静音→逐个数字取录音样本（缺省时按随机嗓音合成）→随机变速变调、音量抖动并叠加随机强度的噪声→裁剪并编码为WAV。
+func GenerateDigitAudioWAV(code string, opt AudioOptions) ([]byte, error) {
+    if code == "" {
+        return nil, errors.New("验证码内容不能为空")
+    }
+    for _, ch := range code {
+        if ch < '0' || ch > '9' {
+            return nil, errors.New("仅支持数字音频验证码（0-9）")
+        }
+    }
+    rate, noise, minGap, maxGap := normalizeAudioOptions(opt)
+    rng := randOrNew(opt.Rand)
+
+    var pcm []float64
+    for _, ch := range code {
+        pcm = append(pcm, addNoise(rng, make([]float64, randomGapSamples(rng, rate, minGap, maxGap)), noise)...)
+        s := opt.Samples[ch]
+        if len(s) == 0 { s = synthDigit(rng, ch, rate) }
+        digit := jitterDigit(rng, s, 0.7+rng.Float64()*0.3)
+        pcm = append(pcm, addNoise(rng, digit, noise*(0.5+rng.Float64()))...)
+    }
+    pcm = append(pcm, addNoise(rng, make([]float64, randomGapSamples(rng, rate, minGap, maxGap)), noise)...)
+
+    // 关键步骤：裁剪到 16 位范围
+    out := make([]int16, len(pcm))
+    for i, v := range pcm {
+        v = math.Max(-1, math.Min(1, v))
+        out[i] = int16(math.Round(v * 32767))
+    }
+    return encodeWAV(out, rate), nil
+}
+
+// ParseWAV 解析16位单声道PCM的WAV数据（用于加载自定义数字录音样本）
+// 参数 data: WAV文件字节
+// 返回值: PCM样本、采样率与错误（非 RIFF/WAVE、非PCM、非16位单声道时返回错误）
+// 关键步骤：遍历 RIFF 子块，读取 fmt 块校验格式，读取 data 块样本；忽略其他块。
+func ParseWAV(data []byte) (samples []int16, sampleRate int, err error) {
+    if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+        return nil, 0, errors.New("不是有效的WAV文件")
+    }
+    var gotFmt bool
+    for off := 12; off+8 <= len(data); {
+        id := string(data[off : off+4])
+        size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+        body := off + 8
+        if size < 0 || body+size > len(data) {
+            return nil, 0, errors.New("WAV数据块长度非法")
+        }
+        switch id {
+        case "fmt ":
+            if size < 16 {
+                return nil, 0, errors.New("WAV格式块长度非法")
+            }
+            format := binary.LittleEndian.Uint16(data[body:])
+            channels := binary.LittleEndian.Uint16(data[body+2:])
+            sampleRate = int(binary.LittleEndian.Uint32(data[body+4:]))
+            bits := binary.LittleEndian.Uint16(data[body+14:])
+            if format != 1 || channels != 1 || bits != 16 {
+                return nil, 0, errors.New("仅支持16位单声道PCM的WAV")
+            }
+            gotFmt = true
+        case "data":
+            if !gotFmt {
+                return nil, 0, errors.New("WAV缺少格式块")
+            }
+            samples = make([]int16, size/2)
+            for i := range samples {
+                samples[i] = int16(binary.LittleEndian.Uint16(data[body+2*i:]))
+            }
+            return samples, sampleRate, nil
+        }
+        // 关键步骤：RIFF 子块按偶数字节对齐
+        off = body + size + size%2
+    }
+    return nil, 0, errors.New("WAV缺少数据块")
+}
+
+// AudioWAV 为已签发的验证码生成对应的音频（与图片共用同一答案）
+// 参数 id: 验证码ID
+// 参数 opt: 音频选项
+// 返回值: WAV音频字节与错误（ID不存在、已过期或答案含非数字字符时返回错误）
+// 关键步骤：从存储读取答案后生成音频，不影响校验状态；需配置 Alphabet 为纯数字以保证答案可朗读。
+func (m *Manager) AudioWAV(id string, opt AudioOptions) ([]byte, error) {
+    answer, ok, err := m.store.Get(id)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, errors.New("验证码不存在或已过期")
+    }
+    return GenerateDigitAudioWAV(answer, opt)
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// normalizeAudioOptions 填充音频选项默认值
+// 参数 opt: 音频选项
+// 返回值: 采样率、噪声强度与静音范围
+func normalizeAudioOptions(opt AudioOptions) (rate int, noise float64, minGap, maxGap time.Duration) {
+    rate = opt.SampleRate
+    if rate <= 0 { rate = 8000 }
+    noise = opt.NoiseLevel
+    if noise == 0 { noise = 0.05 }
+    if noise > 1 { noise = 1 }
+    minGap, maxGap = opt.MinGap, opt.MaxGap
+    if minGap <= 0 && maxGap <= 0 { minGap, maxGap = 300*time.Millisecond, 700*time.Millisecond }
+    if minGap < 0 { minGap = 0 }
+    if maxGap < minGap { maxGap = minGap }
+    return rate, noise, minGap, maxGap
+}
+
+// randomGapSamples 随机静音时长对应的样本数
+// 参数 rng: 随机源
+// 参数 rate: 采样率
+// 参数 minGap,maxGap: 静音时长范围
+// 返回值: 样本数
+func randomGapSamples(rng *mrand.Rand, rate int, minGap, maxGap time.Duration) int {
+    d := minGap
+    if maxGap > minGap {
+        d += time.Duration(rng.Int63n(int64(maxGap - minGap + 1)))
+    }
+    return int(int64(d) * int64(rate) / int64(time.Second))
+}
+
+// jitterDigit 对单个数字的录音做随机变速变调与音量调整
+// 参数 rng: 随机源
+// 参数 s: 数字录音样本（非空）
+// 参数 gain: 音量（0~1）
+// 返回值: 归一化到 [-1,1] 的样本
+// 关键步骤：以 0.9~1.1 的随机倍速线性插值重采样，时长与音高同时变化，同一录音每次输出的波形都不同。
+func jitterDigit(rng *mrand.Rand, s []int16, gain float64) []float64 {
+    speed := 0.9 + rng.Float64()*0.2
+    out := make([]float64, maxInt(1, int(float64(len(s))/speed)))
+    for i := range out {
+        pos := float64(i) * speed
+        j := int(pos)
+        if j >= len(s) { j = len(s) - 1 }
+        a, b := float64(s[j]), float64(s[j])
+        if j+1 < len(s) { b = float64(s[j+1]) }
+        out[i] = (a + (b-a)*(pos-float64(j))) / 32768 * gain
+    }
+    return out
+}
+
+// addNoise 为一段样本叠加白噪声
+// 参数 rng: 随机源
+// 参数 seg: 样本（原地修改）
+// 参数 level: 噪声强度；<=0 时不加噪声
+// 返回值: 修改后的样本
+func addNoise(rng *mrand.Rand, seg []float64, level float64) []float64 {
+    if level <= 0 {
+        return seg
+    }
+    for i := range seg {
+        seg[i] += (rng.Float64()*2 - 1) * level
+    }
+    return seg
+}
+
+// encodeWAV 将16位单声道PCM样本编码为WAV
+// 参数 samples: PCM样本
+// 参数 rate: 采样率
+// 返回值: WAV字节
+func encodeWAV(samples []int16, rate int) []byte {
+    dataSize := uint32(len(samples) * 2)
+    var buf bytes.Buffer
+    buf.Grow(44 + int(dataSize))
+    le := binary.LittleEndian
+    buf.WriteString("RIFF")
+    binary.Write(&buf, le, 36+dataSize)
+    buf.WriteString("WAVEfmt ")
+    binary.Write(&buf, le, uint32(16))       // fmt 块长度
+    binary.Write(&buf, le, uint16(1))        // PCM
+    binary.Write(&buf, le, uint16(1))        // 单声道
+    binary.Write(&buf, le, uint32(rate))     // 采样率
+    binary.Write(&buf, le, uint32(rate*2))   // 字节率
+    binary.Write(&buf, le, uint16(2))        // 块对齐
+    binary.Write(&buf, le, uint16(16))       // 位深
+    buf.WriteString("data")
+    binary.Write(&buf, le, dataSize)
+    binary.Write(&buf, le, samples)
+    return buf.Bytes()
+}
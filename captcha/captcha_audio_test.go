@@ -0,0 +1,188 @@
+package captcha
+
+import (
+    "math"
+    mrand "math/rand"
+    "testing"
+    "time"
+)
+
+// goertzelPower 计算样本在指定频率上的能量（Goertzel 算法）
+// 参数 s: PCM样本
+// 参数 rate: 采样率
+// 参数 freq: 目标频率
+// 返回值: 能量值
+func goertzelPower(s []int16, rate int, freq float64) float64 {
+    coeff := 2 * math.Cos(2*math.Pi*freq/float64(rate))
+    var q1, q2 float64
+    for _, v := range s {
+        q0 := coeff*q1 - q2 + float64(v)
+        q2, q1 = q1, q0
+    }
+    return q1*q1 + q2*q2 - coeff*q1*q2
+}
+
+// testDigitSamples 构造测试用的数字样本（数字 d 为 500+100·d Hz 的正弦，250ms）
+// 参数 rate: 采样率
+// 返回值: 全部数字的样本
+func testDigitSamples(rate int) map[rune][]int16 {
+    samples := map[rune][]int16{}
+    for d := '0'; d <= '9'; d++ {
+        s := make([]int16, rate/4)
+        for i := range s { s[i] = int16(16000 * math.Sin(2*math.Pi*float64(500+100*(d-'0'))*float64(i)/float64(rate))) }
+        samples[d] = s
+    }
+    return samples
+}
+
+// peakFreq 在频率范围内按 10Hz 步进查找能量最大的频率
+// 参数 s: PCM样本
+// 参数 rate: 采样率
+// 参数 lo,hi: 频率范围
+// 返回值: 能量最大的频率
+func peakFreq(s []int16, rate int, lo, hi float64) float64 {
+    best, bestP := lo, -1.0
+    for f := lo; f <= hi; f += 10 {
+        if p := goertzelPower(s, rate, f); p > bestP { best, bestP = f, p }
+    }
+    return best
+}
+
+// bandEnergy 按 50Hz 步进累加频率范围内的能量
+// 参数 s: PCM样本
+// 参数 rate: 采样率
+// 参数 lo,hi: 频率范围（左闭右开）
+// 返回值: 能量和
+func bandEnergy(s []int16, rate int, lo, hi float64) float64 {
+    e := 0.0
+    for f := lo; f < hi; f += 50 { e += goertzelPower(s, rate, f) }
+    return e
+}
+
+// pitchAt 以自相关估计某位置附近 40ms 窗口的基频
+// 参数 s: PCM样本
+// 参数 rate: 采样率
+// 参数 pos: 窗口中心（相对全长的比例）
+// 返回值: 基频（Hz）
+// 关键步骤：在 60~400Hz 对应的周期内求自相关，取首个达到最大值 90% 的周期（避免倍周期误判）
+func pitchAt(s []int16, rate int, pos float64) float64 {
+    n := rate / 25
+    start := int(float64(len(s))*pos) - n/2
+    w := s[start : start+n]
+    lo, hi := rate/400, rate/60
+    r := make([]float64, hi+1)
+    best := 0.0
+    for lag := lo; lag <= hi; lag++ {
+        for i := 0; i+lag < len(w); i++ { r[lag] += float64(w[i]) * float64(w[i+lag]) }
+        best = math.Max(best, r[lag])
+    }
+    for lag := lo; lag <= hi; lag++ {
+        if r[lag] >= best*0.9 && r[lag] >= r[lag-1] && r[lag] >= r[lag+1] { return float64(rate) / float64(lag) }
+    }
+    return 0
+}
+
+// TestGenerateDigitAudioWAV_BuiltinVoice 测试：未提供录音时使用内置合成语音，Samples 按数字覆盖
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：空选项可生成全部数字；非数字与空串返回错误；只缺 '5' 的样本时 '1' 使用录音（600Hz 正弦）、'5' 使用合成语音
+func TestGenerateDigitAudioWAV_BuiltinVoice(t *testing.T) {
+    wav, err := GenerateDigitAudioWAV("0123456789", AudioOptions{})
+    if err != nil { t.Fatalf("GenerateDigitAudioWAV error: %v", err) }
+    if samples, rate, err := ParseWAV(wav); err != nil || rate != 8000 || len(samples) < 8000*3 { t.Fatalf("unexpected audio: err=%v rate=%d len=%d", err, rate, len(samples)) }
+    if _, err := GenerateDigitAudioWAV("12a", AudioOptions{}); err == nil { t.Fatalf("expected error for non-digit code") }
+    if _, err := GenerateDigitAudioWAV("", AudioOptions{}); err == nil { t.Fatalf("expected error for empty code") }
+
+    partial := testDigitSamples(8000)
+    delete(partial, '5')
+    opt := func() AudioOptions {
+        return AudioOptions{Samples: partial, NoiseLevel: -1, MinGap: time.Millisecond, MaxGap: time.Millisecond, Rand: mrand.New(mrand.NewSource(3))}
+    }
+    wav, _ = GenerateDigitAudioWAV("1", opt())
+    one, _, _ := ParseWAV(wav)
+    if f := peakFreq(one, 8000, 400, 800); f < 600*0.9-10 || f > 600*1.1+10 { t.Fatalf("expected recorded sample for '1', peak=%g", f) }
+    wav, _ = GenerateDigitAudioWAV("5", opt())
+    five, _, _ := ParseWAV(wav)
+    if len(five) < 8000/4*10/9+32 { t.Fatalf("expected synthesized voice for '5', len=%d", len(five)) }
+}
+
+// TestSynthDigit_Phonetics 测试：合成语音具备可辨识的声韵与声调特征
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：多个随机嗓音下检查 sì 的声母为高频擦音而韵母集中在低频；yī 的 F2 区（1.8~3kHz）能量占比高于 wǔ；
+// yī（阴平）基频平稳，sì（去声）基频明显下降；同一随机种子输出一致
+func TestSynthDigit_Phonetics(t *testing.T) {
+    const rate = 16000
+    for seed := int64(1); seed <= 20; seed++ {
+        si := synthDigit(mrand.New(mrand.NewSource(seed)), '4', rate)
+        head, mid := si[:rate/20], si[len(si)/2-rate/20:len(si)/2+rate/20]
+        if r := bandEnergy(head, rate, 3000, 8000) / bandEnergy(head, rate, 0, 8000); r < 0.7 { t.Fatalf("seed %d: expected high-frequency frication in si, ratio=%.2f", seed, r) }
+        if r := bandEnergy(mid, rate, 0, 1500) / bandEnergy(mid, rate, 0, 8000); r < 0.7 { t.Fatalf("seed %d: expected voiced vowel in si, ratio=%.2f", seed, r) }
+
+        yi := synthDigit(mrand.New(mrand.NewSource(seed)), '1', rate)
+        wu := synthDigit(mrand.New(mrand.NewSource(seed)), '5', rate)
+        ratio := func(s []int16) float64 {
+            m := s[len(s)/2-rate/20 : len(s)/2+rate/20]
+            return bandEnergy(m, rate, 1800, 3000) / bandEnergy(m, rate, 0, 8000)
+        }
+        if ratio(yi) < 3*ratio(wu) { t.Fatalf("seed %d: expected stronger F2 region in yi (%.4f) than wu (%.4f)", seed, ratio(yi), ratio(wu)) }
+
+        if a, b := pitchAt(yi, rate, 0.3), pitchAt(yi, rate, 0.8); math.Abs(b/a-1) > 0.08 { t.Fatalf("seed %d: expected level tone for yi, %.0f -> %.0f Hz", seed, a, b) }
+        if a, b := pitchAt(si, rate, 0.45), pitchAt(si, rate, 0.85); b > a*0.85 { t.Fatalf("seed %d: expected falling tone for si, %.0f -> %.0f Hz", seed, a, b) }
+    }
+    a := synthDigit(mrand.New(mrand.NewSource(9)), '0', 8000)
+    b := synthDigit(mrand.New(mrand.NewSource(9)), '0', 8000)
+    if len(a) != len(b) || a[len(a)/2] != b[len(b)/2] { t.Fatalf("expected reproducible synthesis with the same seed") }
+}
+
+// TestGenerateDigitAudioWAV_Jitter 测试：每个数字独立变速变调，间隔落在范围内
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：无噪声时按非零区段切分出两次出现的 '7'（1200Hz、250ms）→长度与主频均在 ±10% 内，且两段波形不同
+func TestGenerateDigitAudioWAV_Jitter(t *testing.T) {
+    wav, err := GenerateDigitAudioWAV("77", AudioOptions{SampleRate: 16000, Samples: testDigitSamples(16000), NoiseLevel: -1, MinGap: 10 * time.Millisecond, MaxGap: 50 * time.Millisecond, Rand: mrand.New(mrand.NewSource(1))})
+    if err != nil { t.Fatalf("GenerateDigitAudioWAV error: %v", err) }
+    samples, rate, err := ParseWAV(wav)
+    if err != nil || rate != 16000 { t.Fatalf("ParseWAV error: %v rate=%d", err, rate) }
+    var segs [][]int16
+    start := -1
+    for i := 0; i <= len(samples); i++ {
+        // 关键步骤：连续 32 个零样本视为静音间隔（正弦过零点不会连续为零）
+        silent := allZero(samples[i:min(i+32, len(samples))])
+        if !silent && start < 0 { start = i }
+        if silent && start >= 0 { segs, start = append(segs, samples[start:i]), -1 }
+    }
+    if len(segs) != 2 { t.Fatalf("expected 2 digit segments, got %d", len(segs)) }
+    for _, seg := range segs {
+        if n := len(seg); n < 4000*10/11-32 || n > 4000*10/9+32 { t.Fatalf("segment length out of range: %d", n) }
+        if f := peakFreq(seg, rate, 1000, 1400); f < 1200*0.9-10 || f > 1200*1.1+10 { t.Fatalf("segment pitch out of range: %g", f) }
+    }
+    if len(segs[0]) == len(segs[1]) && segs[0][100] == segs[1][100] { t.Fatalf("expected per-digit jitter to differ") }
+    if n := len(samples); n > 2*4445+3*800 { t.Fatalf("total length out of range: %d", n) }
+}
+
+// allZero 判断样本是否全为零
+// 参数 s: PCM样本
+// 返回值: 是否全为零
+func allZero(s []int16) bool {
+    for _, v := range s {
+        if v != 0 { return false }
+    }
+    return true
+}
+
+// TestManager_AudioWAV 测试：音频与图片共用同一答案
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：纯数字字符集签发验证码→按ID生成音频成功；未知ID返回错误；生成音频不影响校验
+func TestManager_AudioWAV(t *testing.T) {
+    store := NewMemoryStore(0)
+    defer store.Close()
+    m := NewManager(ManagerOptions{Store: store, Alphabet: "0123456789", Render: func(string) ([]byte, error) { return nil, nil }})
+    defer m.Close()
+    id, _, _ := m.Generate()
+    if _, err := m.AudioWAV(id, AudioOptions{}); err != nil { t.Fatalf("AudioWAV error: %v", err) }
+    if _, err := m.AudioWAV("missing", AudioOptions{}); err == nil { t.Fatalf("expected error for unknown id") }
+    answer, _, _ := store.Get(id)
+    if !m.Verify(id, answer, true) { t.Fatalf("expected verify ok after audio") }
+}
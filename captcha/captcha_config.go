@@ -0,0 +1,419 @@
+package captcha
+
+import (
+    "bytes"
+    "errors"
+    "image"
+    "image/color"
+    "image/jpeg"
+    "image/png"
+    "math"
+    mrand "math/rand"
+
+    "golang.org/x/image/font"
+)
+
+// 本文件提供基于配置结构体与函数式选项（Option）的验证码生成API，
+// 统一描述尺寸、配色、背景、噪声、波纹、旋转、字体与输出格式，替代冗长的位置参数。
+// 旧的 GenerateXxx 位置参数函数保留为本API的薄封装，行为不变。
+
+// Format 输出图片格式
+type Format int
+
+const (
+    // FormatPNG PNG 静态图（默认）
+    FormatPNG Format = iota
+    // FormatGIF GIF 动图（帧数与延时见 Config.GIF）
+    FormatGIF
+    // FormatJPEG JPEG 静态图（体积更小，质量见 Config.JPEGQuality；标准库无 WebP 编码器，需要更小体积时优先使用 JPEG）
+    FormatJPEG
+)
+
+// MIMEType 返回格式对应的 MIME 类型
+// 参数: 无
+// 返回值: 如 "image/png"；未知格式返回 "application/octet-stream"
+func (f Format) MIMEType() string {
+    switch f {
+    case FormatPNG:
+        return "image/png"
+    case FormatGIF:
+        return "image/gif"
+    case FormatJPEG:
+        return "image/jpeg"
+    }
+    return "application/octet-stream"
+}
+
+// Background 背景样式（通过 SolidBackground/GradientBackground/ImageBackground 构造）
+// 结构体字段解释：
+// - kind: 背景类型（0 纯色、1 渐变、2 图片）
+// - from, to: 纯色或渐变起止颜色
+// - img: 背景图片（按画布尺寸最近邻缩放）
+type Background struct {
+    kind     int
+    from, to color.RGBA
+    img      image.Image
+}
+
+// Config 验证码渲染配置
+// 结构体字段解释：
+// - Width, Height: 图片尺寸（像素）；文本验证码至少 60x24，数字验证码至少 30x20
+// - Palette: 字符颜色候选（每个字符随机选取）；为空时随机生成深色
+// - Background: 背景样式；零值为纯白
+// - NoiseLines, NoiseDots: 干扰线与干扰点数量
+// - WaveAmplitude: 整体波纹振幅（像素）；0 时按高度自适应（高度/36，至少1），<0 表示关闭
+// - WaveFrequency: 波纹频率；<=0 时默认 2.0
+// - Rotation: 字符最大旋转角度（度）；0 时默认 8，<0 表示不旋转（数字验证码不使用）
+// - Shear: 字符最大水平错切因子；0 时默认 0.12，<0 表示不错切（数字验证码不使用）
+// - Overlap: 相邻字符的重叠比例（相对字符格宽度）；0 表示不重叠，需在 [0, 0.6] 内（数字验证码不使用）
+// - Fonts: 按优先级排列的 TTF/OTF 字体字节，每个字符使用第一个包含其字形的字体，最后回退到 goregular
+// - FontScale: 字号缩放比例（相对高度的默认字号）；0 时为 1，需在 (0, 4] 内，超出时返回错误而非静默截断
+// - Format: 输出格式
+// - GIF: 动图选项（仅 Format 为 FormatGIF 时使用）
+// - JPEGQuality: JPEG 质量（1~100，仅 Format 为 FormatJPEG 时使用）；0 时默认 80，超出范围返回错误
+// - Rand: 抖动、颜色与噪声使用的随机源；为 nil 时每次生成随机播种。指定后输出可逐字节复现（用于测试），
+//   但 *rand.Rand 非并发安全，携带 Rand 的配置不可在多个协程中同时使用
+type Config struct {
+    Width         int
+    Height        int
+    Palette       []color.Color
+    Background    Background
+    NoiseLines    int
+    NoiseDots     int
+    WaveAmplitude int
+    WaveFrequency float64
+    Rotation      float64
+    Shear         float64
+    Overlap       float64
+    Fonts         [][]byte
+    FontScale     float64
+    Format        Format
+    GIF           GIFOptions
+    JPEGQuality   int
+    Rand          *mrand.Rand
+}
+
+// Option 配置选项函数
+type Option func(c *Config)
+
+// DefaultConfig 返回默认配置
+// 参数: 无
+// 返回值: 160x60、4 条干扰线、120 个干扰点、白色背景、PNG 输出的配置
+func DefaultConfig() Config {
+    return Config{Width: 160, Height: 60, NoiseLines: 4, NoiseDots: 120}
+}
+
+// NewConfig 在默认配置上依次应用选项
+// 参数 opts: 选项列表
+// 返回值: 配置
+func NewConfig(opts ...Option) Config {
+    c := DefaultConfig()
+    for _, opt := range opts {
+        opt(&c)
+    }
+    return c
+}
+
+// WithSize 设置图片尺寸
+// 参数 width,height: 宽高（像素）
+// 返回值: 选项
+func WithSize(width, height int) Option {
+    return func(c *Config) { c.Width, c.Height = width, height }
+}
+
+// WithPalette 设置字符颜色候选
+// 参数 colors: 颜色列表
+// 返回值: 选项
+func WithPalette(colors ...color.Color) Option {
+    return func(c *Config) { c.Palette = colors }
+}
+
+// WithBackground 设置背景样式
+// 参数 bg: 背景
+// 返回值: 选项
+func WithBackground(bg Background) Option {
+    return func(c *Config) { c.Background = bg }
+}
+
+// WithNoise 设置干扰线与干扰点数量
+// 参数 lines: 干扰线数量
+// 参数 dots: 干扰点数量
+// 返回值: 选项
+func WithNoise(lines, dots int) Option {
+    return func(c *Config) { c.NoiseLines, c.NoiseDots = lines, dots }
+}
+
+// WithWave 设置整体波纹
+// 参数 amplitude: 振幅（像素，<0 关闭）
+// 参数 frequency: 频率
+// 返回值: 选项
+func WithWave(amplitude int, frequency float64) Option {
+    return func(c *Config) { c.WaveAmplitude, c.WaveFrequency = amplitude, frequency }
+}
+
+// WithRotation 设置字符最大旋转角度与错切因子
+// 参数 degrees: 最大旋转角度（度，<0 不旋转）
+// 参数 shear: 最大水平错切因子（<0 不错切）
+// 返回值: 选项
+func WithRotation(degrees, shear float64) Option {
+    return func(c *Config) { c.Rotation, c.Shear = degrees, shear }
+}
+
+// WithOverlap 设置相邻字符的重叠比例（字符挤在一起更难被逐字切分）
+// 参数 ratio: 重叠比例（[0, 0.6]，相对字符格宽度）
+// 返回值: 选项
+func WithOverlap(ratio float64) Option {
+    return func(c *Config) { c.Overlap = ratio }
+}
+
+// WithFonts 设置字体列表（按优先级逐字回退）
+// 参数 fonts: TTF/OTF 字体字节
+// 返回值: 选项
+func WithFonts(fonts ...[]byte) Option {
+    return func(c *Config) { c.Fonts = fonts }
+}
+
+// WithFontScale 设置字号缩放比例
+// 参数 scale: 缩放比例（(0, 4]）
+// 返回值: 选项
+func WithFontScale(scale float64) Option {
+    return func(c *Config) { c.FontScale = scale }
+}
+
+// WithFormat 设置输出格式
+// 参数 f: 输出格式
+// 返回值: 选项
+func WithFormat(f Format) Option {
+    return func(c *Config) { c.Format = f }
+}
+
+// WithGIF 设置输出为 GIF 动图并指定动图选项
+// 参数 opt: 动图选项
+// 返回值: 选项
+func WithGIF(opt GIFOptions) Option {
+    return func(c *Config) { c.Format, c.GIF = FormatGIF, opt }
+}
+
+// WithJPEG 设置输出为 JPEG 并指定质量
+// 参数 quality: JPEG 质量（1~100，0 表示默认 80）
+// 返回值: 选项
+func WithJPEG(quality int) Option {
+    return func(c *Config) { c.Format, c.JPEGQuality = FormatJPEG, quality }
+}
+
+// WithRand 设置随机源
+// 参数 r: 随机源（nil 表示每次生成随机播种）
+// 返回值: 选项
+func WithRand(r *mrand.Rand) Option {
+    return func(c *Config) { c.Rand = r }
+}
+
+// WithSeed 使用固定种子创建随机源（相同种子与配置生成的图片逐字节一致）
+// 参数 seed: 随机种子
+// 返回值: 选项
+func WithSeed(seed int64) Option {
+    return func(c *Config) { c.Rand = mrand.New(mrand.NewSource(seed)) }
+}
+
+// SolidBackground 纯色背景
+// 参数 c: 颜色
+// 返回值: 背景
+func SolidBackground(c color.Color) Background {
+    rgba := color.RGBAModel.Convert(c).(color.RGBA)
+    return Background{kind: 0, from: rgba, to: rgba}
+}
+
+// GradientBackground 线性渐变背景（左上到右下）
+// 参数 from, to: 起止颜色
+// 返回值: 背景
+func GradientBackground(from, to color.Color) Background {
+    return Background{kind: 1, from: color.RGBAModel.Convert(from).(color.RGBA), to: color.RGBAModel.Convert(to).(color.RGBA)}
+}
+
+// ImageBackground 图片背景（按画布尺寸缩放铺满）
+// 参数 img: 背景图片（为 nil 时退化为纯白）
+// 返回值: 背景
+func ImageBackground(img image.Image) Background {
+    if img == nil {
+        return Background{}
+    }
+    return Background{kind: 2, img: img}
+}
+
+// GenerateText 按选项生成文本图片验证码
+// 参数 text: 验证码文本（字母、数字、汉字等，所需字形由 Fonts 提供）
+// 参数 opts: 选项（在 DefaultConfig 基础上应用）
+// 返回值: 按 Format 编码的图片字节与错误
+func GenerateText(text string, opts ...Option) ([]byte, error) {
+    return NewConfig(opts...).GenerateText(text)
+}
+
+// GenerateDigits 按选项生成数字图片验证码（7段数码管样式）
+// 参数 code: 数字验证码（仅支持'0'-'9'）
+// 参数 opts: 选项（在 DefaultConfig 基础上应用）
+// 返回值: 按 Format 编码的图片字节与错误
+func GenerateDigits(code string, opts ...Option) ([]byte, error) {
+    return NewConfig(opts...).GenerateDigits(code)
+}
+
+// GenerateText 按配置生成文本图片验证码
+// 参数 text: 验证码文本
+// 返回值: 按 Format 编码的图片字节与错误（参数非法、字体解析失败或缺字时返回错误）
+// 关键步骤：校验配置→按字号加载字体并逐字选择→生成字符样式与干扰线→逐帧渲染并编码。
+func (c Config) GenerateText(text string) ([]byte, error) {
+    if err := checkTextCaptchaArgs(text, c.Width, c.Height); err != nil {
+        return nil, err
+    }
+    if err := c.normalize(); err != nil {
+        return nil, err
+    }
+    candidates, err := loadFontFaces(c.Fonts, float64(c.Height)*1.2*c.FontScale)
+    if err != nil {
+        return nil, err
+    }
+    faces, err := pickGlyphFaces([]rune(text), candidates)
+    if err != nil {
+        return nil, err
+    }
+    return c.renderText(text, faces)
+}
+
+// GenerateDigits 按配置生成数字图片验证码
+// 参数 code: 数字验证码
+// 返回值: 按 Format 编码的图片字节与错误
+func (c Config) GenerateDigits(code string) ([]byte, error) {
+    if err := checkDigitCaptchaArgs(code, c.Width, c.Height); err != nil {
+        return nil, err
+    }
+    if err := c.normalize(); err != nil {
+        return nil, err
+    }
+    cols := c.glyphColors(len(code))
+    return c.encode(func(lines []noiseLine, phase float64) *image.RGBA {
+        return renderDigitFrame(code, &c, cols, lines, phase)
+    })
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// normalize 校验配置并填充默认值（原地修改副本）
+// 参数: 无
+// 返回值: 配置非法时返回错误
+func (c *Config) normalize() error {
+    if c.FontScale == 0 { c.FontScale = 1 }
+    if c.FontScale < 0 || c.FontScale > 4 || math.IsNaN(c.FontScale) {
+        return errors.New("字体缩放比例需在 (0, 4] 范围内")
+    }
+    if c.WaveAmplitude == 0 { c.WaveAmplitude = maxInt(1, c.Height/36) }
+    if c.WaveAmplitude < 0 { c.WaveAmplitude = 0 }
+    if c.WaveFrequency <= 0 { c.WaveFrequency = 2.0 }
+    if c.Rotation == 0 { c.Rotation = 8 }
+    if c.Rotation < 0 { c.Rotation = 0 }
+    if c.Shear == 0 { c.Shear = 0.12 }
+    if c.Shear < 0 { c.Shear = 0 }
+    if c.Overlap < 0 || c.Overlap > 0.6 || math.IsNaN(c.Overlap) {
+        return errors.New("字符重叠比例需在 [0, 0.6] 范围内")
+    }
+    if c.NoiseLines < 0 { c.NoiseLines = 0 }
+    if c.NoiseDots < 0 { c.NoiseDots = 0 }
+    if c.JPEGQuality == 0 { c.JPEGQuality = 80 }
+    if c.JPEGQuality < 1 || c.JPEGQuality > 100 {
+        return errors.New("JPEG 质量需在 1~100 范围内")
+    }
+    if c.Format != FormatPNG && c.Format != FormatGIF && c.Format != FormatJPEG {
+        return errors.New("不支持的输出格式")
+    }
+    c.Rand = randOrNew(c.Rand)
+    return nil
+}
+
+// renderText 使用已选定的字体渲染并编码文本验证码
+// 参数 text: 验证码文本（已校验）
+// 参数 faces: 与字符一一对应的字体
+// 返回值: 图片字节与错误
+// 关键步骤：字符样式全帧共享，动图逐帧只改变抖动、干扰与波纹相位。
+func (c Config) renderText(text string, faces []font.Face) ([]byte, error) {
+    styles := c.glyphStyles(len(faces))
+    return c.encode(func(lines []noiseLine, phase float64) *image.RGBA {
+        return renderTextFrame(text, &c, faces, styles, lines, phase)
+    })
+}
+
+// encode 生成干扰线并按输出格式渲染编码
+// 参数 frame: 单帧渲染函数（入参为本帧干扰线与波纹相位）
+// 返回值: 图片字节与错误
+// 关键步骤：GIF 逐帧渲染；PNG 与 JPEG 渲染单帧后按格式编码
+func (c Config) encode(frame func(lines []noiseLine, phase float64) *image.RGBA) ([]byte, error) {
+    lines := randomNoiseLines(c.Rand, c.NoiseLines, c.Width, c.Height)
+    if c.Format == FormatGIF {
+        return encodeCaptchaGIF(c.Rand, lines, c.GIF, frame)
+    }
+    img := frame(lines, c.Rand.Float64()*2*math.Pi)
+    var buf bytes.Buffer
+    var err error
+    if c.Format == FormatJPEG {
+        err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: c.JPEGQuality})
+    } else {
+        err = png.Encode(&buf, img)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// glyphColors 为每个字符选取颜色（优先使用调色板）
+// 参数 n: 字符数量
+// 返回值: 颜色列表
+func (c Config) glyphColors(n int) []color.RGBA {
+    if len(c.Palette) == 0 {
+        return randomGlyphColors(c.Rand, n)
+    }
+    cols := make([]color.RGBA, n)
+    for i := range cols {
+        cols[i] = color.RGBAModel.Convert(c.Palette[c.Rand.Intn(len(c.Palette))]).(color.RGBA)
+    }
+    return cols
+}
+
+// glyphStyles 为每个字符生成颜色与形变样式
+// 参数 n: 字符数量
+// 返回值: 样式列表
+func (c Config) glyphStyles(n int) []glyphStyle {
+    cols := c.glyphColors(n)
+    styles := make([]glyphStyle, n)
+    for i := range styles {
+        styles[i] = glyphStyle{
+            col:   cols[i],
+            angle: (c.Rand.Float64()*2 - 1) * c.Rotation,
+            shear: (c.Rand.Float64()*2 - 1) * c.Shear,
+        }
+    }
+    return styles
+}
+
+// paint 将背景绘制到图像
+// 参数 img: 目标图像
+// 返回值: 无
+func (bg Background) paint(img *image.RGBA) {
+    b := img.Bounds()
+    switch bg.kind {
+    case 1:
+        fillGradient(img, bg.from, bg.to)
+    case 2:
+        sb := bg.img.Bounds()
+        for y := 0; y < b.Dy(); y++ {
+            sy := sb.Min.Y + y*sb.Dy()/b.Dy()
+            for x := 0; x < b.Dx(); x++ {
+                sx := sb.Min.X + x*sb.Dx()/b.Dx()
+                img.Set(b.Min.X+x, b.Min.Y+y, bg.img.At(sx, sy))
+            }
+        }
+    default:
+        col := bg.from
+        if col == (color.RGBA{}) {
+            col = color.RGBA{255, 255, 255, 255}
+        }
+        fillRect(img, b.Min.X, b.Min.Y, b.Max.X, b.Max.Y, col)
+    }
+}
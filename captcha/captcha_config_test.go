@@ -0,0 +1,97 @@
+package captcha
+
+import (
+    "bytes"
+    "image"
+    "image/color"
+    "image/png"
+    "testing"
+)
+
+// TestNewConfig_Options 测试：选项按顺序应用在默认配置之上
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：未设置的字段保留默认值；WithGIF 同时切换输出格式
+func TestNewConfig_Options(t *testing.T) {
+    c := NewConfig(WithSize(200, 70), WithNoise(1, 2), WithWave(-1, 0), WithRotation(15, -1), WithFontScale(1.5), WithGIF(GIFOptions{Frames: 3}))
+    if c.Width != 200 || c.Height != 70 { t.Fatalf("size mismatch: %dx%d", c.Width, c.Height) }
+    if c.NoiseLines != 1 || c.NoiseDots != 2 { t.Fatalf("noise mismatch: %d/%d", c.NoiseLines, c.NoiseDots) }
+    if c.Rotation != 15 || c.Shear != -1 || c.FontScale != 1.5 { t.Fatalf("style mismatch: %+v", c) }
+    if c.Format != FormatGIF || c.GIF.Frames != 3 { t.Fatalf("format mismatch: %v %+v", c.Format, c.GIF) }
+    d := NewConfig()
+    if d.Width != 160 || d.Height != 60 || d.NoiseLines != 4 || d.NoiseDots != 120 || d.Format != FormatPNG { t.Fatalf("default mismatch: %+v", d) }
+    if err := c.normalize(); err != nil { t.Fatalf("normalize error: %v", err) }
+    if c.WaveAmplitude != 0 || c.Shear != 0 || c.WaveFrequency != 2.0 { t.Fatalf("normalize mismatch: %+v", c) }
+}
+
+// TestGenerateText_FontScaleOutOfRange 测试：字号缩放比例超出范围返回错误而非截断
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：0 使用默认 1；负数与大于 4 均报错
+func TestGenerateText_FontScaleOutOfRange(t *testing.T) {
+    if _, err := GenerateText("Ab3X", WithFontScale(0)); err != nil { t.Fatalf("default scale error: %v", err) }
+    for _, s := range []float64{-1, 4.5} {
+        if _, err := GenerateText("Ab3X", WithFontScale(s)); err == nil { t.Fatalf("expected error for scale %v", s) }
+    }
+}
+
+// TestGenerateDigits_BackgroundAndPalette 测试：纯色背景与调色板生效
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：无噪声、无波纹的数字图片中只应出现背景色与调色板颜色
+func TestGenerateDigits_BackgroundAndPalette(t *testing.T) {
+    bg := color.RGBA{10, 20, 30, 255}
+    fg := color.RGBA{250, 200, 0, 255}
+    b, err := GenerateDigits("2468", WithBackground(SolidBackground(bg)), WithPalette(fg), WithNoise(0, 0), WithWave(-1, 0))
+    if err != nil { t.Fatalf("GenerateDigits error: %v", err) }
+    img, err := png.Decode(bytes.NewReader(b))
+    if err != nil { t.Fatalf("png decode error: %v", err) }
+    seenFG := false
+    for y := 0; y < img.Bounds().Dy(); y++ {
+        for x := 0; x < img.Bounds().Dx(); x++ {
+            c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+            if c == fg { seenFG = true; continue }
+            if c != bg { t.Fatalf("unexpected color at (%d,%d): %v", x, y, c) }
+        }
+    }
+    if !seenFG { t.Fatalf("expected palette color in image") }
+}
+
+// TestGenerateText_GradientAndImageBackground 测试：渐变与图片背景
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：关闭噪声与波纹后检查左上角像素来自背景；背景纹理线位置随机且每条线在首行只占一个像素，
+// 因此跳过纹理色取首行前 8 个像素中第一个背景像素（160 宽时纹理线仅 6 条，必有背景像素）
+func TestGenerateText_GradientAndImageBackground(t *testing.T) {
+    texture := color.RGBA{230, 230, 230, 255}
+    corner := func(b []byte) color.RGBA {
+        img, err := png.Decode(bytes.NewReader(b))
+        if err != nil { t.Fatalf("png decode error: %v", err) }
+        for x := 0; x < 8; x++ {
+            if c := color.RGBAModel.Convert(img.At(x, 0)).(color.RGBA); c != texture { return c }
+        }
+        t.Fatalf("no background pixel in first row")
+        return color.RGBA{}
+    }
+    b, err := GenerateText("Ab3X", WithBackground(GradientBackground(color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255})), WithNoise(0, 0), WithWave(-1, 0))
+    if err != nil { t.Fatalf("GenerateText error: %v", err) }
+    if c := corner(b); c.R < 200 || c.B > 60 { t.Fatalf("gradient corner mismatch: %v", c) }
+
+    src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+    fillRect(src, 0, 0, 4, 4, color.RGBA{0, 160, 0, 255})
+    b, err = GenerateText("Ab3X", WithBackground(ImageBackground(src)), WithNoise(0, 0), WithWave(-1, 0))
+    if err != nil { t.Fatalf("GenerateText error: %v", err) }
+    if c := corner(b); c.G < 120 || c.R > 60 || c.B > 60 { t.Fatalf("image corner mismatch: %v", c) }
+}
+
+// TestGenerateText_GIFFormat 测试：Config 输出 GIF 动图
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：WithGIF 指定帧数→解码后检查帧数与尺寸
+func TestGenerateText_GIFFormat(t *testing.T) {
+    b, err := GenerateText("Ab3X", WithSize(120, 40), WithGIF(GIFOptions{Frames: 3}))
+    if err != nil { t.Fatalf("GenerateText error: %v", err) }
+    g := decodeGIF(t, b)
+    if len(g.Image) != 3 { t.Fatalf("frame count mismatch: got=%d want=3", len(g.Image)) }
+    if g.Image[0].Bounds().Dx() != 120 || g.Image[0].Bounds().Dy() != 40 { t.Fatalf("bounds mismatch: %v", g.Image[0].Bounds()) }
+}
@@ -0,0 +1,59 @@
+package captcha
+
+import (
+    "bytes"
+    "encoding/base64"
+)
+
+// 本文件提供 base64 数据URI输出（data:<mime>;base64,...），前端可直接用作 <img src> 或 <audio src>，
+// 无需单独的图片接口。MIME 类型按文件头识别，适用于本包生成的 PNG、JPEG、GIF 与 WAV。
+
+// DataURI 将图片或音频字节编码为 base64 数据URI
+// 参数 b: 图片或音频字节
+// 返回值: 数据URI字符串（无法识别格式时 MIME 为 application/octet-stream）
+func DataURI(b []byte) string {
+    return "data:" + sniffMIME(b) + ";base64," + base64.StdEncoding.EncodeToString(b)
+}
+
+// GenerateTextDataURI 按选项生成文本图片验证码并返回数据URI
+// 参数 text: 验证码文本
+// 参数 opts: 选项（如 WithJPEG(75) 减小体积）
+// 返回值: 数据URI与错误
+func GenerateTextDataURI(text string, opts ...Option) (string, error) {
+    b, err := GenerateText(text, opts...)
+    if err != nil {
+        return "", err
+    }
+    return DataURI(b), nil
+}
+
+// GenerateDigitsDataURI 按选项生成数字图片验证码并返回数据URI
+// 参数 code: 数字验证码
+// 参数 opts: 选项
+// 返回值: 数据URI与错误
+func GenerateDigitsDataURI(code string, opts ...Option) (string, error) {
+    b, err := GenerateDigits(code, opts...)
+    if err != nil {
+        return "", err
+    }
+    return DataURI(b), nil
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// sniffMIME 按文件头识别 MIME 类型
+// 参数 b: 文件字节
+// 返回值: MIME 类型
+func sniffMIME(b []byte) string {
+    switch {
+    case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+        return FormatPNG.MIMEType()
+    case bytes.HasPrefix(b, []byte("\xff\xd8\xff")):
+        return FormatJPEG.MIMEType()
+    case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+        return FormatGIF.MIMEType()
+    case len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+        return "audio/wav"
+    }
+    return "application/octet-stream"
+}
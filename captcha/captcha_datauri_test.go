@@ -0,0 +1,52 @@
+package captcha
+
+import (
+    "bytes"
+    "encoding/base64"
+    "image/jpeg"
+    "strings"
+    "testing"
+)
+
+// TestGenerate_JPEGFormat 测试：JPEG 输出可解码、尺寸正确且质量参数生效
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：同一种子下低质量 JPEG 应小于高质量 JPEG；质量超出范围返回错误
+func TestGenerate_JPEGFormat(t *testing.T) {
+    low, err := GenerateText("Ab3X", WithSeed(1), WithJPEG(30))
+    if err != nil { t.Fatalf("GenerateText error: %v", err) }
+    img, err := jpeg.Decode(bytes.NewReader(low))
+    if err != nil { t.Fatalf("jpeg decode error: %v", err) }
+    if img.Bounds().Dx() != 160 || img.Bounds().Dy() != 60 { t.Fatalf("bounds mismatch: %v", img.Bounds()) }
+    high, _ := GenerateText("Ab3X", WithSeed(1), WithJPEG(95))
+    if len(low) >= len(high) { t.Fatalf("expected quality 30 to be smaller than 95: %d >= %d", len(low), len(high)) }
+    if _, err := GenerateDigits("1234", WithJPEG(0)); err != nil { t.Fatalf("default quality error: %v", err) }
+    if _, err := GenerateDigits("1234", WithJPEG(101)); err == nil { t.Fatalf("expected error for quality 101") }
+    if _, err := GenerateDigits("1234", WithFormat(Format(99))); err == nil { t.Fatalf("expected error for unknown format") }
+}
+
+// TestDataURI 测试：数据URI的 MIME 识别与内容往返
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestDataURI(t *testing.T) {
+    wav, _ := GenerateDigitAudioWAV("12", AudioOptions{})
+    cases := map[string][]Option{
+        "data:image/png;base64,":  nil,
+        "data:image/jpeg;base64,": {WithJPEG(70)},
+        "data:image/gif;base64,":  {WithGIF(GIFOptions{Frames: 2})},
+    }
+    for prefix, opts := range cases {
+        uri, err := GenerateDigitsDataURI("2468", opts...)
+        if err != nil { t.Fatalf("GenerateDigitsDataURI error: %v", err) }
+        if !strings.HasPrefix(uri, prefix) { t.Fatalf("prefix mismatch: want %q got %.30q", prefix, uri) }
+    }
+    uri, err := GenerateTextDataURI("Ab3X", WithSeed(3))
+    if err != nil { t.Fatalf("GenerateTextDataURI error: %v", err) }
+    raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+    if err != nil { t.Fatalf("base64 decode error: %v", err) }
+    want, _ := GenerateText("Ab3X", WithSeed(3))
+    if !bytes.Equal(raw, want) { t.Fatalf("data uri payload mismatch") }
+    if !strings.HasPrefix(DataURI(wav), "data:audio/wav;base64,") { t.Fatalf("wav mime mismatch") }
+    if !strings.HasPrefix(DataURI([]byte("xyz")), "data:application/octet-stream;base64,") { t.Fatalf("unknown mime mismatch") }
+    if _, err := GenerateTextDataURI(""); err == nil { t.Fatalf("expected error for empty text") }
+}
@@ -0,0 +1,235 @@
+package captcha
+
+import (
+    "time"
+
+    "github.com/QinWeisWord/go_utils/kvcache"
+)
+
+// 本文件提供验证码难度预设与自适应加难策略。
+// 预设统一决定尺寸、长度、字符集、扭曲、重叠与噪声，避免各调用方零散调参；
+// 自适应策略按客户端键（如IP、账号）累计校验失败次数，失败越多签发的验证码越难，校验成功后复位。
+
+// Difficulty 难度等级
+type Difficulty int
+
+const (
+    DifficultyEasy     Difficulty = iota // 简单：4位数字，轻微扭曲
+    DifficultyMedium                     // 中等：5位大写字母与数字，默认扭曲
+    DifficultyHard                       // 困难：6位大小写字母与数字，字符重叠，噪声加重
+    DifficultyParanoid                   // 极难：7位大小写字母与数字，强扭曲与重叠，高密度噪声
+)
+
+// Preset 难度预设
+// 结构体字段解释：
+// - Width, Height: 图片尺寸（随长度加宽，保证字符不被裁剪）
+// - Length: 验证码长度
+// - Alphabet: 字符集（均已剔除 O/0/I/1/l；含大小写的等级另剔除大小写字形相近的小写 c/o/s/v/w/x/z）
+// - NoiseLines, NoiseDots: 干扰线与干扰点数量
+// - WaveAmplitude: 波纹振幅（像素）
+// - Rotation, Shear: 字符最大旋转角度（度）与最大水平错切因子
+// - Overlap: 相邻字符重叠比例
+// - FontScale: 字号缩放比例
+type Preset struct {
+    Width         int
+    Height        int
+    Length        int
+    Alphabet      string
+    NoiseLines    int
+    NoiseDots     int
+    WaveAmplitude int
+    Rotation      float64
+    Shear         float64
+    Overlap       float64
+    FontScale     float64
+}
+
+// caseAmbiguousLower 大小写仅字号不同、扭曲缩放后难以区分的小写字母（保留对应大写）
+const caseAmbiguousLower = "cosvwxz"
+
+// presets 各难度等级的预设（下标即 Difficulty）
+var presets = [...]Preset{
+    DifficultyEasy:     {Width: 140, Height: 50, Length: 4, Alphabet: BuildAlphabet(false, false, true, true, ""), NoiseLines: 2, NoiseDots: 40, WaveAmplitude: 1, Rotation: 5, Shear: 0.05, Overlap: 0, FontScale: 0.75},
+    DifficultyMedium:   {Width: 170, Height: 56, Length: 5, Alphabet: BuildAlphabet(true, false, true, true, ""), NoiseLines: 4, NoiseDots: 120, WaveAmplitude: 2, Rotation: 8, Shear: 0.12, Overlap: 0.1, FontScale: 0.68},
+    DifficultyHard:     {Width: 210, Height: 60, Length: 6, Alphabet: BuildAlphabet(true, true, true, true, caseAmbiguousLower), NoiseLines: 6, NoiseDots: 220, WaveAmplitude: 3, Rotation: 15, Shear: 0.2, Overlap: 0.2, FontScale: 0.64},
+    DifficultyParanoid: {Width: 240, Height: 64, Length: 7, Alphabet: BuildAlphabet(true, true, true, true, caseAmbiguousLower), NoiseLines: 9, NoiseDots: 350, WaveAmplitude: 4, Rotation: 22, Shear: 0.28, Overlap: 0.3, FontScale: 0.6},
+}
+
+// String 返回难度名称
+// 参数: 无
+// 返回值: "easy"/"medium"/"hard"/"paranoid"，未知等级返回 "unknown"
+func (d Difficulty) String() string {
+    switch d {
+    case DifficultyEasy:
+        return "easy"
+    case DifficultyMedium:
+        return "medium"
+    case DifficultyHard:
+        return "hard"
+    case DifficultyParanoid:
+        return "paranoid"
+    }
+    return "unknown"
+}
+
+// Preset 返回难度等级对应的预设
+// 参数: 无
+// 返回值: 预设副本（可按需修改后使用）；超出范围的等级按最近的有效等级处理
+func (d Difficulty) Preset() Preset {
+    return presets[clampDifficulty(d)]
+}
+
+// Options 将预设转换为渲染选项
+// 参数: 无
+// 返回值: 选项列表（可继续追加 WithFonts、WithFormat 等，后追加的选项覆盖预设）
+func (p Preset) Options() []Option {
+    return []Option{
+        WithSize(p.Width, p.Height),
+        WithNoise(p.NoiseLines, p.NoiseDots),
+        WithWave(p.WaveAmplitude, 0),
+        WithRotation(p.Rotation, p.Shear),
+        WithOverlap(p.Overlap),
+        WithFontScale(p.FontScale),
+    }
+}
+
+// Generate 按预设生成随机验证码并渲染图片
+// 参数 opts: 追加的渲染选项（覆盖预设，如 WithFonts、WithSeed）
+// 返回值: 验证码文本、图片字节与错误
+func (p Preset) Generate(opts ...Option) (code string, img []byte, err error) {
+    code, err = GenerateCodeString(p.Length, p.Alphabet)
+    if err != nil {
+        return "", nil, err
+    }
+    img, err = GenerateText(code, append(p.Options(), opts...)...)
+    if err != nil {
+        return "", nil, err
+    }
+    return code, img, nil
+}
+
+// GenerateWithPreset 按难度预设生成一个新的验证码
+// 参数 p: 难度预设（通常来自 Difficulty.Preset 或 AdaptivePolicy.Preset）
+// 返回值: 验证码ID、图片字节与错误
+// 关键步骤：长度、字符集、尺寸与噪声取自预设（忽略 ManagerOptions 中的对应字段及 Render/Challenge），
+// 字体仍使用 FontBytes；答案的保存与校验与 Generate 相同
+func (m *Manager) GenerateWithPreset(p Preset) (id string, img []byte, err error) {
+    var opts []Option
+    if m.opt.FontBytes != nil { opts = append(opts, WithFonts(m.opt.FontBytes)) }
+    code, img, err := p.Generate(opts...)
+    if err != nil {
+        return "", nil, err
+    }
+    return m.issue(code, img)
+}
+
+// AdaptiveOptions 自适应难度策略选项
+// 结构体字段解释：
+// - Base: 无失败记录时的初始难度
+// - Max: 最高难度；零值（DifficultyEasy）视为未设置，默认 DifficultyParanoid；低于 Base 时按 Base 处理
+// - NoEscalation: 是否固定为 Base 不随失败升级（等价于 Max 等于 Base，可表达 Base 为 DifficultyEasy 的情形）
+// - Step: 每累计多少次失败提升一级；<=0 时默认 3
+// - Window: 失败计数的保留时长（每次失败顺延，期间无新失败则自动清零）；<=0 时默认 15 分钟
+type AdaptiveOptions struct {
+    Base         Difficulty
+    Max          Difficulty
+    NoEscalation bool
+    Step         int
+    Window       time.Duration
+}
+
+// AdaptivePolicy 自适应难度策略（并发安全）
+// 结构体字段解释：
+// - opt: 归一化后的选项
+// - failures: 各客户端键的失败次数（带滑动过期）
+type AdaptivePolicy struct {
+    opt      AdaptiveOptions
+    failures *kvcache.KVCache[string, int]
+}
+
+// NewAdaptivePolicy 创建自适应难度策略
+// 参数 opt: 策略选项（零值字段使用默认值）
+// 返回值: 策略指针（使用完毕需调用 Close）
+func NewAdaptivePolicy(opt AdaptiveOptions) *AdaptivePolicy {
+    opt.Base = clampDifficulty(opt.Base)
+    opt.Max = clampDifficulty(opt.Max)
+    if opt.Max == DifficultyEasy { opt.Max = DifficultyParanoid }
+    if opt.Max < opt.Base || opt.NoEscalation { opt.Max = opt.Base }
+    if opt.Step <= 0 { opt.Step = 3 }
+    if opt.Window <= 0 { opt.Window = 15 * time.Minute }
+    return &AdaptivePolicy{
+        opt:      opt,
+        failures: kvcache.NewWithOptions(kvcache.Options[string, int]{DefaultTTL: opt.Window, CleanupInterval: time.Minute}),
+    }
+}
+
+// Level 返回客户端键当前的难度等级
+// 参数 key: 客户端键（如IP、账号）
+// 返回值: Base + 失败次数/Step，不超过 Max
+func (p *AdaptivePolicy) Level(key string) Difficulty {
+    n, _ := p.failures.Get(key)
+    return p.levelFor(n)
+}
+
+// Preset 返回客户端键当前难度对应的预设
+// 参数 key: 客户端键
+// 返回值: 难度预设
+func (p *AdaptivePolicy) Preset(key string) Preset {
+    return p.Level(key).Preset()
+}
+
+// RecordFailure 记录一次校验失败
+// 参数 key: 客户端键
+// 返回值: 记录后的难度等级
+// 关键步骤：原子递增并顺延过期时间，持续失败的客户端计数不会在窗口内被清零
+func (p *AdaptivePolicy) RecordFailure(key string) Difficulty {
+    n, _ := p.failures.UpdateWithTTL(key, p.opt.Window, func(old int, _ bool) (int, bool) { return old + 1, true })
+    return p.levelFor(n)
+}
+
+// RecordSuccess 记录一次校验成功，清零该客户端键的失败次数
+// 参数 key: 客户端键
+// 返回值: 无
+func (p *AdaptivePolicy) RecordSuccess(key string) {
+    p.failures.Delete(key)
+}
+
+// Verify 一次性校验答案并按结果更新客户端键的失败计数
+// 参数 m: 验证码管理器
+// 参数 key: 客户端键
+// 参数 id: 验证码ID
+// 参数 answer: 用户提交的答案
+// 返回值: 是否校验通过
+func (p *AdaptivePolicy) Verify(m *Manager, key, id, answer string) bool {
+    if m.Verify(id, answer, true) {
+        p.RecordSuccess(key)
+        return true
+    }
+    p.RecordFailure(key)
+    return false
+}
+
+// Close 释放失败计数缓存
+// 参数: 无
+// 返回值: 无
+func (p *AdaptivePolicy) Close() { p.failures.Close() }
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// levelFor 按失败次数计算难度等级
+// 参数 n: 失败次数
+// 返回值: 难度等级
+func (p *AdaptivePolicy) levelFor(n int) Difficulty {
+    d := p.opt.Base + Difficulty(n/p.opt.Step)
+    if d > p.opt.Max { d = p.opt.Max }
+    return d
+}
+
+// clampDifficulty 将难度等级限制在有效范围内
+// 参数 d: 难度等级
+// 返回值: 有效的难度等级
+func clampDifficulty(d Difficulty) Difficulty {
+    if d < DifficultyEasy { return DifficultyEasy }
+    if d > DifficultyParanoid { return DifficultyParanoid }
+    return d
+}
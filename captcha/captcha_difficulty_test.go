@@ -0,0 +1,118 @@
+package captcha
+
+import (
+    "bytes"
+    "errors"
+    "image/png"
+    "strings"
+    "testing"
+    "time"
+    "unicode"
+)
+
+// failingStore 写入总是失败的存储（用于测试 ErrStore 包装）
+type failingStore struct{}
+
+// Set 总是返回错误
+func (failingStore) Set(string, string, time.Duration) error { return errors.New("store down") }
+
+// Get 总是返回不存在
+func (failingStore) Get(string) (string, bool, error) { return "", false, nil }
+
+// Delete 不做任何事
+func (failingStore) Delete(string) error { return nil }
+
+// TestDifficulty_Presets 测试：预设随难度单调加难，生成结果符合长度、字符集与尺寸
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：逐级比较长度、噪声、扭曲与重叠；超出范围的等级按最近有效等级处理
+func TestDifficulty_Presets(t *testing.T) {
+    prev := Preset{}
+    for d := DifficultyEasy; d <= DifficultyParanoid; d++ {
+        p := d.Preset()
+        if p.Length <= prev.Length || p.NoiseDots <= prev.NoiseDots || p.Rotation <= prev.Rotation || p.Overlap < prev.Overlap { t.Fatalf("%v preset not harder than previous: %+v", d, p) }
+        prev = p
+        code, b, err := p.Generate(WithSeed(1))
+        if err != nil { t.Fatalf("%v Generate error: %v", d, err) }
+        if len(code) != p.Length { t.Fatalf("%v code length mismatch: %q", d, code) }
+        for _, r := range code {
+            if !strings.ContainsRune(p.Alphabet, r) { t.Fatalf("%v code %q outside alphabet", d, code) }
+        }
+        for _, r := range "cosvwxz" {
+            if strings.ContainsRune(p.Alphabet, r) && strings.ContainsRune(p.Alphabet, unicode.ToUpper(r)) { t.Fatalf("%v alphabet keeps case-ambiguous pair %c/%c", d, r, unicode.ToUpper(r)) }
+        }
+        img, err := png.Decode(bytes.NewReader(b))
+        if err != nil { t.Fatalf("png decode error: %v", err) }
+        if img.Bounds().Dx() != p.Width || img.Bounds().Dy() != p.Height { t.Fatalf("%v bounds mismatch: %v", d, img.Bounds()) }
+    }
+    if DifficultyHard.String() != "hard" || Difficulty(9).String() != "unknown" { t.Fatalf("String mismatch") }
+    if Difficulty(9).Preset().Length != DifficultyParanoid.Preset().Length || Difficulty(-1).Preset().Length != DifficultyEasy.Preset().Length { t.Fatalf("out-of-range level not clamped") }
+}
+
+// TestGenerateText_Overlap 测试：字符重叠比例的范围校验
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestGenerateText_Overlap(t *testing.T) {
+    if _, err := GenerateText("Ab3X", WithOverlap(0.3)); err != nil { t.Fatalf("overlap error: %v", err) }
+    for _, v := range []float64{-0.1, 0.7} {
+        if _, err := GenerateText("Ab3X", WithOverlap(v)); err == nil { t.Fatalf("expected error for overlap %v", v) }
+    }
+}
+
+// TestAdaptivePolicy 测试：连续失败逐级提升难度，成功后复位，且不超过 Max；Max 等于 Base 或 NoEscalation 时不加难
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：经 Manager 生成预设验证码，错误答案累计失败，正确答案清零；不同客户端键互不影响
+func TestAdaptivePolicy(t *testing.T) {
+    m := NewManager(ManagerOptions{})
+    defer m.Close()
+    p := NewAdaptivePolicy(AdaptiveOptions{Base: DifficultyMedium, Max: DifficultyHard, Step: 2})
+    defer p.Close()
+
+    if p.Level("ip1") != DifficultyMedium { t.Fatalf("base level mismatch: %v", p.Level("ip1")) }
+    for i := 0; i < 2; i++ {
+        id, _, err := m.GenerateWithPreset(p.Preset("ip1"))
+        if err != nil { t.Fatalf("GenerateWithPreset error: %v", err) }
+        if p.Verify(m, "ip1", id, "wrong") { t.Fatalf("wrong answer should fail") }
+    }
+    if p.Level("ip1") != DifficultyHard { t.Fatalf("level after 2 failures mismatch: %v", p.Level("ip1")) }
+    for i := 0; i < 4; i++ {
+        p.RecordFailure("ip1")
+    }
+    if p.Level("ip1") != DifficultyHard { t.Fatalf("level should stop at Max: %v", p.Level("ip1")) }
+    if p.Level("ip2") != DifficultyMedium { t.Fatalf("other key affected: %v", p.Level("ip2")) }
+
+    id, _, err := m.GenerateWithPreset(p.Preset("ip1"))
+    if err != nil { t.Fatalf("GenerateWithPreset error: %v", err) }
+    answer, _, _ := m.store.Get(id)
+    if len(answer) != DifficultyHard.Preset().Length { t.Fatalf("answer length mismatch: %q", answer) }
+    if !p.Verify(m, "ip1", id, answer) { t.Fatalf("correct answer should pass") }
+    if p.Level("ip1") != DifficultyMedium { t.Fatalf("level not reset after success: %v", p.Level("ip1")) }
+
+    d := NewAdaptivePolicy(AdaptiveOptions{})
+    defer d.Close()
+    for i := 0; i < 20; i++ {
+        d.RecordFailure("k")
+    }
+    if d.Level("k") != DifficultyParanoid { t.Fatalf("default max mismatch: %v", d.Level("k")) }
+
+    fixed := NewAdaptivePolicy(AdaptiveOptions{Base: DifficultyHard, Max: DifficultyHard})
+    defer fixed.Close()
+    easy := NewAdaptivePolicy(AdaptiveOptions{Base: DifficultyEasy, Max: DifficultyEasy, NoEscalation: true})
+    defer easy.Close()
+    for i := 0; i < 20; i++ {
+        fixed.RecordFailure("k")
+        easy.RecordFailure("k")
+    }
+    if fixed.Level("k") != DifficultyHard || easy.Level("k") != DifficultyEasy { t.Fatalf("no-escalation mismatch: %v %v", fixed.Level("k"), easy.Level("k")) }
+}
+
+// TestGenerateWithPreset_StoreError 测试：存储失败时与 Generate 一样包装为 ErrStore
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestGenerateWithPreset_StoreError(t *testing.T) {
+    m := NewManager(ManagerOptions{Store: failingStore{}})
+    defer m.Close()
+    if _, _, err := m.Generate(); !errors.Is(err, ErrStore) { t.Fatalf("Generate: expected ErrStore, got %v", err) }
+    if _, _, err := m.GenerateWithPreset(DifficultyEasy.Preset()); !errors.Is(err, ErrStore) { t.Fatalf("GenerateWithPreset: expected ErrStore, got %v", err) }
+}
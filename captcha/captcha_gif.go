@@ -0,0 +1,116 @@
+package captcha
+
+import (
+    "bytes"
+    "image"
+    "image/color/palette"
+    "image/draw"
+    "image/gif"
+    "math"
+    mrand "math/rand"
+    "time"
+)
+
+// 本文件提供动图（GIF）验证码：在多帧之间保持字符颜色与形变不变，仅让干扰点闪烁、干扰线移动、
+// 字符位置轻微抖动、整体波纹流动。人眼可借助多帧轻松辨认，而单帧截图 OCR 的干扰明显增加。
+
+// GIFOptions 动图验证码选项
+// 结构体字段解释：
+// - Frames: 帧数；<=0 时默认 8，最多 60
+// - Delay: 每帧显示时长；<=0 时默认 120ms（GIF 精度为 10ms，最少 20ms）
+// - LoopCount: 循环次数；0 表示无限循环，-1 表示只播放一次（同 image/gif）
+type GIFOptions struct {
+    Frames    int
+    Delay     time.Duration
+    LoopCount int
+}
+
+// GenerateDigitCodeImageGIF 根据数字验证码生成GIF动图（7段数码管样式）
+// 参数 code: 数字验证码字符串（仅支持'0'-'9'）
+// 参数 width: 图片宽度（像素，建议>= 100）
+// 参数 height: 图片高度（像素，建议>= 36）
+// 参数 noiseLines: 干扰线数量（建议 2~8）
+// 参数 noiseDots: 每帧干扰点数量（建议 50~300）
+// 参数 opt: 动图选项（帧数、延时、循环次数）
+// 返回值: GIF编码的图片字节与错误；参数校验规则同 GenerateDigitCodeImagePNG
+// 关键步骤：固定每个数字颜色，逐帧重新抖动数字位置、移动干扰线、刷新干扰点并施加流动波纹。
+func GenerateDigitCodeImageGIF(code string, width, height int, noiseLines, noiseDots int, opt GIFOptions) ([]byte, error) {
+    return NewConfig(WithSize(width, height), WithNoise(noiseLines, noiseDots), WithGIF(opt)).GenerateDigits(code)
+}
+
+// GenerateTextCaptchaImageGIF 生成文本GIF动图验证码（支持字母与自定义字体）
+// 参数 text: 验证码文本内容（建议字母数字混合）
+// 参数 width: 图片宽度（像素，建议>=120）
+// 参数 height: 图片高度（像素，建议>=36）
+// 参数 noiseLines: 干扰线数量（建议 2~8）
+// 参数 noiseDots: 每帧干扰点数量（建议 50~300）
+// 参数 fontBytes: 可选的 TTF 字体字节；为空则使用默认矢量字体
+// 参数 opt: 动图选项（帧数、延时、循环次数）
+// 返回值: GIF编码的图片字节与错误；参数校验规则同 GenerateTextCaptchaImagePNG
+// 关键步骤：字体与字符样式（颜色/旋转/错切）全帧共享，逐帧重新抖动字符位置、移动干扰线并推进波纹相位。
+func GenerateTextCaptchaImageGIF(text string, width, height int, noiseLines, noiseDots int, fontBytes []byte, opt GIFOptions) ([]byte, error) {
+    if err := checkTextCaptchaArgs(text, width, height); err != nil {
+        return nil, err
+    }
+    c := NewConfig(WithSize(width, height), WithNoise(noiseLines, noiseDots), WithGIF(opt))
+    if err := c.normalize(); err != nil {
+        return nil, err
+    }
+    return c.renderText(text, repeatFace(textFace(fontBytes, height, 1.0), len([]rune(text))))
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// encodeCaptchaGIF 逐帧渲染并编码GIF
+// 参数 rng: 随机源（干扰线速度与初始相位）
+// 参数 lines: 首帧干扰线
+// 参数 opt: 动图选项
+// 参数 frame: 单帧渲染函数（入参为本帧干扰线与波纹相位）
+// 返回值: GIF字节与错误
+// 关键步骤：干扰线按各自速度逐帧平移；波纹相位在一个循环内推进一整周，保证首尾衔接；
+// 帧图像量化到 Plan9 调色板后编码。
+func encodeCaptchaGIF(rng *mrand.Rand, lines []noiseLine, opt GIFOptions, frame func(lines []noiseLine, phase float64) *image.RGBA) ([]byte, error) {
+    frames, delay := normalizeGIFOptions(opt)
+    type velocity struct{ dx, dy int }
+    vel := make([]velocity, len(lines))
+    for i := range vel {
+        vel[i] = velocity{rng.Intn(7) - 3, rng.Intn(7) - 3}
+    }
+    phase0 := rng.Float64() * 2 * math.Pi
+
+    anim := &gif.GIF{LoopCount: opt.LoopCount}
+    moved := make([]noiseLine, len(lines))
+    for f := 0; f < frames; f++ {
+        for i, l := range lines {
+            l.x0 += vel[i].dx * f
+            l.x1 += vel[i].dx * f
+            l.y0 += vel[i].dy * f
+            l.y1 += vel[i].dy * f
+            moved[i] = l
+        }
+        img := frame(moved, phase0+2*math.Pi*float64(f)/float64(frames))
+        pal := image.NewPaletted(img.Bounds(), palette.Plan9)
+        draw.Draw(pal, pal.Bounds(), img, image.Point{}, draw.Src)
+        anim.Image = append(anim.Image, pal)
+        anim.Delay = append(anim.Delay, delay)
+    }
+    var buf bytes.Buffer
+    if err := gif.EncodeAll(&buf, anim); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// normalizeGIFOptions 归一化帧数与延时
+// 参数 opt: 动图选项
+// 返回值: 帧数与每帧延时（单位 1/100 秒）
+func normalizeGIFOptions(opt GIFOptions) (frames, delay int) {
+    frames = opt.Frames
+    if frames <= 0 { frames = 8 }
+    if frames > 60 { frames = 60 }
+    d := opt.Delay
+    if d <= 0 { d = 120 * time.Millisecond }
+    delay = int(d / (10 * time.Millisecond))
+    if delay < 2 { delay = 2 }
+    return frames, delay
+}
@@ -0,0 +1,48 @@
+package captcha
+
+import (
+    "bytes"
+    "image/gif"
+    "testing"
+    "time"
+)
+
+// decodeGIF 解码GIF动图，解码失败时测试直接失败
+// 参数 t: 测试上下文
+// 参数 b: GIF字节切片
+// 返回值: 解码后的动图
+func decodeGIF(t *testing.T, b []byte) *gif.GIF {
+    t.Helper()
+    g, err := gif.DecodeAll(bytes.NewReader(b))
+    if err != nil { t.Fatalf("gif decode error: %v", err) }
+    return g
+}
+
+// TestGenerateTextCaptchaImageGIF_Frames 测试：文本动图帧数、延时与尺寸
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：指定 5 帧、80ms 延时→解码后逐帧检查尺寸与延时；相邻帧内容应不同
+func TestGenerateTextCaptchaImageGIF_Frames(t *testing.T) {
+    b, err := GenerateTextCaptchaImageGIF("Ab3X", 160, 60, 4, 100, nil, GIFOptions{Frames: 5, Delay: 80 * time.Millisecond})
+    if err != nil { t.Fatalf("GenerateTextCaptchaImageGIF error: %v", err) }
+    g := decodeGIF(t, b)
+    if len(g.Image) != 5 { t.Fatalf("frame count mismatch: got=%d want=5", len(g.Image)) }
+    for i, img := range g.Image {
+        if img.Bounds().Dx() != 160 || img.Bounds().Dy() != 60 { t.Fatalf("frame %d bounds mismatch: %v", i, img.Bounds()) }
+        if g.Delay[i] != 8 { t.Fatalf("frame %d delay mismatch: got=%d want=8", i, g.Delay[i]) }
+    }
+    if bytes.Equal(g.Image[0].Pix, g.Image[1].Pix) { t.Fatalf("expected frames to differ") }
+}
+
+// TestGenerateDigitCodeImageGIF_Defaults 测试：数字动图默认选项与参数校验
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：零值选项使用默认 8 帧、12 厘秒延时、无限循环；非数字内容返回错误
+func TestGenerateDigitCodeImageGIF_Defaults(t *testing.T) {
+    b, err := GenerateDigitCodeImageGIF("2468", 140, 48, 3, 60, GIFOptions{})
+    if err != nil { t.Fatalf("GenerateDigitCodeImageGIF error: %v", err) }
+    g := decodeGIF(t, b)
+    if len(g.Image) != 8 || g.Delay[0] != 12 || g.LoopCount != 0 { t.Fatalf("defaults mismatch: frames=%d delay=%d loop=%d", len(g.Image), g.Delay[0], g.LoopCount) }
+    if _, err := GenerateDigitCodeImageGIF("12A", 140, 48, 3, 60, GIFOptions{}); err == nil { t.Fatalf("expected error for non-digit code") }
+    if _, err := GenerateTextCaptchaImageGIF("", 140, 48, 3, 60, nil, GIFOptions{}); err == nil { t.Fatalf("expected error for empty text") }
+}
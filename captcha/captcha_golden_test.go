@@ -0,0 +1,150 @@
+package captcha
+
+import (
+    "bytes"
+    "flag"
+    "image"
+    "image/color"
+    "image/gif"
+    "image/png"
+    mrand "math/rand"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// update 为 true 时重写 testdata 下的基准图片：go test ./captcha -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden captcha images in testdata")
+
+// goldenCase 基准图片用例
+// 结构体字段解释：
+// - name: 基准文件名（位于 testdata 下）
+// - gen: 使用固定随机源生成图片字节
+type goldenCase struct {
+    name string
+    gen  func() ([]byte, error)
+}
+
+// goldenCases 返回全部基准图片用例
+// 参数: 无
+// 返回值: 用例列表
+func goldenCases() []goldenCase {
+    seeded := func(seed int64) *mrand.Rand { return mrand.New(mrand.NewSource(seed)) }
+    return []goldenCase{
+        {"digits.png", func() ([]byte, error) { return GenerateDigits("20260417", WithSeed(1)) }},
+        {"text.png", func() ([]byte, error) { return GenerateText("Ab3X", WithSeed(2)) }},
+        {"text_styled.png", func() ([]byte, error) {
+            return GenerateText("Qz7k", WithSeed(3), WithSize(200, 70),
+                WithPalette(color.RGBA{20, 60, 160, 255}, color.RGBA{160, 30, 30, 255}),
+                WithBackground(GradientBackground(color.White, color.RGBA{210, 225, 250, 255})),
+                WithWave(3, 2.5), WithRotation(15, 0.2))
+        }},
+        {"digits.gif", func() ([]byte, error) { return GenerateDigits("4821", WithSeed(4), WithGIF(GIFOptions{Frames: 3})) }},
+        {"click.png", func() ([]byte, error) {
+            cc, err := GenerateHanziClickCaptcha(ClickOptions{Alphabet: "ABCDEFGHJKMNPQRSTUVWXYZ", Rand: seeded(5)})
+            if err != nil { return nil, err }
+            return cc.Image, nil
+        }},
+        {"slider.png", func() ([]byte, error) {
+            bg := image.NewRGBA(image.Rect(0, 0, 320, 160))
+            fillGradient(bg, color.RGBA{40, 120, 200, 255}, color.RGBA{220, 180, 60, 255})
+            sp, err := GenerateSliderCaptcha(bg, SliderOptions{Rand: seeded(7)})
+            if err != nil { return nil, err }
+            return sp.Background, nil
+        }},
+        {"math.png", func() ([]byte, error) {
+            _, _, b, err := GenerateMathCaptchaImagePNG(MathOptions{Operators: "+*", Rand: seeded(6)}, 160, 60, 3, 80, nil)
+            return b, err
+        }},
+    }
+}
+
+// TestGolden_Images 测试：固定随机源渲染结果与 testdata 基准图片逐像素一致
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：生成→（-update 时写回基准）→解码双方并逐帧逐像素比较，避免依赖编码器的压缩细节
+func TestGolden_Images(t *testing.T) {
+    for _, tc := range goldenCases() {
+        t.Run(tc.name, func(t *testing.T) {
+            got, err := tc.gen()
+            if err != nil { t.Fatalf("generate error: %v", err) }
+            path := filepath.Join("testdata", tc.name)
+            if *update {
+                if err := os.MkdirAll("testdata", 0o755); err != nil { t.Fatalf("mkdir error: %v", err) }
+                if err := os.WriteFile(path, got, 0o644); err != nil { t.Fatalf("write golden error: %v", err) }
+            }
+            want, err := os.ReadFile(path)
+            if err != nil { t.Fatalf("read golden error (run with -update to create): %v", err) }
+            gotFrames, wantFrames := decodeFrames(t, got), decodeFrames(t, want)
+            if len(gotFrames) != len(wantFrames) { t.Fatalf("frame count mismatch: got=%d want=%d", len(gotFrames), len(wantFrames)) }
+            for i := range gotFrames {
+                if n, at := diffPixels(gotFrames[i], wantFrames[i]); n > 0 { t.Fatalf("frame %d: %d pixels differ, first at %v (run with -update if the change is intended)", i, n, at) }
+            }
+        })
+    }
+}
+
+// TestSeed_Reproducible 测试：相同种子逐字节一致，不同种子结果不同
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：覆盖图片、动图与音频三类输出
+func TestSeed_Reproducible(t *testing.T) {
+    gens := map[string]func(seed int64) ([]byte, error){
+        "text": func(seed int64) ([]byte, error) { return GenerateText("Ab3X", WithSeed(seed)) },
+        "gif":  func(seed int64) ([]byte, error) { return GenerateText("Ab3X", WithSeed(seed), WithGIF(GIFOptions{Frames: 2})) },
+        "audio": func(seed int64) ([]byte, error) {
+            return GenerateDigitAudioWAV("123", AudioOptions{Rand: mrand.New(mrand.NewSource(seed))})
+        },
+    }
+    for name, gen := range gens {
+        a, err := gen(42)
+        if err != nil { t.Fatalf("%s: generate error: %v", name, err) }
+        b, _ := gen(42)
+        c, _ := gen(43)
+        if !bytes.Equal(a, b) { t.Fatalf("%s: same seed produced different output", name) }
+        if bytes.Equal(a, c) { t.Fatalf("%s: different seeds produced identical output", name) }
+    }
+    e1, a1, _ := GenerateMathExpression(MathOptions{Operands: 4, Operators: "+-*/", Rand: mrand.New(mrand.NewSource(7))})
+    e2, a2, _ := GenerateMathExpression(MathOptions{Operands: 4, Operators: "+-*/", Rand: mrand.New(mrand.NewSource(7))})
+    if e1 != e2 || a1 != a2 { t.Fatalf("math: same seed produced %q=%d and %q=%d", e1, a1, e2, a2) }
+}
+
+// decodeFrames 解码 PNG 或 GIF 为帧列表
+// 参数 t: 测试上下文
+// 参数 b: 图片字节
+// 返回值: 帧图像列表（PNG 为单帧）
+func decodeFrames(t *testing.T, b []byte) []image.Image {
+    t.Helper()
+    if bytes.HasPrefix(b, []byte("GIF8")) {
+        g, err := gif.DecodeAll(bytes.NewReader(b))
+        if err != nil { t.Fatalf("gif decode error: %v", err) }
+        frames := make([]image.Image, len(g.Image))
+        for i, p := range g.Image {
+            frames[i] = p
+        }
+        return frames
+    }
+    img, err := png.Decode(bytes.NewReader(b))
+    if err != nil { t.Fatalf("png decode error: %v", err) }
+    return []image.Image{img}
+}
+
+// diffPixels 统计两幅图像不同的像素数
+// 参数 a, b: 待比较图像
+// 返回值: 不同像素数与第一个不同像素的位置（尺寸不同时视为全部不同）
+func diffPixels(a, b image.Image) (int, image.Point) {
+    if a.Bounds() != b.Bounds() {
+        return a.Bounds().Dx() * a.Bounds().Dy(), a.Bounds().Min
+    }
+    n, first := 0, image.Point{}
+    r := a.Bounds()
+    for y := r.Min.Y; y < r.Max.Y; y++ {
+        for x := r.Min.X; x < r.Max.X; x++ {
+            if color.RGBAModel.Convert(a.At(x, y)) != color.RGBAModel.Convert(b.At(x, y)) {
+                if n == 0 { first = image.Pt(x, y) }
+                n++
+            }
+        }
+    }
+    return n, first
+}
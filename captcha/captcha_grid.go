@@ -0,0 +1,340 @@
+package captcha
+
+import (
+    "bytes"
+    "errors"
+    "image"
+    "image/draw"
+    _ "image/gif"
+    _ "image/jpeg"
+    "image/png"
+    "io/fs"
+    "math"
+    mrand "math/rand"
+    "os"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+
+    xdraw "golang.org/x/image/draw"
+)
+
+// 本文件提供图片选择（宫格）验证码：“请选择所有包含 X 的图片”。
+// 图片集按标签组织（目录或 fs.FS 中的 <标签>/<文件>.png|jpg|gif），生成时从目标标签与其他标签中随机取图拼成 N×M 宫格，
+// 每格随机裁剪、缩放、翻转、旋转与调色并叠加噪点，整图再加干扰线与波纹，避免同一张素材的哈希比对。
+// 答案为目标格的序号（从0开始，按行优先），只保存在服务端。
+
+// ImageSet 带标签的图片集合
+// 结构体字段解释：
+// - images: 标签到图片列表的映射
+type ImageSet struct {
+    images map[string][]image.Image
+}
+
+// GridOptions 宫格验证码选项
+// 结构体字段解释：
+// - Rows, Cols: 行数与列数；各自 <=0 时默认 3
+// - CellSize: 单格边长（像素）；<=0 时默认 100
+// - Gap: 格间距（像素）；0 时默认 4，<0 表示无间距
+// - Target: 目标标签；为空时随机选取
+// - MinMatches, MaxMatches: 目标格数量范围；<=0 时默认 2~4（不超过总格数-1）
+// - NoiseLines, NoiseDots: 整图干扰线数量与每格干扰点数量；均为0时默认 3 与 CellSize*CellSize/80
+// - Rand: 随机源；为 nil 时每次生成随机播种，指定后结果可复现（仅用于测试）
+type GridOptions struct {
+    Rows       int
+    Cols       int
+    CellSize   int
+    Gap        int
+    Target     string
+    MinMatches int
+    MaxMatches int
+    NoiseLines int
+    NoiseDots  int
+    Rand       *mrand.Rand
+}
+
+// GridCaptcha 宫格验证码生成结果
+// 结构体字段解释：
+// - Image: 宫格图片（PNG）
+// - Target: 目标标签（用于提示文案，如“请选择所有包含 猫 的图片”）
+// - Rows, Cols: 行数与列数
+// - Answer: 目标格序号（升序，按行优先从0开始；勿下发给前端）
+type GridCaptcha struct {
+    Image  []byte
+    Target string
+    Rows   int
+    Cols   int
+    Answer []int
+}
+
+// NewImageSet 创建空的图片集合
+// 参数: 无
+// 返回值: 图片集合指针
+func NewImageSet() *ImageSet {
+    return &ImageSet{images: make(map[string][]image.Image)}
+}
+
+// LoadImageSet 从文件系统加载图片集合
+// 参数 fsys: 文件系统（如 os.DirFS、embed.FS 或 fstest.MapFS）
+// 返回值: 图片集合与错误（图片解码失败或集合中不足2个标签时返回错误）
+// 关键步骤：遍历全部文件，路径的第一级目录名为标签；仅加载 .png/.jpg/.jpeg/.gif 文件，根目录下的文件忽略
+func LoadImageSet(fsys fs.FS) (*ImageSet, error) {
+    set := NewImageSet()
+    err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() || !isImageFile(p) {
+            return nil
+        }
+        label, _, nested := strings.Cut(p, "/")
+        if !nested {
+            return nil
+        }
+        f, err := fsys.Open(p)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        img, _, err := image.Decode(f)
+        if err != nil {
+            return errors.New("图片解码失败: " + p + ": " + err.Error())
+        }
+        set.Add(label, img)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(set.images) < 2 {
+        return nil, errors.New("图片集合至少需要2个标签")
+    }
+    return set, nil
+}
+
+// LoadImageSetDir 从目录加载图片集合（目录结构同 LoadImageSet）
+// 参数 dir: 目录路径
+// 返回值: 图片集合与错误
+func LoadImageSetDir(dir string) (*ImageSet, error) {
+    return LoadImageSet(os.DirFS(dir))
+}
+
+// Add 向集合添加一张图片
+// 参数 label: 标签
+// 参数 img: 图片
+// 返回值: 无
+func (s *ImageSet) Add(label string, img image.Image) {
+    s.images[label] = append(s.images[label], img)
+}
+
+// Labels 返回全部标签（升序）
+// 参数: 无
+// 返回值: 标签列表
+func (s *ImageSet) Labels() []string {
+    labels := make([]string, 0, len(s.images))
+    for l := range s.images {
+        labels = append(labels, l)
+    }
+    sort.Strings(labels)
+    return labels
+}
+
+// GenerateGridCaptcha 生成宫格图片选择验证码
+// 参数 set: 图片集合
+// 参数 opt: 宫格选项
+// 返回值: 生成结果与错误（集合为空、标签不足、目标标签不存在或选项非法时返回错误）
+// 关键步骤：确定目标标签与目标格数量→随机选取目标格位置→目标格取目标标签图片，其余格取其他标签图片→
+// 每格独立形变与加噪→整图干扰线与波纹→编码为PNG。
+func GenerateGridCaptcha(set *ImageSet, opt GridOptions) (*GridCaptcha, error) {
+    if set == nil || len(set.images) < 2 {
+        return nil, errors.New("图片集合至少需要2个标签")
+    }
+    if opt.Rows <= 0 { opt.Rows = 3 }
+    if opt.Cols <= 0 { opt.Cols = 3 }
+    if opt.CellSize <= 0 { opt.CellSize = 100 }
+    if opt.Gap == 0 { opt.Gap = 4 }
+    if opt.Gap < 0 { opt.Gap = 0 }
+    if opt.NoiseLines == 0 && opt.NoiseDots == 0 {
+        opt.NoiseLines, opt.NoiseDots = 3, opt.CellSize*opt.CellSize/80
+    }
+    n := opt.Rows * opt.Cols
+    if n < 2 || opt.CellSize < 16 {
+        return nil, errors.New("宫格至少需要2格且单格边长不小于16像素")
+    }
+    if opt.MinMatches <= 0 { opt.MinMatches = 2 }
+    if opt.MaxMatches <= 0 { opt.MaxMatches = 4 }
+    if opt.MaxMatches > n-1 { opt.MaxMatches = n - 1 }
+    if opt.MinMatches > opt.MaxMatches { opt.MinMatches = opt.MaxMatches }
+    rng := randOrNew(opt.Rand)
+
+    labels := set.Labels()
+    target := opt.Target
+    if target == "" {
+        target = labels[rng.Intn(len(labels))]
+    }
+    if len(set.images[target]) == 0 {
+        return nil, errors.New("目标标签不存在: " + target)
+    }
+    var others []image.Image
+    for _, l := range labels {
+        if l != target {
+            others = append(others, set.images[l]...)
+        }
+    }
+
+    // 关键步骤：随机排列后取前 k 个作为目标格位置
+    k := opt.MinMatches + rng.Intn(opt.MaxMatches-opt.MinMatches+1)
+    cells := rng.Perm(n)
+    answer := append([]int(nil), cells[:k]...)
+    sort.Ints(answer)
+    isTarget := make([]bool, n)
+    for _, i := range answer {
+        isTarget[i] = true
+    }
+
+    cs, gap := opt.CellSize, opt.Gap
+    w, h := opt.Cols*cs+(opt.Cols+1)*gap, opt.Rows*cs+(opt.Rows+1)*gap
+    img := image.NewRGBA(image.Rect(0, 0, w, h))
+    fillRect(img, 0, 0, w, h, randomGlyphColors(rng, 1)[0])
+    targetImgs := set.images[target]
+    for i := 0; i < n; i++ {
+        src := others[rng.Intn(len(others))]
+        if isTarget[i] {
+            src = targetImgs[rng.Intn(len(targetImgs))]
+        }
+        cell := renderGridCell(rng, src, cs, opt.NoiseDots)
+        at := image.Pt(gap+(i%opt.Cols)*(cs+gap), gap+(i/opt.Cols)*(cs+gap))
+        draw.Draw(img, cell.Bounds().Add(at), cell, image.Point{}, draw.Src)
+    }
+    drawNoiseLines(img, randomNoiseLines(rng, opt.NoiseLines, w, h))
+    img = applyWaveX(img, maxInt(1, cs/40), 1.5, rng.Float64()*2*math.Pi)
+
+    var buf bytes.Buffer
+    if err := png.Encode(&buf, img); err != nil {
+        return nil, err
+    }
+    return &GridCaptcha{Image: buf.Bytes(), Target: target, Rows: opt.Rows, Cols: opt.Cols, Answer: answer}, nil
+}
+
+// VerifyGrid 校验用户选择的格子
+// 参数 answer: 生成时保存的目标格序号
+// 参数 selected: 用户选择的格子序号（顺序无关，重复项视为一次）
+// 返回值: 选择的集合与答案集合完全一致时返回 true（多选或漏选均失败；任一方为空时失败，避免空答案与空提交相等而放行）
+func VerifyGrid(answer, selected []int) bool {
+    if len(answer) == 0 || len(selected) == 0 {
+        return false
+    }
+    want := make(map[int]bool, len(answer))
+    for _, i := range answer {
+        want[i] = true
+    }
+    got := make(map[int]bool, len(selected))
+    for _, i := range selected {
+        if !want[i] {
+            return false
+        }
+        got[i] = true
+    }
+    return len(got) == len(want)
+}
+
+// FormatGridAnswer 将格子序号编码为字符串（升序、逗号分隔），便于保存到 Store
+// 参数 indices: 格子序号
+// 返回值: 如 "1,4,7"
+func FormatGridAnswer(indices []int) string {
+    sorted := append([]int(nil), indices...)
+    sort.Ints(sorted)
+    parts := make([]string, len(sorted))
+    for i, v := range sorted {
+        parts[i] = strconv.Itoa(v)
+    }
+    return strings.Join(parts, ",")
+}
+
+// ParseGridAnswer 解析逗号分隔的格子序号（前端提交或从 Store 读取）
+// 参数 s: 如 "1,4,7"（允许空白；空字符串表示未选择）
+// 返回值: 格子序号与错误（包含非整数或负数时返回错误）
+func ParseGridAnswer(s string) ([]int, error) {
+    var out []int
+    for _, part := range strings.Split(s, ",") {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        v, err := strconv.Atoi(part)
+        if err != nil || v < 0 {
+            return nil, errors.New("格子序号非法: " + part)
+        }
+        out = append(out, v)
+    }
+    return out, nil
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// renderGridCell 对素材图片做随机形变后渲染为单格图像
+// 参数 rng: 随机源
+// 参数 src: 素材图片
+// 参数 size: 单格边长
+// 参数 noiseDots: 干扰点数量
+// 返回值: 单格图像
+// 关键步骤：随机正方形裁剪（放大 1~1.25 倍）→按 1.2 倍边长缩放→随机水平翻转→±10° 旋转后取中心区域（避免透明角）→
+// 各通道亮度抖动→叠加干扰点
+func renderGridCell(rng *mrand.Rand, src image.Image, size, noiseDots int) *image.RGBA {
+    b := src.Bounds()
+    side := maxInt(1, int(float64(minInt(b.Dx(), b.Dy()))/(1+rng.Float64()*0.25)))
+    x0 := b.Min.X + rng.Intn(b.Dx()-side+1)
+    y0 := b.Min.Y + rng.Intn(b.Dy()-side+1)
+    big := int(float64(size) * 1.2)
+    scaled := image.NewRGBA(image.Rect(0, 0, big, big))
+    xdraw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), src, image.Rect(x0, y0, x0+side, y0+side), xdraw.Src, nil)
+    if rng.Intn(2) == 0 {
+        for y := 0; y < big; y++ {
+            for x := 0; x < big/2; x++ {
+                l, r := scaled.RGBAAt(x, y), scaled.RGBAAt(big-1-x, y)
+                scaled.SetRGBA(x, y, r)
+                scaled.SetRGBA(big-1-x, y, l)
+            }
+        }
+    }
+    rot := rotateRGBA(scaled, (rng.Float64()*2-1)*10)
+    cell := image.NewRGBA(image.Rect(0, 0, size, size))
+    off := image.Pt((rot.Bounds().Dx()-size)/2, (rot.Bounds().Dy()-size)/2)
+    draw.Draw(cell, cell.Bounds(), rot, off, draw.Src)
+
+    // 关键步骤：各通道独立的亮度抖动（0.85~1.15），改变像素值但不影响辨识
+    var gain [3]float64
+    for i := range gain {
+        gain[i] = 0.85 + rng.Float64()*0.3
+    }
+    for i := 0; i < len(cell.Pix); i += 4 {
+        for c := 0; c < 3; c++ {
+            cell.Pix[i+c] = uint8(math.Min(255, float64(cell.Pix[i+c])*gain[c]))
+        }
+        cell.Pix[i+3] = 255
+    }
+    drawNoiseDots(rng, cell, noiseDots)
+    return cell
+}
+
+// isImageFile 判断文件扩展名是否为支持的图片格式
+// 参数 p: 文件路径
+// 返回值: 是否为 .png/.jpg/.jpeg/.gif
+func isImageFile(p string) bool {
+    switch strings.ToLower(path.Ext(p)) {
+    case ".png", ".jpg", ".jpeg", ".gif":
+        return true
+    }
+    return false
+}
+
+// minInt 返回两个整数中的较小值
+// 参数 a, b: 整数
+// 返回值: 较小值
+func minInt(a, b int) int {
+    if a < b {
+        return a
+    }
+    return b
+}
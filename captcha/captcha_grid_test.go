@@ -0,0 +1,111 @@
+package captcha
+
+import (
+    "bytes"
+    "image"
+    "image/color"
+    "image/png"
+    mrand "math/rand"
+    "testing"
+    "testing/fstest"
+)
+
+// solidPNG 生成纯色PNG字节（左上角带一个小色块，使同标签的图片互不相同）
+// 参数 t: 测试上下文
+// 参数 c: 主色
+// 参数 variant: 变体编号
+// 返回值: PNG字节
+func solidPNG(t *testing.T, c color.RGBA, variant int) []byte {
+    t.Helper()
+    img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+    fillRect(img, 0, 0, 64, 48, c)
+    fillRect(img, 0, 0, 4+variant, 4, color.RGBA{128, 128, 128, 255})
+    var buf bytes.Buffer
+    if err := png.Encode(&buf, img); err != nil { t.Fatalf("png encode error: %v", err) }
+    return buf.Bytes()
+}
+
+// testImageFS 构造三种颜色标签的图片文件系统
+// 参数 t: 测试上下文
+// 返回值: 内存文件系统
+func testImageFS(t *testing.T) fstest.MapFS {
+    return fstest.MapFS{
+        "red/a.png":   {Data: solidPNG(t, color.RGBA{220, 30, 30, 255}, 0)},
+        "red/b.png":   {Data: solidPNG(t, color.RGBA{220, 30, 30, 255}, 1)},
+        "green/a.png": {Data: solidPNG(t, color.RGBA{30, 200, 30, 255}, 0)},
+        "blue/a.png":  {Data: solidPNG(t, color.RGBA{30, 30, 220, 255}, 0)},
+        "README.md":   {Data: []byte("ignored")},
+        "root.png":    {Data: solidPNG(t, color.RGBA{0, 0, 0, 255}, 0)},
+    }
+}
+
+// TestLoadImageSet 测试：按一级目录加载标签，忽略根目录与非图片文件，解码失败返回错误
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestLoadImageSet(t *testing.T) {
+    set, err := LoadImageSet(testImageFS(t))
+    if err != nil { t.Fatalf("LoadImageSet error: %v", err) }
+    if got := set.Labels(); len(got) != 3 || got[0] != "blue" || got[2] != "red" { t.Fatalf("labels mismatch: %v", got) }
+    if len(set.images["red"]) != 2 { t.Fatalf("red image count mismatch: %d", len(set.images["red"])) }
+
+    bad := testImageFS(t)
+    bad["red/broken.png"] = &fstest.MapFile{Data: []byte("not a png")}
+    if _, err := LoadImageSet(bad); err == nil { t.Fatalf("expected decode error") }
+    if _, err := LoadImageSet(fstest.MapFS{"red/a.png": {Data: solidPNG(t, color.RGBA{255, 0, 0, 255}, 0)}}); err == nil { t.Fatalf("expected error for single label") }
+}
+
+// TestGenerateGridCaptcha_AnswerMatchesCells 测试：答案格子的内容确实来自目标标签
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：按格子中心区域的平均颜色判断主色，目标格应为红色，其余格不应为红色；校验集合相等语义
+func TestGenerateGridCaptcha_AnswerMatchesCells(t *testing.T) {
+    set, err := LoadImageSet(testImageFS(t))
+    if err != nil { t.Fatalf("LoadImageSet error: %v", err) }
+    for seed := int64(0); seed < 5; seed++ {
+        gc, err := GenerateGridCaptcha(set, GridOptions{Rows: 3, Cols: 4, CellSize: 60, Target: "red", Rand: mrand.New(mrand.NewSource(seed))})
+        if err != nil { t.Fatalf("GenerateGridCaptcha error: %v", err) }
+        if gc.Target != "red" || gc.Rows != 3 || gc.Cols != 4 || len(gc.Answer) < 2 || len(gc.Answer) > 4 { t.Fatalf("result mismatch: %+v", gc) }
+        img, err := png.Decode(bytes.NewReader(gc.Image))
+        if err != nil { t.Fatalf("png decode error: %v", err) }
+        if img.Bounds().Dx() != 4*60+5*4 || img.Bounds().Dy() != 3*60+4*4 { t.Fatalf("bounds mismatch: %v", img.Bounds()) }
+        isTarget := map[int]bool{}
+        for _, i := range gc.Answer {
+            isTarget[i] = true
+        }
+        for i := 0; i < 12; i++ {
+            x0, y0 := 4+(i%4)*64+20, 4+(i/4)*64+20
+            var r, g, b int
+            for y := y0; y < y0+20; y++ {
+                for x := x0; x < x0+20; x++ {
+                    c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+                    r, g, b = r+int(c.R), g+int(c.G), b+int(c.B)
+                }
+            }
+            red := r > g && r > b
+            if red != isTarget[i] { t.Fatalf("seed %d cell %d: red=%v target=%v", seed, i, red, isTarget[i]) }
+        }
+        if !VerifyGrid(gc.Answer, append([]int{gc.Answer[0]}, gc.Answer...)) { t.Fatalf("duplicate selection should pass") }
+        if VerifyGrid(gc.Answer, gc.Answer[1:]) { t.Fatalf("missing selection should fail") }
+        extra := 0
+        for isTarget[extra] { extra++ }
+        if VerifyGrid(gc.Answer, append([]int{extra}, gc.Answer...)) { t.Fatalf("extra selection should fail") }
+    }
+    if _, err := GenerateGridCaptcha(set, GridOptions{Target: "cat"}); err == nil { t.Fatalf("expected error for unknown target") }
+    gc, err := GenerateGridCaptcha(set, GridOptions{Rows: 2, CellSize: 40})
+    if err != nil { t.Fatalf("GenerateGridCaptcha error: %v", err) }
+    if gc.Rows != 2 || gc.Cols != 3 { t.Fatalf("unset Cols should default alone: %dx%d", gc.Rows, gc.Cols) }
+}
+
+// TestGridAnswer_FormatParse 测试：答案字符串编码与解析
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestGridAnswer_FormatParse(t *testing.T) {
+    if s := FormatGridAnswer([]int{7, 1, 4}); s != "1,4,7" { t.Fatalf("format mismatch: %q", s) }
+    got, err := ParseGridAnswer(" 4, 1 ,7,")
+    if err != nil || !VerifyGrid([]int{1, 4, 7}, got) { t.Fatalf("parse mismatch: %v %v", got, err) }
+    if got, err := ParseGridAnswer(""); err != nil || len(got) != 0 { t.Fatalf("empty parse mismatch: %v %v", got, err) }
+    if empty, _ := ParseGridAnswer(""); VerifyGrid(empty, empty) || VerifyGrid(nil, []int{1}) || VerifyGrid([]int{1}, nil) { t.Fatalf("empty answer or selection should fail") }
+    for _, s := range []string{"1,x", "-1"} {
+        if _, err := ParseGridAnswer(s); err == nil { t.Fatalf("expected error for %q", s) }
+    }
+}
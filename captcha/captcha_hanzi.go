@@ -0,0 +1,351 @@
+package captcha
+
+import (
+    "bytes"
+    "errors"
+    "image"
+    "image/color"
+    "image/png"
+    mrand "math/rand"
+    "strings"
+
+    "golang.org/x/image/font"
+    "golang.org/x/image/font/gofont/goregular"
+    "golang.org/x/image/font/opentype"
+    "golang.org/x/image/math/fixed"
+)
+
+// 本文件提供汉字验证码：内置常用汉字字符集、按字符（rune）生成验证码、多字体逐字回退绘制，
+// 以及“按顺序点击汉字”的点选验证码（返回每个目标字的包围盒，供服务端校验点击坐标）。
+// 默认字体 goregular 不含汉字字形，调用方需提供至少一个包含中文字形的 TTF/OTF 字体（如思源黑体）。
+
+// CommonHanzi 内置常用汉字字符集（取自高频常用字，剔除笔画过少易混淆的字）
+const CommonHanzi = "的是不在有我他这中大来上国个到说们为子和你地出道也时年得就那要下以生会自着去之过家学对可她里后小心多" +
+    "天而能好都然没日于起还发成事只作当想看文无开手用主行方又如前所本见经头面公同已老从动两长知民样现分将" +
+    "外但身些与高意进把法此实回理美点月明其种声全工己话儿者向情部正名定女问力机给等几很业最间新什打便位因" +
+    "重被走电四第门相次东政海口使教西再平真听世气信北少关并内加化由却代军产先山五太水万市眼体别处总才场师" +
+    "书比住员九笑性通目华报立马命张活难神数件安表原车白应路期叫死常提感金何更反合放做系计或司利受光王果亲" +
+    "界及今京务制解各任至清物台象记边共风战干接它许特觉望直服毛林题建南度统色字请交爱让认算论百吃义科怎元" +
+    "社术结六功指思非流每青管夫连远资队跟带花快条院变联言权往展该领传近留红治决周保达办运武半候七必城父强" +
+    "步完革深区即求品士转量空甚众技轻程告江语英基派满式李息写呢识极令黄德收脸钱党倒未持取设始版双历越史商" +
+    "千片容研像找友孩站广改议形委早房音火际则首单据导影失拿网香似斯专石若兵弟谁校读志飞观争究包组造落视济" +
+    "喜离虽坐集编宝谈府拉黑且随格尽剑讲布杀微怕母调局根曾准团段终乐切级克精哪官示冷域"
+
+// GenerateHanziCode 生成指定长度的汉字验证码（按字符而非字节取样）
+// 参数 length: 验证码字数（<=0 返回空字符串）
+// 参数 alphabet: 候选字符集合；为空时使用 CommonHanzi
+// 返回值: 验证码字符串与错误
+// 关键步骤：将字符集拆分为 rune 后使用加密随机源均匀选取，支持任意 Unicode 字符集。
+func GenerateHanziCode(length int, alphabet string) (string, error) {
+    if length <= 0 {
+        return "", nil
+    }
+    if alphabet == "" {
+        alphabet = CommonHanzi
+    }
+    runes := []rune(alphabet)
+    out := make([]rune, length)
+    for i := range out {
+        out[i] = runes[secureIntn(len(runes))]
+    }
+    return string(out), nil
+}
+
+// GenerateHanziCaptchaImagePNG 生成汉字图片验证码
+// 参数 text: 验证码文本（可混合汉字、字母与数字）
+// 参数 width: 图片宽度（像素，建议 >= 字数×高度）
+// 参数 height: 图片高度（像素，建议>=48）
+// 参数 noiseLines: 干扰线数量（建议 2~6）
+// 参数 noiseDots: 干扰点数量（建议 50~200）
+// 参数 fonts: 按优先级排列的字体字节；每个字符使用第一个包含该字形的字体，最后回退到 goregular
+// 返回值: PNG编码的图片字节与错误（字体解析失败或所有字体均缺少某字形时返回错误）
+// 关键步骤：逐字选择字体→复用文本验证码的形变、噪声与波纹流程绘制（Config API 的薄封装）。
+func GenerateHanziCaptchaImagePNG(text string, width, height int, noiseLines, noiseDots int, fonts ...[]byte) ([]byte, error) {
+    // 关键步骤：汉字为方块字，字号取 Config 默认字号的 0.625 倍（约为高度的 75%）
+    return GenerateText(text, WithSize(width, height), WithNoise(noiseLines, noiseDots), WithFonts(fonts...), WithFontScale(0.625))
+}
+
+// ClickTarget 点选验证码中的一个目标字
+// 结构体字段解释：
+// - Char: 目标字符
+// - X, Y: 包围盒左上角坐标（像素）
+// - W, H: 包围盒宽高（像素）
+type ClickTarget struct {
+    Char string `json:"char"`
+    X    int    `json:"x"`
+    Y    int    `json:"y"`
+    W    int    `json:"w"`
+    H    int    `json:"h"`
+}
+
+// ClickCaptcha 点选验证码生成结果
+// 结构体字段解释：
+// - Image: PNG图片字节（包含目标字与干扰字）
+// - Prompt: 提示文本（目标字按点击顺序以空格分隔，如 "天 地 人"）
+// - Targets: 目标字及其包围盒（按点击顺序；需保存在服务端用于 VerifyClick，不可下发给客户端）
+type ClickCaptcha struct {
+    Image   []byte
+    Prompt  string
+    Targets []ClickTarget
+}
+
+// ClickOptions 点选验证码选项
+// 结构体字段解释：
+// - Width, Height: 图片尺寸；各自 <=0 时分别默认 320 与 200
+// - Count: 需要点击的目标字数量；<=0 时默认3
+// - Decoys: 干扰字数量；0 时默认2，<0 表示不加干扰字
+// - Alphabet: 候选字符集；为空时使用 CommonHanzi
+// - FontSize: 字号（像素）；<=0 时默认 Height/5
+// - Fonts: 按优先级排列的字体字节（需包含候选字符的字形）
+// - NoiseLines, NoiseDots: 干扰线与干扰点数量
+// - Rand: 随机源；为 nil 时字符以加密随机选取、布局随机播种；指定后结果可复现（仅用于测试）
+type ClickOptions struct {
+    Width      int
+    Height     int
+    Count      int
+    Decoys     int
+    Alphabet   string
+    FontSize   float64
+    Fonts      [][]byte
+    NoiseLines int
+    NoiseDots  int
+    Rand       *mrand.Rand
+}
+
+// GenerateHanziClickCaptcha 生成“按顺序点击汉字”的点选验证码
+// 参数 opt: 点选验证码选项
+// 返回值: 生成结果与错误（字体缺字、字符集不足或画布放不下全部字符时返回错误）
+// 关键步骤：选取互不相同的目标字与干扰字→逐字绘制到独立画布并随机旋转→在画布内随机放置且互不重叠→
+// 记录目标字实际笔画的包围盒→叠加渐变背景与噪声。
+func GenerateHanziClickCaptcha(opt ClickOptions) (*ClickCaptcha, error) {
+    if opt.Width <= 0 { opt.Width = 320 }
+    if opt.Height <= 0 { opt.Height = 200 }
+    if opt.Count <= 0 { opt.Count = 3 }
+    if opt.Decoys == 0 { opt.Decoys = 2 }
+    if opt.Decoys < 0 { opt.Decoys = 0 }
+    if opt.Alphabet == "" { opt.Alphabet = CommonHanzi }
+    if opt.FontSize <= 0 { opt.FontSize = float64(opt.Height) / 5 }
+
+    chars, err := pickDistinctRunes(opt.Rand, opt.Alphabet, opt.Count+opt.Decoys)
+    if err != nil {
+        return nil, err
+    }
+    candidates, err := loadFontFaces(opt.Fonts, opt.FontSize)
+    if err != nil {
+        return nil, err
+    }
+    faces, err := pickGlyphFaces(chars, candidates)
+    if err != nil {
+        return nil, err
+    }
+
+    rng := randOrNew(opt.Rand)
+    img := image.NewRGBA(image.Rect(0, 0, opt.Width, opt.Height))
+    fillGradient(img, color.RGBA{uint8(215 + rng.Intn(40)), uint8(215 + rng.Intn(40)), uint8(215 + rng.Intn(40)), 255}, color.RGBA{uint8(215 + rng.Intn(40)), uint8(215 + rng.Intn(40)), uint8(215 + rng.Intn(40)), 255})
+    addBackgroundNoiseTexture(rng, img, maxInt(4, opt.Width/24))
+
+    cols := randomGlyphColors(rng, len(chars))
+    var placed []image.Rectangle
+    targets := make([]ClickTarget, 0, opt.Count)
+    for i, r := range chars {
+        glyph := renderGlyphCell(r, faces[i], cols[i])
+        rot := rotateRGBA(glyph, (rng.Float64()*2-1)*30)
+        at, ok := placeWithoutOverlap(rng, rot.Bounds().Size(), img.Bounds(), placed)
+        if !ok {
+            return nil, errors.New("画布过小，无法放置全部字符")
+        }
+        placed = append(placed, image.Rectangle{Min: at, Max: at.Add(rot.Bounds().Size())})
+        compositeShearRGBA(img, rot, at.X, at.Y, 0)
+        if i < opt.Count {
+            box := opaqueBounds(rot).Add(at)
+            targets = append(targets, ClickTarget{Char: string(r), X: box.Min.X, Y: box.Min.Y, W: box.Dx(), H: box.Dy()})
+        }
+    }
+    drawNoiseLines(img, randomNoiseLines(rng, opt.NoiseLines, opt.Width, opt.Height))
+    drawNoiseDots(rng, img, opt.NoiseDots)
+
+    var buf bytes.Buffer
+    if err := png.Encode(&buf, img); err != nil {
+        return nil, err
+    }
+    prompt := make([]string, len(targets))
+    for i, t := range targets {
+        prompt[i] = t.Char
+    }
+    return &ClickCaptcha{Image: buf.Bytes(), Prompt: strings.Join(prompt, " "), Targets: targets}, nil
+}
+
+// VerifyClick 校验点选验证码的点击坐标
+// 参数 targets: 生成时保存的目标字（按点击顺序）
+// 参数 points: 用户依次点击的坐标
+// 参数 tolerance: 包围盒向外扩展的容差（像素，<0 视为0）
+// 返回值: 点击次数与目标数一致且每次点击都落在对应目标字（含容差）的包围盒内时返回 true
+func VerifyClick(targets []ClickTarget, points []image.Point, tolerance int) bool {
+    if len(targets) == 0 || len(points) != len(targets) {
+        return false
+    }
+    if tolerance < 0 { tolerance = 0 }
+    for i, t := range targets {
+        box := image.Rect(t.X-tolerance, t.Y-tolerance, t.X+t.W+tolerance, t.Y+t.H+tolerance)
+        if !points[i].In(box) {
+            return false
+        }
+    }
+    return true
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// loadFontFaces 解析字体字节并创建指定字号的字体，末尾追加 goregular 作为西文回退
+// 参数 fonts: 字体字节列表
+// 参数 size: 字号（像素）
+// 返回值: 字体列表与错误（任一字体解析失败时返回错误）
+func loadFontFaces(fonts [][]byte, size float64) ([]font.Face, error) {
+    faces := make([]font.Face, 0, len(fonts)+1)
+    all := append(append([][]byte{}, fonts...), goregular.TTF)
+    for _, b := range all {
+        f, err := opentype.Parse(b)
+        if err != nil {
+            return nil, errors.New("字体解析失败: " + err.Error())
+        }
+        face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+        if err != nil {
+            return nil, err
+        }
+        faces = append(faces, face)
+    }
+    return faces, nil
+}
+
+// pickGlyphFaces 为每个字符选择第一个包含其字形的字体
+// 参数 runes: 字符列表
+// 参数 candidates: 按优先级排列的候选字体
+// 返回值: 与字符一一对应的字体与错误（所有字体均缺少某字形时返回错误）
+func pickGlyphFaces(runes []rune, candidates []font.Face) ([]font.Face, error) {
+    faces := make([]font.Face, len(runes))
+    for i, r := range runes {
+        for _, f := range candidates {
+            if _, ok := f.GlyphAdvance(r); ok {
+                faces[i] = f
+                break
+            }
+        }
+        if faces[i] == nil {
+            return nil, errors.New("字体缺少字形: " + string(r))
+        }
+    }
+    return faces, nil
+}
+
+// pickDistinctRunes 从字符集中随机选取 n 个互不相同的字符
+// 参数 rng: 随机源（为 nil 时使用加密随机）
+// 参数 alphabet: 字符集
+// 参数 n: 数量
+// 返回值: 字符列表与错误（去重后的字符集不足 n 个时返回错误）
+func pickDistinctRunes(rng *mrand.Rand, alphabet string, n int) ([]rune, error) {
+    seen := map[rune]bool{}
+    var pool []rune
+    for _, r := range alphabet {
+        if !seen[r] {
+            seen[r] = true
+            pool = append(pool, r)
+        }
+    }
+    if len(pool) < n {
+        return nil, errors.New("字符集中的不同字符数量不足")
+    }
+    // 关键步骤：部分 Fisher-Yates 洗牌，仅打乱前 n 个位置
+    for i := 0; i < n; i++ {
+        j := i + randIntn(rng, len(pool)-i)
+        pool[i], pool[j] = pool[j], pool[i]
+    }
+    return pool[:n], nil
+}
+
+// renderGlyphCell 将单个字符绘制到正方形透明画布的中心
+// 参数 r: 字符
+// 参数 face: 字体
+// 参数 col: 颜色
+// 返回值: 字符画布
+func renderGlyphCell(r rune, face font.Face, col color.RGBA) *image.RGBA {
+    m := face.Metrics()
+    adv, _ := face.GlyphAdvance(r)
+    size := maxInt(adv.Ceil(), m.Height.Ceil()) + 4
+    cell := image.NewRGBA(image.Rect(0, 0, size, size))
+    d := &font.Drawer{
+        Dst:  cell,
+        Src:  &image.Uniform{col},
+        Face: face,
+        Dot:  fixed.P((size-adv.Ceil())/2, (size-m.Height.Ceil())/2+m.Ascent.Ceil()),
+    }
+    d.DrawString(string(r))
+    return cell
+}
+
+// placeWithoutOverlap 在画布内随机寻找与已放置区域不重叠的位置
+// 参数 rng: 随机源
+// 参数 size: 待放置图像尺寸
+// 参数 bounds: 画布范围
+// 参数 placed: 已放置区域
+// 返回值: 左上角坐标与是否找到
+func placeWithoutOverlap(rng *mrand.Rand, size image.Point, bounds image.Rectangle, placed []image.Rectangle) (image.Point, bool) {
+    maxX := bounds.Dx() - size.X
+    maxY := bounds.Dy() - size.Y
+    if maxX < 0 || maxY < 0 {
+        return image.Point{}, false
+    }
+    for try := 0; try < 200; try++ {
+        at := image.Pt(rng.Intn(maxX+1), rng.Intn(maxY+1))
+        r := image.Rectangle{Min: at, Max: at.Add(size)}
+        overlap := false
+        for _, p := range placed {
+            if r.Overlaps(p) {
+                overlap = true
+                break
+            }
+        }
+        if !overlap {
+            return at, true
+        }
+    }
+    return image.Point{}, false
+}
+
+// opaqueBounds 计算图像中不透明像素的最小包围盒
+// 参数 img: 图像
+// 返回值: 包围盒（全透明时返回整幅图像范围）
+func opaqueBounds(img *image.RGBA) image.Rectangle {
+    b := img.Bounds()
+    box := image.Rectangle{}
+    for y := b.Min.Y; y < b.Max.Y; y++ {
+        for x := b.Min.X; x < b.Max.X; x++ {
+            if img.RGBAAt(x, y).A != 0 {
+                box = box.Union(image.Rect(x, y, x+1, y+1))
+            }
+        }
+    }
+    if box.Empty() {
+        return b
+    }
+    return box
+}
+
+// fillGradient 以左上到右下的线性渐变填充图像
+// 参数 img: 目标图像
+// 参数 from, to: 起止颜色
+// 返回值: 无
+func fillGradient(img *image.RGBA, from, to color.RGBA) {
+    b := img.Bounds()
+    span := float64(maxInt(1, b.Dx()+b.Dy()-2))
+    for y := b.Min.Y; y < b.Max.Y; y++ {
+        for x := b.Min.X; x < b.Max.X; x++ {
+            t := float64(x-b.Min.X+y-b.Min.Y) / span
+            img.SetRGBA(x, y, color.RGBA{
+                uint8(float64(from.R) + (float64(to.R)-float64(from.R))*t),
+                uint8(float64(from.G) + (float64(to.G)-float64(from.G))*t),
+                uint8(float64(from.B) + (float64(to.B)-float64(from.B))*t),
+                255,
+            })
+        }
+    }
+}
@@ -0,0 +1,230 @@
+package captcha
+
+import (
+    "bytes"
+    "encoding/binary"
+    "image"
+    "image/png"
+    "strings"
+    "testing"
+    "unicode/utf8"
+
+    "golang.org/x/image/font"
+    "golang.org/x/image/math/fixed"
+)
+
+// missingGlyphFace 模拟缺少部分字形的字体（用于测试逐字回退）
+type missingGlyphFace struct {
+    font.Face
+    missing string
+}
+
+// GlyphAdvance 对 missing 中的字符报告缺字
+func (f missingGlyphFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+    if strings.ContainsRune(f.missing, r) { return 0, false }
+    return f.Face.GlyphAdvance(r)
+}
+
+// testCJKStrokes 测试字体中各汉字的笔画矩形（字体单位，em=1000，基线 y=0）
+var testCJKStrokes = map[rune][][4]int16{
+    '天': {{150, 700, 850, 780}, {100, 420, 900, 500}, {460, 0, 540, 780}, {200, 0, 460, 80}, {540, 0, 800, 80}},
+    '地': {{100, 500, 400, 580}, {220, 100, 300, 800}, {450, 0, 900, 80}, {500, 80, 580, 700}, {700, 150, 780, 800}},
+    '人': {{460, 300, 540, 820}, {150, 0, 460, 300}, {540, 0, 850, 300}},
+    '山': {{460, 100, 540, 800}, {150, 100, 230, 600}, {770, 100, 850, 600}, {150, 0, 850, 100}},
+    '水': {{460, 0, 540, 820}, {120, 400, 420, 480}, {580, 300, 880, 700}, {150, 0, 420, 300}},
+}
+
+// testCJKFont 构造仅含少量汉字的最小 TrueType 字体（测试夹具，避免依赖系统中文字体）
+// 参数: 无
+// 返回值: 字体字节
+// 关键步骤：每个汉字由若干矩形轮廓组成→写入 cmap(format 4)/glyf/loca/head/hhea/hmtx/maxp/post 表，表按标签排序。
+func testCJKFont() []byte {
+    runes := []rune("人地天山水") // 码点升序，cmap 分段需有序
+    be := binary.BigEndian
+    // 关键步骤：glyf 与 loca（0 号为空的 .notdef）
+    var glyf bytes.Buffer
+    loca := []uint32{0, 0}
+    for _, r := range runes {
+        rects := testCJKStrokes[r]
+        hdr := []int16{int16(len(rects)), 100, 0, 900, 820}
+        binary.Write(&glyf, be, hdr)
+        for i := range rects { binary.Write(&glyf, be, uint16(i*4+3)) }
+        binary.Write(&glyf, be, uint16(0)) // 指令长度
+        for i := 0; i < len(rects)*4; i++ { glyf.WriteByte(1) } // 全部为曲线上的点，坐标为2字节增量
+        var xs, ys []int16
+        for _, rc := range rects {
+            // 关键步骤：顺时针（y 轴向上）绘制外轮廓
+            xs = append(xs, rc[0], rc[0], rc[2], rc[2])
+            ys = append(ys, rc[1], rc[3], rc[3], rc[1])
+        }
+        for _, c := range [][]int16{xs, ys} {
+            prev := int16(0)
+            for _, v := range c {
+                binary.Write(&glyf, be, v-prev)
+                prev = v
+            }
+        }
+        for glyf.Len()%4 != 0 { glyf.WriteByte(0) }
+        loca = append(loca, uint32(glyf.Len()))
+    }
+    numGlyphs := uint16(len(runes) + 1)
+    var locaB bytes.Buffer
+    binary.Write(&locaB, be, loca)
+
+    // 关键步骤：cmap format 4，每个字一段，末尾为 0xFFFF 结束段
+    segs := len(runes) + 1
+    var sub bytes.Buffer
+    binary.Write(&sub, be, []uint16{4, uint16(16 + 8*segs), 0, uint16(2 * segs), 0, 0, 0})
+    for _, r := range runes { binary.Write(&sub, be, uint16(r)) }
+    binary.Write(&sub, be, []uint16{0xFFFF, 0})
+    for _, r := range runes { binary.Write(&sub, be, uint16(r)) }
+    binary.Write(&sub, be, uint16(0xFFFF))
+    for i, r := range runes { binary.Write(&sub, be, uint16(i+1)-uint16(r)) }
+    binary.Write(&sub, be, uint16(1))
+    for i := 0; i < segs; i++ { binary.Write(&sub, be, uint16(0)) }
+    var cmap bytes.Buffer
+    binary.Write(&cmap, be, []uint16{0, 1, 3, 1})
+    binary.Write(&cmap, be, uint32(12))
+    cmap.Write(sub.Bytes())
+
+    var head, hhea, hmtx, maxp, post bytes.Buffer
+    binary.Write(&head, be, []uint32{0x00010000, 0x00010000, 0, 0x5F0F3CF5})
+    binary.Write(&head, be, []uint16{0, 1000})
+    binary.Write(&head, be, make([]byte, 16)) // 创建与修改时间
+    binary.Write(&head, be, []int16{100, 0, 900, 820, 0, 8, 2, 1, 0})
+    binary.Write(&hhea, be, uint32(0x00010000))
+    binary.Write(&hhea, be, []int16{880, -120, 0, 1000, 100, 100, 900, 1, 0, 0, 0, 0, 0, 0, 0, int16(numGlyphs)})
+    for i := uint16(0); i < numGlyphs; i++ { binary.Write(&hmtx, be, []int16{1000, 100}) }
+    binary.Write(&maxp, be, uint32(0x00010000))
+    binary.Write(&maxp, be, []uint16{numGlyphs, 64, 8, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0})
+    binary.Write(&post, be, []uint32{0x00030000, 0, 0, 0, 0, 0, 0, 0})
+
+    tables := []struct {
+        tag  string
+        data []byte
+    }{{"cmap", cmap.Bytes()}, {"glyf", glyf.Bytes()}, {"head", head.Bytes()}, {"hhea", hhea.Bytes()}, {"hmtx", hmtx.Bytes()}, {"loca", locaB.Bytes()}, {"maxp", maxp.Bytes()}, {"post", post.Bytes()}}
+    var out bytes.Buffer
+    binary.Write(&out, be, uint32(0x00010000))
+    binary.Write(&out, be, []uint16{uint16(len(tables)), 0, 0, 0})
+    offset := 12 + 16*len(tables)
+    for _, tb := range tables {
+        out.WriteString(tb.tag)
+        binary.Write(&out, be, []uint32{0, uint32(offset), uint32(len(tb.data))})
+        offset += (len(tb.data) + 3) &^ 3
+    }
+    for _, tb := range tables {
+        out.Write(tb.data)
+        for out.Len()%4 != 0 { out.WriteByte(0) }
+    }
+    return out.Bytes()
+}
+
+// TestCommonHanziAndGenerateHanziCode 测试：内置字符集无重复且均为汉字，按字符生成验证码
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：检查字符集去重与 CJK 范围；生成长度按 rune 计算且字符来自字符集
+func TestCommonHanziAndGenerateHanziCode(t *testing.T) {
+    seen := map[rune]bool{}
+    for _, r := range CommonHanzi {
+        if seen[r] { t.Fatalf("duplicate hanzi: %q", r) }
+        if r < 0x4E00 || r > 0x9FFF { t.Fatalf("not a CJK ideograph: %q", r) }
+        seen[r] = true
+    }
+    code, err := GenerateHanziCode(4, "")
+    if err != nil { t.Fatalf("GenerateHanziCode error: %v", err) }
+    if utf8.RuneCountInString(code) != 4 { t.Fatalf("rune length mismatch: %q", code) }
+    for _, r := range code {
+        if !seen[r] { t.Fatalf("rune not in alphabet: %q", r) }
+    }
+}
+
+// TestPickGlyphFaces_Fallback 测试：逐字回退到包含字形的字体
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：首选字体缺 'A' 时回退到第二个字体；所有字体都缺字时返回错误
+func TestPickGlyphFaces_Fallback(t *testing.T) {
+    base, err := loadFontFaces(nil, 20)
+    if err != nil { t.Fatalf("loadFontFaces error: %v", err) }
+    primary := missingGlyphFace{Face: base[0], missing: "A"}
+    faces, err := pickGlyphFaces([]rune("AB"), []font.Face{primary, base[0]})
+    if err != nil { t.Fatalf("pickGlyphFaces error: %v", err) }
+    if faces[0] != base[0] || faces[1] != font.Face(primary) { t.Fatalf("fallback mismatch") }
+    if _, err := pickGlyphFaces([]rune("汉"), base); err == nil { t.Fatalf("expected error for missing hanzi glyph") }
+    if _, err := GenerateHanziCaptchaImagePNG("汉字验证", 240, 60, 2, 50); err == nil { t.Fatalf("expected error without CJK font") }
+    if _, err := GenerateHanziCaptchaImagePNG("ab", 120, 48, 2, 50, []byte("not a font")); err == nil { t.Fatalf("expected error for invalid font") }
+    img, err := GenerateHanziCaptchaImagePNG("Ab3", 150, 50, 2, 50)
+    if err != nil { t.Fatalf("GenerateHanziCaptchaImagePNG error: %v", err) }
+    if w, h := decodePNGBounds(t, img); w != 150 || h != 50 { t.Fatalf("bounds mismatch: %dx%d", w, h) }
+}
+
+// TestGenerateHanziClickCaptcha_Verify 测试：点选验证码包围盒与点击校验
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：使用西文字符集（默认字体可绘制）生成→包围盒在画布内且含深色笔画→按顺序点击中心通过，乱序或缺点失败
+func TestGenerateHanziClickCaptcha_Verify(t *testing.T) {
+    cc, err := GenerateHanziClickCaptcha(ClickOptions{Alphabet: "ABCDEFGHJKMNPQRSTUVWXYZ"})
+    if err != nil { t.Fatalf("GenerateHanziClickCaptcha error: %v", err) }
+    if len(cc.Targets) != 3 || len(strings.Fields(cc.Prompt)) != 3 { t.Fatalf("target count mismatch: %+v", cc) }
+    img, err := png.Decode(bytes.NewReader(cc.Image))
+    if err != nil { t.Fatalf("png decode error: %v", err) }
+    if img.Bounds().Dx() != 320 || img.Bounds().Dy() != 200 { t.Fatalf("bounds mismatch: %v", img.Bounds()) }
+    var centers []image.Point
+    for _, tg := range cc.Targets {
+        box := image.Rect(tg.X, tg.Y, tg.X+tg.W, tg.Y+tg.H)
+        if !box.In(img.Bounds()) || box.Empty() { t.Fatalf("box out of bounds: %+v", tg) }
+        dark := false
+        for y := box.Min.Y; y < box.Max.Y && !dark; y++ {
+            for x := box.Min.X; x < box.Max.X; x++ {
+                r, g, b, _ := img.At(x, y).RGBA()
+                if r>>8 < 130 && g>>8 < 130 && b>>8 < 130 { dark = true; break }
+            }
+        }
+        if !dark { t.Fatalf("expected glyph pixels inside box: %+v", tg) }
+        centers = append(centers, image.Pt(tg.X+tg.W/2, tg.Y+tg.H/2))
+    }
+    if !VerifyClick(cc.Targets, centers, 0) { t.Fatalf("expected click verify ok") }
+    if VerifyClick(cc.Targets, []image.Point{centers[1], centers[0], centers[2]}, 0) { t.Fatalf("expected wrong order to fail") }
+    if VerifyClick(cc.Targets, centers[:2], 0) { t.Fatalf("expected missing click to fail") }
+    edge := image.Pt(cc.Targets[0].X-3, cc.Targets[0].Y)
+    if VerifyClick(cc.Targets[:1], []image.Point{edge}, 0) || !VerifyClick(cc.Targets[:1], []image.Point{edge}, 4) { t.Fatalf("tolerance mismatch") }
+    if _, err := GenerateHanziClickCaptcha(ClickOptions{Alphabet: "AB"}); err == nil { t.Fatalf("expected error for small alphabet") }
+}
+
+// TestGenerateHanziClickCaptcha_CJKFont 测试：使用含汉字字形的字体夹具真实绘制汉字
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：无字体时汉字字符集报缺字；提供夹具后字形选自夹具而非 goregular；点选图中每个目标字包围盒内
+// 深色笔画覆盖率足够高（夹具笔画为实心矩形）；汉字图片验证码渲染成功；宽高只设一项时另一项单独取默认值
+func TestGenerateHanziClickCaptcha_CJKFont(t *testing.T) {
+    alphabet := "天地人山水"
+    if _, err := GenerateHanziClickCaptcha(ClickOptions{Alphabet: alphabet}); err == nil { t.Fatalf("expected missing glyph error without CJK font") }
+    cjk := testCJKFont()
+    faces, err := loadFontFaces([][]byte{cjk}, 40)
+    if err != nil { t.Fatalf("loadFontFaces error: %v", err) }
+    picked, err := pickGlyphFaces([]rune(alphabet+"A"), faces)
+    if err != nil { t.Fatalf("pickGlyphFaces error: %v", err) }
+    for i, f := range picked[:5] {
+        if f != faces[0] { t.Fatalf("glyph %d should come from the CJK font", i) }
+    }
+    if picked[5] != faces[1] { t.Fatalf("latin glyph should fall back to goregular") }
+
+    cc, err := GenerateHanziClickCaptcha(ClickOptions{Width: 360, Alphabet: alphabet, Fonts: [][]byte{cjk}})
+    if err != nil { t.Fatalf("GenerateHanziClickCaptcha error: %v", err) }
+    img, err := png.Decode(bytes.NewReader(cc.Image))
+    if err != nil { t.Fatalf("png decode error: %v", err) }
+    if img.Bounds().Dx() != 360 || img.Bounds().Dy() != 200 { t.Fatalf("unset height should default alone: %v", img.Bounds()) }
+    for _, tg := range cc.Targets {
+        if !strings.ContainsRune(alphabet, []rune(tg.Char)[0]) { t.Fatalf("unexpected target: %q", tg.Char) }
+        dark := 0
+        for y := tg.Y; y < tg.Y+tg.H; y++ {
+            for x := tg.X; x < tg.X+tg.W; x++ {
+                r, g, b, _ := img.At(x, y).RGBA()
+                if r>>8 < 130 && g>>8 < 130 && b>>8 < 130 { dark++ }
+            }
+        }
+        if tg.W < 20 || tg.H < 20 || dark*5 < tg.W*tg.H { t.Fatalf("glyph %q not drawn: box %dx%d dark=%d", tg.Char, tg.W, tg.H, dark) }
+    }
+    b, err := GenerateHanziCaptchaImagePNG("天地人山", 240, 60, 2, 50, cjk)
+    if err != nil { t.Fatalf("GenerateHanziCaptchaImagePNG error: %v", err) }
+    if w, h := decodePNGBounds(t, b); w != 240 || h != 60 { t.Fatalf("bounds mismatch: %dx%d", w, h) }
+}
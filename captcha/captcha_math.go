@@ -0,0 +1,229 @@
+package captcha
+
+import (
+    crand "crypto/rand"
+    "errors"
+    "math/big"
+    mrand "math/rand"
+    "strconv"
+    "strings"
+
+    "github.com/QinWeisWord/go_utils/numberchinese"
+)
+
+// 本文件提供算术验证码：生成形如“3 + 7 × 2 = ?”的表达式及其整数答案（遵循先乘除后加减），
+// 可选以中文数字显示操作数，并复用文本图片验证码的绘制流程输出图片。
+
+// MathOptions 算术验证码选项
+// 结构体字段解释：
+// - Operators: 允许的运算符集合，取值为 '+','-','*','/' 的组合；为空时默认 "+-"
+// - Operands: 操作数个数（2~5）；<=0 时默认2
+// - Min, Max: 操作数取值范围（闭区间，均需>=0）；Max<=0 时默认 1~9
+// - AllowNegative: 是否允许答案为负数；默认不允许（会重新生成）
+// - ChineseNumerals: 是否以中文小写数字显示操作数（如“三 + 七”，绘制时需提供含中文字形的字体）
+// - Rand: 随机源；为 nil 时题目使用加密随机、绘制随机播种；指定后题目与图片可复现（仅用于测试）
+type MathOptions struct {
+    Operators       string
+    Operands        int
+    Min             int
+    Max             int
+    AllowNegative   bool
+    ChineseNumerals bool
+    Rand            *mrand.Rand
+}
+
+// GenerateMathExpression 生成算术表达式与答案
+// 参数 opt: 算术验证码选项
+// 返回值: 表达式字符串（如 "3 + 7 × 2 = ?"）、整数答案与错误（选项非法或无法满足约束时返回错误）
+// 关键步骤：加密随机选取操作数与运算符；除法只选取能整除当前乘除项的除数（不存在时改为乘法）；
+// 按先乘除后加减求值；不允许负数时重试生成。
+func GenerateMathExpression(opt MathOptions) (expr string, answer int, err error) {
+    ops, err := normalizeMathOptions(&opt)
+    if err != nil {
+        return "", 0, err
+    }
+    for attempt := 0; attempt < 100; attempt++ {
+        nums, sel := buildMathTerms(opt, ops)
+        answer = evalMathTerms(nums, sel)
+        if answer >= 0 || opt.AllowNegative {
+            return formatMathExpression(nums, sel, opt.ChineseNumerals), answer, nil
+        }
+    }
+    return "", 0, errors.New("无法在给定范围内生成非负结果的表达式")
+}
+
+// GenerateMathCaptchaImagePNG 生成算术验证码图片
+// 参数 opt: 算术验证码选项
+// 参数 width: 图片宽度（像素，建议>=160）
+// 参数 height: 图片高度（像素，建议>=48）
+// 参数 noiseLines: 干扰线数量（建议 2~6）
+// 参数 noiseDots: 干扰点数量（建议 50~200）
+// 参数 fontBytes: 可选 TTF 字体字节；使用中文数字时必须提供含中文字形的字体
+// 返回值: 表达式字符串、整数答案、PNG图片字节与错误
+// 关键步骤：生成表达式后去掉空格（避免空格占据字符格子），交由文本图片验证码流程绘制。
+func GenerateMathCaptchaImagePNG(opt MathOptions, width, height int, noiseLines, noiseDots int, fontBytes []byte) (expr string, answer int, png []byte, err error) {
+    expr, answer, err = GenerateMathExpression(opt)
+    if err != nil {
+        return "", 0, nil, err
+    }
+    png, err = generateTextCaptchaImagePNGInternal(compactMathExpression(expr), width, height, noiseLines, noiseDots, fontBytes, 1.0, opt.Rand)
+    if err != nil {
+        return "", 0, nil, err
+    }
+    return expr, answer, png, nil
+}
+
+// MathChallenge 返回可用于 ManagerOptions.Challenge 的算术题目生成函数
+// 参数 opt: 算术验证码选项
+// 返回值: 题目生成函数（题面为去空格的表达式，答案为十进制整数字符串）
+func MathChallenge(opt MathOptions) func() (question, answer string, err error) {
+    return func() (string, string, error) {
+        expr, ans, err := GenerateMathExpression(opt)
+        if err != nil {
+            return "", "", err
+        }
+        return compactMathExpression(expr), strconv.Itoa(ans), nil
+    }
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// normalizeMathOptions 校验并填充默认选项
+// 参数 opt: 选项指针（原地修改）
+// 返回值: 去重后的运算符列表与错误
+func normalizeMathOptions(opt *MathOptions) ([]byte, error) {
+    if opt.Operators == "" { opt.Operators = "+-" }
+    if opt.Operands <= 0 { opt.Operands = 2 }
+    if opt.Operands < 2 || opt.Operands > 5 {
+        return nil, errors.New("操作数个数需在2~5之间")
+    }
+    if opt.Max <= 0 { opt.Min, opt.Max = 1, 9 }
+    if opt.Min < 0 || opt.Min > opt.Max {
+        return nil, errors.New("操作数范围非法")
+    }
+    var ops []byte
+    seen := map[byte]bool{}
+    for i := 0; i < len(opt.Operators); i++ {
+        c := opt.Operators[i]
+        if !strings.ContainsRune("+-*/", rune(c)) {
+            return nil, errors.New("不支持的运算符: " + string(c))
+        }
+        if !seen[c] {
+            seen[c] = true
+            ops = append(ops, c)
+        }
+    }
+    return ops, nil
+}
+
+// buildMathTerms 随机生成操作数与运算符
+// 参数 opt: 已归一化的选项
+// 参数 ops: 可用运算符
+// 返回值: 操作数列表与运算符列表（len(sel) == len(nums)-1）
+// 关键步骤：维护当前乘除项的值，除法时仅在能整除的候选中选取除数
+func buildMathTerms(opt MathOptions, ops []byte) (nums []int, sel []byte) {
+    nums = []int{opt.Min + randIntn(opt.Rand, opt.Max-opt.Min+1)}
+    term := nums[0]
+    for i := 1; i < opt.Operands; i++ {
+        op := ops[randIntn(opt.Rand, len(ops))]
+        var n int
+        if op == '/' {
+            var divisors []int
+            for d := maxInt(1, opt.Min); d <= opt.Max; d++ {
+                if term%d == 0 { divisors = append(divisors, d) }
+            }
+            if len(divisors) == 0 {
+                op = '*'
+            } else {
+                n = divisors[randIntn(opt.Rand, len(divisors))]
+            }
+        }
+        if op != '/' {
+            n = opt.Min + randIntn(opt.Rand, opt.Max-opt.Min+1)
+        }
+        switch op {
+        case '*':
+            term *= n
+        case '/':
+            term /= n
+        default:
+            term = n
+        }
+        nums = append(nums, n)
+        sel = append(sel, op)
+    }
+    return nums, sel
+}
+
+// evalMathTerms 按先乘除后加减求值
+// 参数 nums: 操作数列表
+// 参数 sel: 运算符列表
+// 返回值: 计算结果
+func evalMathTerms(nums []int, sel []byte) int {
+    total, sign, term := 0, 1, nums[0]
+    for i, op := range sel {
+        n := nums[i+1]
+        switch op {
+        case '*':
+            term *= n
+        case '/':
+            term /= n
+        default:
+            total += sign * term
+            sign, term = 1, n
+            if op == '-' { sign = -1 }
+        }
+    }
+    return total + sign*term
+}
+
+// formatMathExpression 格式化表达式为显示文本
+// 参数 nums: 操作数列表
+// 参数 sel: 运算符列表
+// 参数 chinese: 是否以中文数字显示
+// 返回值: 形如 "3 + 7 × 2 = ?" 的字符串
+func formatMathExpression(nums []int, sel []byte, chinese bool) string {
+    symbols := map[byte]string{'+': "+", '-': "-", '*': "×", '/': "÷"}
+    var sb strings.Builder
+    for i, n := range nums {
+        if i > 0 {
+            sb.WriteString(" " + symbols[sel[i-1]] + " ")
+        }
+        if chinese {
+            sb.WriteString(numberchinese.ToChineseLowerInt(int64(n)))
+        } else {
+            sb.WriteString(strconv.Itoa(n))
+        }
+    }
+    sb.WriteString(" = ?")
+    return sb.String()
+}
+
+// compactMathExpression 去除表达式中的空格（用于绘制）
+// 参数 expr: 表达式
+// 返回值: 去空格后的表达式
+func compactMathExpression(expr string) string {
+    return strings.ReplaceAll(expr, " ", "")
+}
+
+// secureIntn 返回 [0,n) 内的加密随机整数
+// 参数 n: 上界（>0）
+// 返回值: 随机整数（加密随机源失败时回退到非加密随机）
+func secureIntn(n int) int {
+    v, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+    if err != nil {
+        return mrand.Intn(n)
+    }
+    return int(v.Int64())
+}
+
+// randIntn 返回 [0,n) 内的随机整数
+// 参数 rng: 随机源（为 nil 时使用加密随机）
+// 参数 n: 上界（>0）
+// 返回值: 随机整数
+func randIntn(rng *mrand.Rand, n int) int {
+    if rng != nil {
+        return rng.Intn(n)
+    }
+    return secureIntn(n)
+}
@@ -0,0 +1,78 @@
+package captcha
+
+import (
+    "strconv"
+    "strings"
+    "testing"
+)
+
+// TestEvalMathTerms_Precedence 测试：先乘除后加减
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：3 + 7 × 2 = 17；8 ÷ 4 - 6 = -4；2 × 3 × 4 - 10 ÷ 5 = 22
+func TestEvalMathTerms_Precedence(t *testing.T) {
+    cases := []struct {
+        nums []int
+        sel  string
+        want int
+    }{
+        {[]int{3, 7, 2}, "+*", 17},
+        {[]int{8, 4, 6}, "/-", -4},
+        {[]int{2, 3, 4, 10, 5}, "**-/", 22},
+    }
+    for _, c := range cases {
+        if got := evalMathTerms(c.nums, []byte(c.sel)); got != c.want { t.Fatalf("eval %v %q: got=%d want=%d", c.nums, c.sel, got, c.want) }
+    }
+    if got := formatMathExpression([]int{3, 7, 2}, []byte("+*"), false); got != "3 + 7 × 2 = ?" { t.Fatalf("format mismatch: %q", got) }
+    if got := formatMathExpression([]int{3, 10}, []byte("-"), true); got != "三 - 十 = ?" { t.Fatalf("chinese format mismatch: %q", got) }
+}
+
+// TestGenerateMathExpression_Constraints 测试：随机表达式满足范围、整除与非负约束
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：多次生成并按题面重新解析求值，应与返回答案一致且非负
+func TestGenerateMathExpression_Constraints(t *testing.T) {
+    opt := MathOptions{Operators: "+-*/", Operands: 3, Min: 1, Max: 12}
+    for i := 0; i < 200; i++ {
+        expr, ans, err := GenerateMathExpression(opt)
+        if err != nil { t.Fatalf("GenerateMathExpression error: %v", err) }
+        if ans < 0 { t.Fatalf("negative answer: %s %d", expr, ans) }
+        fields := strings.Fields(strings.TrimSuffix(expr, " = ?"))
+        var nums []int
+        var sel []byte
+        for j, f := range fields {
+            if j%2 == 1 {
+                sel = append(sel, map[string]byte{"+": '+', "-": '-', "×": '*', "÷": '/'}[f])
+                continue
+            }
+            n, err := strconv.Atoi(f)
+            if err != nil || n < 1 || n > 12 { t.Fatalf("operand out of range: %q in %s", f, expr) }
+            nums = append(nums, n)
+        }
+        if len(nums) != 3 { t.Fatalf("operand count mismatch: %s", expr) }
+        if got := evalMathTerms(nums, sel); got != ans { t.Fatalf("answer mismatch: %s got=%d want=%d", expr, got, ans) }
+    }
+    if _, _, err := GenerateMathExpression(MathOptions{Operators: "%"}); err == nil { t.Fatalf("expected error for unsupported operator") }
+    if _, _, err := GenerateMathExpression(MathOptions{Operators: "-", Operands: 3, Min: 5, Max: 5}); err == nil { t.Fatalf("expected error when non-negative result impossible") }
+}
+
+// TestGenerateMathCaptchaImagePNG_AndManager 测试：算术验证码图片与管理器集成
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：图片可解码且尺寸正确；Manager 使用 MathChallenge 时保存的是计算结果而非题面
+func TestGenerateMathCaptchaImagePNG_AndManager(t *testing.T) {
+    expr, ans, img, err := GenerateMathCaptchaImagePNG(MathOptions{Operators: "+*"}, 180, 60, 3, 80, nil)
+    if err != nil { t.Fatalf("GenerateMathCaptchaImagePNG error: %v", err) }
+    if !strings.HasSuffix(expr, "= ?") || ans < 0 { t.Fatalf("unexpected expr/answer: %q %d", expr, ans) }
+    if w, h := decodePNGBounds(t, img); w != 180 || h != 60 { t.Fatalf("bounds mismatch: %dx%d", w, h) }
+
+    store := NewMemoryStore(0)
+    defer store.Close()
+    var question string
+    m := NewManager(ManagerOptions{Store: store, Challenge: MathChallenge(MathOptions{}), Render: func(q string) ([]byte, error) { question = q; return nil, nil }})
+    defer m.Close()
+    id, _, _ := m.Generate()
+    answer, _, _ := store.Get(id)
+    if !strings.HasSuffix(question, "=?") || answer == question { t.Fatalf("unexpected question/answer: %q %q", question, answer) }
+    if !m.Verify(id, answer, true) { t.Fatalf("expected math answer to verify") }
+}
@@ -0,0 +1,270 @@
+package captcha
+
+import (
+    "bytes"
+    "errors"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "math"
+    mrand "math/rand"
+    "time"
+)
+
+// 本文件提供滑块拼图验证码：从背景图中随机位置切出拼图形状的滑块，返回带缺口的背景图、滑块图与缺口横坐标；
+// 校验时按容差比较用户拖动的偏移量，并可选分析拖动轨迹（点数、耗时、速度变化、纵向抖动）以识别脚本拖动。
+// 缺口横坐标 X 为答案，只保存在服务端；滑块纵坐标 Y 需下发给前端用于摆放滑块。
+
+// SliderOptions 滑块拼图选项
+// 结构体字段解释：
+// - PieceSize: 拼图块主体边长（像素，不含凸起）；<=0 时默认 背景高度/4（至少20）
+// - Rand: 随机源（缺口位置）；为 nil 时每次生成随机播种，指定后结果可复现（仅用于测试）
+type SliderOptions struct {
+    PieceSize int
+    Rand      *mrand.Rand
+}
+
+// SliderPuzzle 滑块拼图生成结果
+// 结构体字段解释：
+// - Background: 带缺口的背景图（PNG）
+// - Piece: 滑块图（PNG，拼图形状以外透明，尺寸为拼图块包围盒）
+// - X: 缺口左上角横坐标（答案，勿下发给前端）
+// - Y: 缺口左上角纵坐标（前端按此高度摆放滑块）
+type SliderPuzzle struct {
+    Background []byte
+    Piece      []byte
+    X          int
+    Y          int
+}
+
+// TrackPoint 拖动轨迹采样点
+// 结构体字段解释：
+// - X, Y: 相对拖动起点的坐标（像素）
+// - T: 相对拖动起点的毫秒数（与前端 Date.now() 差值一致）
+type TrackPoint struct {
+    X int `json:"x"`
+    Y int `json:"y"`
+    T int `json:"t"`
+}
+
+// TrackOptions 轨迹分析选项
+// 结构体字段解释：
+// - MinPoints: 最少采样点数；<=0 时默认 5
+// - MinDuration: 最短拖动耗时；<=0 时默认 200ms
+// - MaxDuration: 最长拖动耗时；<=0 时默认 20s
+// - MinSpeedCV: 分段速度变异系数（标准差/均值）下限，低于该值视为匀速脚本；0 时默认 0.1，<0 表示不检查
+// - AllowFlatY: 是否允许纵坐标全程不变（部分触屏设备如此）；默认不允许
+type TrackOptions struct {
+    MinPoints   int
+    MinDuration time.Duration
+    MaxDuration time.Duration
+    MinSpeedCV  float64
+    AllowFlatY  bool
+}
+
+// GenerateSliderCaptcha 生成滑块拼图验证码
+// 参数 bg: 背景图片（建议 320x160 左右，宽度至少为拼图块包围盒的2倍加10像素）
+// 参数 opt: 滑块选项
+// 返回值: 生成结果与错误（背景为空或尺寸过小时返回错误）
+// 关键步骤：计算拼图掩码→在滑块起始区域右侧随机选取缺口位置→复制掩码内像素为滑块并描边→
+// 背景缺口区域压暗并描边→分别编码为PNG。
+func GenerateSliderCaptcha(bg image.Image, opt SliderOptions) (*SliderPuzzle, error) {
+    if bg == nil {
+        return nil, errors.New("背景图片不能为空")
+    }
+    b := bg.Bounds()
+    w, h := b.Dx(), b.Dy()
+    s := opt.PieceSize
+    if s <= 0 { s = maxInt(20, h/4) }
+    mask := jigsawMask(s)
+    pw, ph := mask.bounds().Dx(), mask.bounds().Dy()
+    if w < pw*2+10 || h < ph {
+        return nil, errors.New("背景图片过小，无法放置拼图块")
+    }
+    rng := randOrNew(opt.Rand)
+    // 关键步骤：缺口不与左侧滑块起始位置重叠，且距右边缘留出余量
+    minX := pw + 5
+    x := minX + rng.Intn(w-pw-minX+1)
+    y := rng.Intn(h - ph + 1)
+
+    canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+    draw.Draw(canvas, canvas.Bounds(), bg, b.Min, draw.Src)
+    piece := image.NewRGBA(image.Rect(0, 0, pw, ph))
+    for py := 0; py < ph; py++ {
+        for px := 0; px < pw; px++ {
+            if !mask.at(px, py) {
+                continue
+            }
+            src := canvas.RGBAAt(x+px, y+py)
+            src.A = 255
+            hole := color.RGBA{uint8(int(src.R) * 2 / 5), uint8(int(src.G) * 2 / 5), uint8(int(src.B) * 2 / 5), 255}
+            if mask.edge(px, py) {
+                src = blendRGBA(src, color.RGBA{255, 255, 255, 255}, 0.8)
+                hole = blendRGBA(hole, color.RGBA{255, 255, 255, 255}, 0.5)
+            }
+            piece.SetRGBA(px, py, src)
+            canvas.SetRGBA(x+px, y+py, hole)
+        }
+    }
+
+    var bgBuf, pieceBuf bytes.Buffer
+    if err := png.Encode(&bgBuf, canvas); err != nil {
+        return nil, err
+    }
+    if err := png.Encode(&pieceBuf, piece); err != nil {
+        return nil, err
+    }
+    return &SliderPuzzle{Background: bgBuf.Bytes(), Piece: pieceBuf.Bytes(), X: x, Y: y}, nil
+}
+
+// VerifySlider 校验滑块偏移量
+// 参数 secretX: 生成时保存的缺口横坐标
+// 参数 userX: 用户拖动后滑块左上角的横坐标
+// 参数 tolerance: 允许的误差（像素，<0 视为0）
+// 返回值: 误差在容差内时返回 true
+func VerifySlider(secretX, userX, tolerance int) bool {
+    if tolerance < 0 { tolerance = 0 }
+    d := userX - secretX
+    return d >= -tolerance && d <= tolerance
+}
+
+// AnalyzeTrack 分析拖动轨迹是否像人工操作
+// 参数 track: 拖动轨迹（按时间顺序）
+// 参数 opt: 分析选项
+// 返回值: 轨迹可疑时返回描述原因的错误，看起来正常时返回 nil
+// 关键步骤：检查点数与时间单调→总耗时范围→纵坐标是否全程不变→分段速度变异系数（人工拖动有明显加减速，脚本多为匀速）。
+func AnalyzeTrack(track []TrackPoint, opt TrackOptions) error {
+    if opt.MinPoints <= 0 { opt.MinPoints = 5 }
+    if opt.MinDuration <= 0 { opt.MinDuration = 200 * time.Millisecond }
+    if opt.MaxDuration <= 0 { opt.MaxDuration = 20 * time.Second }
+    if opt.MinSpeedCV == 0 { opt.MinSpeedCV = 0.1 }
+    if len(track) < opt.MinPoints {
+        return errors.New("轨迹采样点过少")
+    }
+    flatY := true
+    for i := 1; i < len(track); i++ {
+        if track[i].T < track[i-1].T {
+            return errors.New("轨迹时间不是递增的")
+        }
+        if track[i].Y != track[0].Y { flatY = false }
+    }
+    d := time.Duration(track[len(track)-1].T-track[0].T) * time.Millisecond
+    if d < opt.MinDuration {
+        return errors.New("拖动耗时过短")
+    }
+    if d > opt.MaxDuration {
+        return errors.New("拖动耗时过长")
+    }
+    if flatY && !opt.AllowFlatY {
+        return errors.New("轨迹纵坐标没有任何抖动")
+    }
+    if opt.MinSpeedCV > 0 {
+        if cv, ok := speedCV(track); ok && cv < opt.MinSpeedCV {
+            return errors.New("拖动速度过于均匀")
+        }
+    }
+    return nil
+}
+
+// VerifySliderTrack 根据拖动轨迹校验滑块验证码
+// 参数 secretX: 生成时保存的缺口横坐标
+// 参数 track: 拖动轨迹（最后一个点的 X 视为滑块最终偏移）
+// 参数 tolerance: 允许的偏移误差（像素）
+// 参数 opt: 轨迹分析选项
+// 返回值: 偏移在容差内且轨迹分析通过时返回 true
+func VerifySliderTrack(secretX int, track []TrackPoint, tolerance int, opt TrackOptions) bool {
+    if len(track) == 0 {
+        return false
+    }
+    return VerifySlider(secretX, track[len(track)-1].X, tolerance) && AnalyzeTrack(track, opt) == nil
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// pieceMask 拼图块掩码（包围盒坐标系）
+// 结构体字段解释：
+// - s: 主体边长
+// - r: 凸起/凹口半径
+type pieceMask struct {
+    s, r int
+}
+
+// jigsawMask 构造拼图块掩码：正方形主体，上边与右边各一个圆形凸起，左边一个圆形凹口
+// 参数 s: 主体边长
+// 返回值: 掩码
+func jigsawMask(s int) pieceMask {
+    return pieceMask{s: s, r: maxInt(3, s/5)}
+}
+
+// bounds 掩码包围盒（主体 + 上方与右侧凸起）
+// 参数: 无
+// 返回值: 包围盒
+func (m pieceMask) bounds() image.Rectangle {
+    return image.Rect(0, 0, m.s+m.r, m.s+m.r)
+}
+
+// at 判断包围盒内的点是否属于拼图块
+// 参数 x, y: 包围盒坐标
+// 返回值: 是否属于拼图块
+func (m pieceMask) at(x, y int) bool {
+    if !image.Pt(x, y).In(m.bounds()) {
+        return false
+    }
+    fx, fy := float64(x)+0.5, float64(y)+0.5
+    s, r := float64(m.s), float64(m.r)
+    inCircle := func(cx, cy float64) bool { return math.Hypot(fx-cx, fy-cy) <= r }
+    // 关键步骤：主体位于包围盒左下方（上方与右侧留给凸起）
+    body := fx < s && fy >= r
+    if body && inCircle(0, r+s/2) {
+        return false
+    }
+    return body || inCircle(s/2, r) || inCircle(s, r+s/2)
+}
+
+// edge 判断拼图块内的点是否位于轮廓上（任一四邻域点不属于拼图块）
+// 参数 x, y: 包围盒坐标
+// 返回值: 是否为轮廓点
+func (m pieceMask) edge(x, y int) bool {
+    return !m.at(x-1, y) || !m.at(x+1, y) || !m.at(x, y-1) || !m.at(x, y+1)
+}
+
+// blendRGBA 按比例混合两种颜色
+// 参数 a, b: 颜色
+// 参数 t: b 所占比例（0~1）
+// 返回值: 混合后的不透明颜色
+func blendRGBA(a, b color.RGBA, t float64) color.RGBA {
+    mix := func(x, y uint8) uint8 { return uint8(float64(x)*(1-t) + float64(y)*t + 0.5) }
+    return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// speedCV 计算轨迹分段速度的变异系数
+// 参数 track: 拖动轨迹
+// 返回值: 变异系数与是否有足够的有效分段（至少3段）
+// 关键步骤：跳过时间间隔为0的分段；速度为每毫秒移动的欧氏距离
+func speedCV(track []TrackPoint) (float64, bool) {
+    var speeds []float64
+    for i := 1; i < len(track); i++ {
+        dt := float64(track[i].T - track[i-1].T)
+        if dt <= 0 {
+            continue
+        }
+        speeds = append(speeds, math.Hypot(float64(track[i].X-track[i-1].X), float64(track[i].Y-track[i-1].Y))/dt)
+    }
+    if len(speeds) < 3 {
+        return 0, false
+    }
+    var sum float64
+    for _, v := range speeds {
+        sum += v
+    }
+    mean := sum / float64(len(speeds))
+    if mean == 0 {
+        return 0, true
+    }
+    var sq float64
+    for _, v := range speeds {
+        sq += (v - mean) * (v - mean)
+    }
+    return math.Sqrt(sq/float64(len(speeds))) / mean, true
+}
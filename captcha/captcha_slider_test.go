@@ -0,0 +1,90 @@
+package captcha
+
+import (
+    "bytes"
+    "image"
+    "image/color"
+    "image/png"
+    mrand "math/rand"
+    "testing"
+)
+
+// sliderBackground 构造测试用渐变背景
+// 参数 w, h: 尺寸
+// 返回值: 背景图片
+func sliderBackground(w, h int) *image.RGBA {
+    img := image.NewRGBA(image.Rect(0, 0, w, h))
+    fillGradient(img, color.RGBA{40, 120, 200, 255}, color.RGBA{220, 180, 60, 255})
+    return img
+}
+
+// TestGenerateSliderCaptcha_PieceAndHole 测试：滑块与缺口位置、尺寸与透明度
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：滑块主体中心像素不透明且与原背景相同，包围盒角落透明；背景缺口处被压暗；缺口位于滑块起始区域右侧
+func TestGenerateSliderCaptcha_PieceAndHole(t *testing.T) {
+    bg := sliderBackground(320, 160)
+    for seed := int64(0); seed < 20; seed++ {
+        sp, err := GenerateSliderCaptcha(bg, SliderOptions{Rand: mrand.New(mrand.NewSource(seed))})
+        if err != nil { t.Fatalf("GenerateSliderCaptcha error: %v", err) }
+        piece, err := png.Decode(bytes.NewReader(sp.Piece))
+        if err != nil { t.Fatalf("piece decode error: %v", err) }
+        hole, err := png.Decode(bytes.NewReader(sp.Background))
+        if err != nil { t.Fatalf("background decode error: %v", err) }
+        pw, ph := piece.Bounds().Dx(), piece.Bounds().Dy()
+        if hole.Bounds().Dx() != 320 || hole.Bounds().Dy() != 160 { t.Fatalf("background bounds mismatch: %v", hole.Bounds()) }
+        if sp.X < pw || sp.X+pw > 320 || sp.Y < 0 || sp.Y+ph > 160 { t.Fatalf("hole out of range: x=%d y=%d piece=%dx%d", sp.X, sp.Y, pw, ph) }
+
+        // 主体中心：(s/2, r+s/2)，包围盒为 (s+r)x(s+r)，s=40, r=8
+        cx, cy := 20, 28
+        got := color.RGBAModel.Convert(piece.At(cx, cy)).(color.RGBA)
+        if got != bg.RGBAAt(sp.X+cx, sp.Y+cy) { t.Fatalf("piece pixel mismatch: %v vs %v", got, bg.RGBAAt(sp.X+cx, sp.Y+cy)) }
+        if _, _, _, a := piece.At(pw-1, 0).RGBA(); a != 0 { t.Fatalf("piece corner should be transparent") }
+        dark := color.RGBAModel.Convert(hole.At(sp.X+cx, sp.Y+cy)).(color.RGBA)
+        if int(dark.R)+int(dark.G)+int(dark.B) >= int(got.R)+int(got.G)+int(got.B) { t.Fatalf("hole should be darker: %v vs %v", dark, got) }
+    }
+}
+
+// TestGenerateSliderCaptcha_Errors 测试：背景为空或过小返回错误
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestGenerateSliderCaptcha_Errors(t *testing.T) {
+    if _, err := GenerateSliderCaptcha(nil, SliderOptions{}); err == nil { t.Fatalf("expected error for nil background") }
+    if _, err := GenerateSliderCaptcha(sliderBackground(80, 40), SliderOptions{PieceSize: 30}); err == nil { t.Fatalf("expected error for small background") }
+}
+
+// TestVerifySlider_Tolerance 测试：偏移容差边界
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestVerifySlider_Tolerance(t *testing.T) {
+    if !VerifySlider(100, 104, 4) || !VerifySlider(100, 96, 4) { t.Fatalf("expected offsets within tolerance to pass") }
+    if VerifySlider(100, 105, 4) || VerifySlider(100, 95, 4) { t.Fatalf("expected offsets beyond tolerance to fail") }
+    if !VerifySlider(100, 100, -1) || VerifySlider(100, 101, -1) { t.Fatalf("negative tolerance should mean exact match") }
+}
+
+// TestAnalyzeTrack 测试：人工轨迹通过，脚本特征的轨迹被拒绝
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：人工轨迹先加速后减速且纵向抖动；分别构造匀速、过快、纵坐标不变、点数过少与时间倒退的轨迹
+func TestAnalyzeTrack(t *testing.T) {
+    human := []TrackPoint{{0, 0, 0}, {6, 1, 60}, {22, 1, 120}, {55, 2, 180}, {98, 1, 240}, {130, 3, 300}, {146, 2, 380}, {152, 2, 480}, {154, 3, 600}}
+    if err := AnalyzeTrack(human, TrackOptions{}); err != nil { t.Fatalf("human track rejected: %v", err) }
+    if !VerifySliderTrack(152, human, 4, TrackOptions{}) { t.Fatalf("VerifySliderTrack should accept human track") }
+    if VerifySliderTrack(140, human, 4, TrackOptions{}) { t.Fatalf("VerifySliderTrack should reject wrong offset") }
+
+    var linear []TrackPoint
+    for i := 0; i <= 10; i++ {
+        linear = append(linear, TrackPoint{X: i * 15, Y: i % 2, T: i * 50})
+    }
+    cases := map[string][]TrackPoint{
+        "linear":   linear,
+        "fast":     {{0, 0, 0}, {50, 1, 20}, {100, 0, 40}, {140, 2, 60}, {150, 1, 80}},
+        "flatY":    {{0, 5, 0}, {6, 5, 60}, {22, 5, 120}, {55, 5, 180}, {98, 5, 240}, {150, 5, 400}},
+        "few":      {{0, 0, 0}, {150, 1, 500}},
+        "timeBack": {{0, 0, 0}, {6, 1, 60}, {22, 1, 50}, {55, 2, 180}, {150, 1, 400}},
+    }
+    for name, tr := range cases {
+        if err := AnalyzeTrack(tr, TrackOptions{}); err == nil { t.Fatalf("%s: expected track to be rejected", name) }
+    }
+    if err := AnalyzeTrack(cases["flatY"], TrackOptions{AllowFlatY: true}); err != nil { t.Fatalf("flatY should pass when allowed: %v", err) }
+}
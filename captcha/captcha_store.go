@@ -0,0 +1,302 @@
+package captcha
+
+import (
+    crand "crypto/rand"
+    "crypto/subtle"
+    "encoding/hex"
+    "errors"
+    "strings"
+    "time"
+
+    "github.com/QinWeisWord/go_utils/kvcache"
+)
+
+// 本文件提供验证码的服务端生命周期管理：签发ID、保存答案、一次性校验。
+// Store 抽象答案存储（默认内存实现基于 kvcache 的 TTL），Manager 负责生成图片与校验，
+// 通过“校验即删除”与“最大尝试次数”抵御重放与暴力枚举。
+
+// Store 验证码答案存储接口
+// 方法说明：
+// - Set: 保存答案，ttl 后过期
+// - Get: 读取答案；不存在或已过期时返回 ok=false 且 err=nil
+// - Delete: 删除答案；不存在不视为错误
+type Store interface {
+    Set(id, answer string, ttl time.Duration) error
+    Get(id string) (answer string, ok bool, err error)
+    Delete(id string) error
+}
+
+// GetDeleter 可选接口：原子地读取并删除答案
+// 说明：Store 实现该接口时，Manager 在一次性校验中使用它，保证并发提交同一ID时至多一次读到答案；
+// 未实现时退化为 Get 后 Delete（存在极小的并发重放窗口）。
+type GetDeleter interface {
+    GetAndDelete(id string) (answer string, ok bool, err error)
+}
+
+// MemoryStore 基于 kvcache 的内存答案存储（并发安全，过期自动清理）
+// 结构体字段解释：
+// - cache: 底层缓存（键为验证码ID，值为答案）
+type MemoryStore struct {
+    cache *kvcache.KVCache[string, string]
+}
+
+// NewMemoryStore 创建内存答案存储
+// 参数 cleanupInterval: 过期条目清理间隔（<=0 时仅在读取时惰性删除）
+// 返回值: 内存存储指针（使用完毕需调用 Close 释放后台协程）
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+    return &MemoryStore{cache: kvcache.NewWithOptions(kvcache.Options[string, string]{CleanupInterval: cleanupInterval})}
+}
+
+// Set 保存答案
+// 参数 id: 验证码ID
+// 参数 answer: 答案
+// 参数 ttl: 有效期（<=0 表示永不过期，不建议）
+// 返回值: 恒为nil
+func (s *MemoryStore) Set(id, answer string, ttl time.Duration) error {
+    s.cache.SetWithTTL(id, answer, ttl)
+    return nil
+}
+
+// Get 读取答案
+// 参数 id: 验证码ID
+// 返回值: 答案、是否存在与错误（恒为nil）
+func (s *MemoryStore) Get(id string) (answer string, ok bool, err error) {
+    answer, ok = s.cache.Get(id)
+    return answer, ok, nil
+}
+
+// Delete 删除答案
+// 参数 id: 验证码ID
+// 返回值: 恒为nil
+func (s *MemoryStore) Delete(id string) error {
+    s.cache.Delete(id)
+    return nil
+}
+
+// GetAndDelete 原子地读取并删除答案
+// 参数 id: 验证码ID
+// 返回值: 答案、是否存在与错误（恒为nil）
+// 关键步骤：借助缓存的 Update 在一次加锁内完成读取与删除
+func (s *MemoryStore) GetAndDelete(id string) (answer string, ok bool, err error) {
+    s.cache.Update(id, func(old string, exists bool) (string, bool) {
+        answer, ok = old, exists
+        return "", false
+    })
+    return answer, ok, nil
+}
+
+// Len 返回当前保存的答案数量
+// 参数: 无
+// 返回值: 数量（可能包含已过期但尚未清理的条目）
+func (s *MemoryStore) Len() int { return s.cache.Len() }
+
+// Close 停止后台清理协程
+// 参数: 无
+// 返回值: 无
+func (s *MemoryStore) Close() { s.cache.Close() }
+
+// ManagerOptions 验证码管理器选项
+// 结构体字段解释：
+// - Store: 答案存储；为空时使用内存存储（由 Manager.Close 负责释放）
+// - TTL: 验证码有效期；<=0 时默认5分钟
+// - Length: 验证码长度；<=0 时默认4
+// - Alphabet: 字符集；为空时使用 GenerateCodeString 的默认字符集
+// - Width, Height: 图片尺寸；<=0 时默认 160x60
+// - NoiseLines, NoiseDots: 干扰线与干扰点数量；均为0时默认 4 与 120
+// - FontBytes: 可选 TTF 字体字节
+// - IgnoreCase: 校验时是否忽略大小写
+// - MaxAttempts: 同一ID允许的最大错误次数（仅对 clear=false 的校验有意义）；<=0 时默认5
+// - Render: 自定义图片渲染函数；为空时使用 GenerateTextCaptchaImagePNG
+// - Challenge: 自定义题目生成函数，返回绘制内容与答案（如 MathChallenge）；为空时按 Length/Alphabet 生成随机字符
+type ManagerOptions struct {
+    Store       Store
+    TTL         time.Duration
+    Length      int
+    Alphabet    string
+    Width       int
+    Height      int
+    NoiseLines  int
+    NoiseDots   int
+    FontBytes   []byte
+    IgnoreCase  bool
+    MaxAttempts int
+    Render      func(code string) ([]byte, error)
+    Challenge   func() (question, answer string, err error)
+}
+
+// Manager 验证码管理器
+// 结构体字段解释：
+// - opt: 归一化后的选项
+// - store: 答案存储
+// - ownStore: 内部创建的内存存储（Close 时释放）
+// - failures: 各ID的错误次数（本进程内计数，随验证码一同过期）
+type Manager struct {
+    opt      ManagerOptions
+    store    Store
+    ownStore *MemoryStore
+    failures *kvcache.KVCache[string, int]
+}
+
+// ErrStore 保存答案失败（Generate 返回的错误可用 errors.Is 判断）
+var ErrStore = errors.New("验证码存储失败")
+
+// NewManager 创建验证码管理器
+// 参数 opt: 管理器选项（零值字段使用默认值）
+// 返回值: 管理器指针（使用完毕需调用 Close）
+func NewManager(opt ManagerOptions) *Manager {
+    if opt.TTL <= 0 { opt.TTL = 5 * time.Minute }
+    if opt.Length <= 0 { opt.Length = 4 }
+    if opt.Width <= 0 { opt.Width = 160 }
+    if opt.Height <= 0 { opt.Height = 60 }
+    if opt.NoiseLines == 0 && opt.NoiseDots == 0 {
+        opt.NoiseLines, opt.NoiseDots = 4, 120
+    }
+    if opt.MaxAttempts <= 0 { opt.MaxAttempts = 5 }
+    m := &Manager{opt: opt, store: opt.Store}
+    if m.store == nil {
+        m.ownStore = NewMemoryStore(time.Minute)
+        m.store = m.ownStore
+    }
+    m.failures = kvcache.NewWithOptions(kvcache.Options[string, int]{DefaultTTL: opt.TTL, CleanupInterval: time.Minute})
+    return m
+}
+
+// Generate 生成一个新的验证码
+// 参数: 无
+// 返回值: 验证码ID、PNG图片字节与错误
+// 关键步骤：加密随机生成ID与题目→渲染图片→保存答案（有效期为 TTL）
+func (m *Manager) Generate() (id string, png []byte, err error) {
+    question, answer, err := m.challenge()
+    if err != nil {
+        return "", nil, err
+    }
+    png, err = m.render(question)
+    if err != nil {
+        return "", nil, err
+    }
+    return m.issue(answer, png)
+}
+
+// Verify 校验用户提交的答案
+// 参数 id: 验证码ID
+// 参数 answer: 用户提交的答案（会去除首尾空白）
+// 参数 clear: 是否在本次校验后作废该验证码（无论成功与否）；登录等最终提交应传 true
+// 返回值: 是否校验通过（ID不存在、已过期、已作废或存储出错均返回 false）
+// 关键步骤：clear=true 时原子地读取并删除，杜绝重放；clear=false 时累计错误次数，达到 MaxAttempts 后作废；
+// 比较使用常量时间，避免计时侧信道
+func (m *Manager) Verify(id, answer string, clear bool) bool {
+    if id == "" {
+        return false
+    }
+    var want string
+    var ok bool
+    var err error
+    if clear {
+        want, ok, err = m.getAndDelete(id)
+        m.failures.Delete(id)
+    } else {
+        want, ok, err = m.store.Get(id)
+    }
+    if err != nil || !ok {
+        return false
+    }
+    if m.match(want, answer) {
+        return true
+    }
+    if !clear {
+        if kvcache.Increment(m.failures, id, 1) >= m.opt.MaxAttempts {
+            m.store.Delete(id)
+            m.failures.Delete(id)
+        }
+    }
+    return false
+}
+
+// Close 释放内部资源（内部创建的内存存储与错误计数缓存）
+// 参数: 无
+// 返回值: 无
+func (m *Manager) Close() {
+    if m.ownStore != nil {
+        m.ownStore.Close()
+    }
+    m.failures.Close()
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// challenge 生成题目与答案
+// 参数: 无
+// 返回值: 绘制内容、答案与错误
+func (m *Manager) challenge() (question, answer string, err error) {
+    if m.opt.Challenge != nil {
+        return m.opt.Challenge()
+    }
+    code, err := GenerateCodeString(m.opt.Length, m.opt.Alphabet)
+    return code, code, err
+}
+
+// render 渲染验证码图片
+// 参数 code: 验证码文本
+// 返回值: 图片字节与错误
+func (m *Manager) render(code string) ([]byte, error) {
+    if m.opt.Render != nil {
+        return m.opt.Render(code)
+    }
+    return GenerateTextCaptchaImagePNG(code, m.opt.Width, m.opt.Height, m.opt.NoiseLines, m.opt.NoiseDots, m.opt.FontBytes)
+}
+
+// getAndDelete 读取并删除答案（优先使用存储的原子实现）
+// 参数 id: 验证码ID
+// 返回值: 答案、是否存在与错误
+func (m *Manager) getAndDelete(id string) (string, bool, error) {
+    if gd, ok := m.store.(GetDeleter); ok {
+        return gd.GetAndDelete(id)
+    }
+    answer, ok, err := m.store.Get(id)
+    if err != nil {
+        return "", false, err
+    }
+    if err := m.store.Delete(id); err != nil {
+        return "", false, err
+    }
+    return answer, ok, nil
+}
+
+// match 比较答案（常量时间，可选忽略大小写）
+// 参数 want: 正确答案
+// 参数 got: 用户提交的答案
+// 返回值: 是否一致
+func (m *Manager) match(want, got string) bool {
+    got = strings.TrimSpace(got)
+    if m.opt.IgnoreCase {
+        want, got = strings.ToLower(want), strings.ToLower(got)
+    }
+    return subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// issue 签发验证码：生成ID并保存答案（Generate 与 GenerateWithPreset 共用）
+// 参数 answer: 正确答案
+// 参数 img: 已渲染的图片字节
+// 返回值: 验证码ID、图片字节与错误（存储失败时包装 ErrStore）
+// 关键步骤：加密随机生成ID→以 TTL 保存答案
+func (m *Manager) issue(answer string, img []byte) (id string, out []byte, err error) {
+    id, err = newCaptchaID()
+    if err != nil {
+        return "", nil, err
+    }
+    if err := m.store.Set(id, answer, m.opt.TTL); err != nil {
+        return "", nil, errors.Join(ErrStore, err)
+    }
+    return id, img, nil
+}
+
+// newCaptchaID 生成加密随机的验证码ID（32位十六进制）
+// 参数: 无
+// 返回值: ID 与错误（加密随机源读取失败时返回错误，不回退到非加密随机）
+func newCaptchaID() (string, error) {
+    var b [16]byte
+    if _, err := crand.Read(b[:]); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b[:]), nil
+}
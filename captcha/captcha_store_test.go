@@ -0,0 +1,98 @@
+package captcha
+
+import (
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// TestManager_GenerateVerify 测试：生成→校验的完整生命周期
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：生成可解码PNG与32位ID；正确答案校验通过；clear=true 后再次提交失败（防重放）
+func TestManager_GenerateVerify(t *testing.T) {
+    store := NewMemoryStore(0)
+    defer store.Close()
+    m := NewManager(ManagerOptions{Store: store})
+    defer m.Close()
+    id, img, err := m.Generate()
+    if err != nil { t.Fatalf("Generate error: %v", err) }
+    if len(id) != 32 { t.Fatalf("id length mismatch: %q", id) }
+    if w, h := decodePNGBounds(t, img); w != 160 || h != 60 { t.Fatalf("bounds mismatch: %dx%d", w, h) }
+    answer, ok, _ := store.Get(id)
+    if !ok || len(answer) != 4 { t.Fatalf("answer not stored: %q", answer) }
+    if !m.Verify(id, answer, false) { t.Fatalf("expected verify ok without clear") }
+    if !m.Verify(id, " "+answer+" ", true) { t.Fatalf("expected verify ok with clear") }
+    if m.Verify(id, answer, true) { t.Fatalf("expected replay to fail") }
+    if m.Verify("", "", true) { t.Fatalf("expected empty id to fail") }
+}
+
+// TestManager_IgnoreCaseAndClearOnFailure 测试：忽略大小写选项与失败即作废
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：默认区分大小写；IgnoreCase 时小写答案通过；clear=true 的错误提交也会作废验证码
+func TestManager_IgnoreCaseAndClearOnFailure(t *testing.T) {
+    store := NewMemoryStore(0)
+    defer store.Close()
+    strict := NewManager(ManagerOptions{Store: store, Alphabet: "ABC"})
+    defer strict.Close()
+    id, _, _ := strict.Generate()
+    answer, _, _ := store.Get(id)
+    if strict.Verify(id, strings.ToLower(answer), false) { t.Fatalf("expected case-sensitive mismatch") }
+
+    loose := NewManager(ManagerOptions{Store: store, Alphabet: "ABC", IgnoreCase: true})
+    defer loose.Close()
+    id, _, _ = loose.Generate()
+    answer, _, _ = store.Get(id)
+    if !loose.Verify(id, strings.ToLower(answer), false) { t.Fatalf("expected case-insensitive match") }
+    if loose.Verify(id, "wrong", true) { t.Fatalf("expected wrong answer to fail") }
+    if _, ok, _ := store.Get(id); ok { t.Fatalf("expected captcha cleared after failed final verify") }
+}
+
+// TestManager_MaxAttemptsAndExpiry 测试：错误次数上限与过期
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：连续错误达到 MaxAttempts 后正确答案也无法通过；超过 TTL 后校验失败
+func TestManager_MaxAttemptsAndExpiry(t *testing.T) {
+    store := NewMemoryStore(0)
+    defer store.Close()
+    m := NewManager(ManagerOptions{Store: store, MaxAttempts: 3, TTL: 50 * time.Millisecond})
+    defer m.Close()
+    id, _, _ := m.Generate()
+    answer, _, _ := store.Get(id)
+    for i := 0; i < 3; i++ { m.Verify(id, "bad", false) }
+    if m.Verify(id, answer, false) { t.Fatalf("expected captcha invalid after max attempts") }
+
+    id, _, _ = m.Generate()
+    answer, _, _ = store.Get(id)
+    time.Sleep(60 * time.Millisecond)
+    if m.Verify(id, answer, true) { t.Fatalf("expected expired captcha to fail") }
+}
+
+// TestManager_ConcurrentReplay 测试：并发提交同一答案时至多一次通过
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：内存存储实现 GetDeleter，clear=true 的校验原子地读取并删除
+func TestManager_ConcurrentReplay(t *testing.T) {
+    m := NewManager(ManagerOptions{Render: func(string) ([]byte, error) { return nil, nil }})
+    defer m.Close()
+    id, _, _ := m.Generate()
+    answer, _, _ := m.store.Get(id)
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    passed := 0
+    for i := 0; i < 16; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if m.Verify(id, answer, true) {
+                mu.Lock()
+                passed++
+                mu.Unlock()
+            }
+        }()
+    }
+    wg.Wait()
+    if passed != 1 { t.Fatalf("expected exactly one success, got %d", passed) }
+}
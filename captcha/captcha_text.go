@@ -1,12 +1,9 @@
 package captcha
 
 import (
-    "bytes"
     "errors"
     "image"
     "image/color"
-    "image/draw"
-    "image/png"
     "math"
     mrand "math/rand"
 
@@ -30,7 +27,7 @@ import (
 // 返回值: PNG编码的图片字节与错误
 // 关键步骤：包装内部实现，默认缩放比例为 1.0。
 func GenerateTextCaptchaImagePNG(text string, width, height int, noiseLines, noiseDots int, fontBytes []byte) ([]byte, error) {
-    return generateTextCaptchaImagePNGInternal(text, width, height, noiseLines, noiseDots, fontBytes, 1.0)
+    return generateTextCaptchaImagePNGInternal(text, width, height, noiseLines, noiseDots, fontBytes, 1.0, nil)
 }
 
 // GenerateTextCaptchaImagePNGWithScale 生成文本图片验证码（支持指定字体缩放比例）
@@ -44,7 +41,7 @@ func GenerateTextCaptchaImagePNG(text string, width, height int, noiseLines, noi
 // 返回值: PNG编码的图片字节与错误
 // 关键步骤：透传到内部实现，并对缩放进行安全范围约束。
 func GenerateTextCaptchaImagePNGWithScale(text string, width, height int, noiseLines, noiseDots int, fontBytes []byte, scale float64) ([]byte, error) {
-    return generateTextCaptchaImagePNGInternal(text, width, height, noiseLines, noiseDots, fontBytes, scale)
+    return generateTextCaptchaImagePNGInternal(text, width, height, noiseLines, noiseDots, fontBytes, scale, nil)
 }
 
 // generateTextCaptchaImagePNGInternal 文本图片验证码内部实现（含缩放参数）
@@ -53,25 +50,41 @@ func GenerateTextCaptchaImagePNGWithScale(text string, width, height int, noiseL
 // 参数 noiseLines,noiseDots: 干扰线与干扰点数量
 // 参数 fontBytes: 可选TTF字节（为空则用默认矢量字体）
 // 参数 scale: 字体缩放比例（>0；建议范围 0.6~2.0）
+// 参数 rng: 随机源（为nil时每次调用随机播种）
 // 返回值: PNG编码字节与错误
 // 关键步骤：选择字体（按高度×比例）、逐字符绘制与形变、噪声与波纹、最终PNG编码。
-func generateTextCaptchaImagePNGInternal(text string, width, height int, noiseLines, noiseDots int, fontBytes []byte, scale float64) ([]byte, error) {
+func generateTextCaptchaImagePNGInternal(text string, width, height int, noiseLines, noiseDots int, fontBytes []byte, scale float64, rng *mrand.Rand) ([]byte, error) {
+    if err := checkTextCaptchaArgs(text, width, height); err != nil {
+        return nil, err
+    }
+    // 关键步骤：沿用旧行为（缩放比例截断到 0.6~2.0、字体解析失败回退 basicfont），其余交给 Config 渲染
+    c := NewConfig(WithSize(width, height), WithNoise(noiseLines, noiseDots), WithRand(rng))
+    if err := c.normalize(); err != nil {
+        return nil, err
+    }
+    return c.renderText(text, repeatFace(textFace(fontBytes, height, scale), len([]rune(text))))
+}
+
+// checkTextCaptchaArgs 校验文本图片验证码参数
+// 参数 text: 验证码文本内容
+// 参数 width,height: 图片尺寸（像素）
+// 返回值: 参数非法时返回错误
+func checkTextCaptchaArgs(text string, width, height int) error {
     if text == "" {
-        return nil, errors.New("验证码文本不能为空")
+        return errors.New("验证码文本不能为空")
     }
     if width < 60 || height < 24 {
-        return nil, errors.New("图片尺寸过小，至少需60x24")
+        return errors.New("图片尺寸过小，至少需60x24")
     }
-    // 关键步骤：使用默认随机源用于抖动与干扰（Go 1.20 起无需调用 Seed）
-
-    // 关键步骤：构造白色背景图
-    img := image.NewRGBA(image.Rect(0, 0, width, height))
-    draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{255, 255, 255, 255}}, image.Point{}, draw.Src)
-
-    // 关键步骤：背景噪声纹理（浅色斜线）
-    addBackgroundNoiseTexture(img, maxInt(4, width/24))
+    return nil
+}
 
-    // 关键步骤：选择字体
+// textFace 按图片高度与缩放比例选择字体
+// 参数 fontBytes: 可选TTF字节（为空则用默认矢量字体）
+// 参数 height: 图片高度（像素）
+// 参数 scale: 字体缩放比例（会约束到 0.6~2.0）
+// 返回值: 字体（解析失败时回退到 basicfont）
+func textFace(fontBytes []byte, height int, scale float64) font.Face {
     var face font.Face
     // 关键步骤：对 scale 进行安全约束，避免过大或过小影响可读性
     if scale <= 0 { scale = 1.0 }
@@ -102,32 +115,69 @@ func generateTextCaptchaImagePNGInternal(text string, width, height int, noiseLi
             face = basicfont.Face7x13
         }
     }
+    return face
+}
 
-    // 关键步骤：计算基线（垂直居中）
-    metrics := face.Metrics()
-    baseline := (height-metrics.Height.Ceil())/2 + metrics.Ascent.Ceil()
+// repeatFace 为每个字符重复使用同一字体
+// 参数 face: 字体
+// 参数 n: 字符数量
+// 返回值: 字体列表
+func repeatFace(face font.Face, n int) []font.Face {
+    faces := make([]font.Face, n)
+    for i := range faces {
+        faces[i] = face
+    }
+    return faces
+}
+
+// glyphStyle 单个字符的绘制样式（颜色与形变），动画帧之间保持不变以保证可读性
+type glyphStyle struct {
+    col   color.RGBA
+    angle float64
+    shear float64
+}
+
+// renderTextFrame 绘制一帧文本验证码图像
+// 参数 text: 验证码文本内容（已校验）
+// 参数 c: 已归一化的渲染配置（尺寸、背景、干扰点、波纹与随机源）
+// 参数 faces: 每个字符使用的字体（支持逐字回退到不同字体）
+// 参数 styles: 每个字符的样式
+// 参数 lines: 干扰线
+// 参数 wavePhase: 整体波纹相位（弧度）
+// 返回值: 绘制完成的图像
+// 关键步骤：背景与纹理→逐字符绘制与形变（每次调用重新随机抖动）→噪声→波纹。
+func renderTextFrame(text string, c *Config, faces []font.Face, styles []glyphStyle, lines []noiseLine, wavePhase float64) *image.RGBA {
+    width, height := c.Width, c.Height
+    // 关键步骤：构造背景图
+    img := image.NewRGBA(image.Rect(0, 0, width, height))
+    c.Background.paint(img)
+
+    // 关键步骤：背景噪声纹理（浅色斜线）
+    addBackgroundNoiseTexture(c.Rand, img, maxInt(4, width/24))
 
     // 关键步骤：逐字符绘制，按格子与轻微抖动分布
     n := len([]rune(text))
     cellW := width / n
+    step := float64(cellW)
+    if c.Overlap > 0 {
+        // 关键步骤：重叠时加宽字符格并按 (1-Overlap) 步进，使 n 个字符仍恰好铺满宽度
+        cellW = int(float64(width) / (1 + float64(n-1)*(1-c.Overlap)))
+        step = float64(cellW) * (1 - c.Overlap)
+    }
     padX := maxInt(2, cellW/10)
-    // 关键步骤：形变参数（旋转/错切与全局波纹）——为提升清晰度，降低默认强度
-    rotMax := 8.0                       // 最大旋转角度（度）
-    shearMax := 0.12                    // 最大水平错切因子
-    waveAmp := maxInt(1, height/36)     // 波纹振幅（像素）
-    waveFreq := 2.0                     // 波纹频率（越大波越密）
 
     for i, r := range []rune(text) {
         // 关键步骤：每个字符独立小画布（透明背景），先正常绘制再变形
         cell := image.NewRGBA(image.Rect(0, 0, cellW, height))
-        jitterX := mrand.Intn(maxInt(1, cellW/12)) - cellW/24
-        jitterY := mrand.Intn(maxInt(1, height/18)) - height/36
-        // 关键步骤：字符颜色随机但偏深，提升可读性
-        col := color.RGBA{uint8(mrand.Intn(120)), uint8(mrand.Intn(120)), uint8(mrand.Intn(120)), 255}
+        // 关键步骤：计算基线（垂直居中）
+        metrics := faces[i].Metrics()
+        baseline := (height-metrics.Height.Ceil())/2 + metrics.Ascent.Ceil()
+        jitterX := c.Rand.Intn(maxInt(1, cellW/12)) - cellW/24
+        jitterY := c.Rand.Intn(maxInt(1, height/18)) - height/36
         d := &font.Drawer{
             Dst:  cell,
-            Src:  &image.Uniform{col},
-            Face: face,
+            Src:  &image.Uniform{styles[i].col},
+            Face: faces[i],
             Dot:  fixed.P(padX+jitterX, baseline+jitterY),
         }
         d.DrawString(string(r))
@@ -135,44 +185,25 @@ func generateTextCaptchaImagePNGInternal(text string, width, height int, noiseLi
         // 说明：通过矢量字体按高度×scale设定来控制大小，避免绘制后缩放导致模糊
 
         // 关键步骤：对字符画布施加旋转与错切
-        angle := (mrand.Float64()*2 - 1) * rotMax
-        rot := rotateRGBA(cell, angle)
-        shear := (mrand.Float64()*2 - 1) * shearMax
+        rot := rotateRGBA(cell, styles[i].angle)
         // 关键步骤：将变形后的字符贴到主图（按格子起点）
         // 居中放置到格子内
-        atX := i*cellW + (cellW-rot.Bounds().Dx())/2
+        left := int(float64(i) * step)
+        atX := left + (cellW-rot.Bounds().Dx())/2
         // 关键步骤：当旋转后宽度超过格子宽度时进行左对齐防止越界裁剪
-        if rot.Bounds().Dx() > cellW { atX = i*cellW }
-        compositeShearRGBA(img, rot, atX, 0, shear)
+        if rot.Bounds().Dx() > cellW { atX = left }
+        compositeShearRGBA(img, rot, atX, 0, styles[i].shear)
     }
 
-    // 关键步骤：绘制干扰线
-    for i := 0; i < noiseLines; i++ {
-        x0 := mrand.Intn(width)
-        y0 := mrand.Intn(height)
-        x1 := mrand.Intn(width)
-        y1 := mrand.Intn(height)
-        lc := color.RGBA{uint8(150 + mrand.Intn(105)), uint8(150 + mrand.Intn(105)), uint8(150 + mrand.Intn(105)), 255}
-        drawLine(img, x0, y0, x1, y1, lc)
-    }
-
-    // 关键步骤：绘制干扰点
-    for i := 0; i < noiseDots; i++ {
-        x := mrand.Intn(width)
-        y := mrand.Intn(height)
-        dc := color.RGBA{uint8(mrand.Intn(255)), uint8(mrand.Intn(255)), uint8(mrand.Intn(255)), 255}
-        img.Set(x, y, dc)
-    }
+    // 关键步骤：绘制干扰线与干扰点
+    drawNoiseLines(img, lines)
+    drawNoiseDots(c.Rand, img, c.NoiseDots)
 
     // 关键步骤：整体波纹扭曲增强对抗性
-    img = applyWaveX(img, waveAmp, waveFreq)
-
-    // 关键步骤：编码为PNG字节
-    var buf bytes.Buffer
-    if err := png.Encode(&buf, img); err != nil {
-        return nil, err
+    if c.WaveAmplitude > 0 {
+        img = applyWaveX(img, c.WaveAmplitude, c.WaveFrequency, wavePhase)
     }
-    return buf.Bytes(), nil
+    return img
 }
 
 // 说明：maxInt 已在同包 captcha.go 中提供，此处复用。
@@ -239,13 +270,13 @@ func compositeShearRGBA(dst *image.RGBA, src *image.RGBA, atX, atY int, shearX f
 // 参数 img: 原图像
 // 参数 amplitude: 波纹振幅（像素）
 // 参数 frequency: 波纹频率（越大越密）
+// 参数 phase: 波纹相位（弧度；动画中逐帧推进即可产生流动效果）
 // 返回值: 扭曲后的新图像
-func applyWaveX(img *image.RGBA, amplitude int, frequency float64) *image.RGBA {
+func applyWaveX(img *image.RGBA, amplitude int, frequency float64, phase float64) *image.RGBA {
     b := img.Bounds()
     w := b.Dx()
     h := b.Dy()
     dst := image.NewRGBA(b)
-    phase := mrand.Float64() * 2 * math.Pi
     for y := 0; y < h; y++ {
         shift := int(float64(amplitude) * math.Sin(frequency*float64(y) + phase))
         for x := 0; x < w; x++ {
@@ -286,17 +317,18 @@ func scaleRGBA(src *image.RGBA, factor float64) *image.RGBA {
     return dst
 }
 // addBackgroundNoiseTexture 添加浅色背景噪声纹理（斜线）
+// 参数 rng: 随机源
 // 参数 img: 目标图像
 // 参数 lines: 噪声线数量
 // 返回值: 无
-func addBackgroundNoiseTexture(img *image.RGBA, lines int) {
+func addBackgroundNoiseTexture(rng *mrand.Rand, img *image.RGBA, lines int) {
     b := img.Bounds()
     w := b.Dx()
     h := b.Dy()
     lc := color.RGBA{230, 230, 230, 255}
     for i := 0; i < lines; i++ {
-        x0 := mrand.Intn(w)
-        x1 := x0 + mrand.Intn(maxInt(1, w/3))
+        x0 := rng.Intn(w)
+        x1 := x0 + rng.Intn(maxInt(1, w/3))
         drawLine(img, x0, 0, x1, h-1, lc)
     }
 }
\ No newline at end of file
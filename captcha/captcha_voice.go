@@ -0,0 +1,252 @@
+package captcha
+
+import (
+    "math"
+    mrand "math/rand"
+)
+
+// 本文件提供内置的普通话数字语音（líng、yī、èr……jiǔ），作为音频验证码未提供录音样本时的默认音源。
+// 采用共振峰合成：每个数字按音段（声母、韵母、鼻尾）给出共振峰目标与声源类型，浊音由声门脉冲激励、
+// 送气与摩擦由噪声激励，经级联共振峰滤波器生成，并按声调曲线变化基频。
+// 每次合成随机选择基频（音高）、声道长度（音色）与语速，同一数字每次的波形都不同。
+// 合成语音可以听懂但机械感明显，正式环境仍建议通过 AudioOptions.Samples 提供真人录音。
+
+// voiceSeg 合成音段（内部使用）
+// 结构体字段解释：
+// - ms: 时长（毫秒）
+// - f: 音段起点的 F1~F3（Hz）
+// - to: 音段终点的 F1~F3；零值表示与 f 相同
+// - voice: 浊音（声门脉冲）幅度
+// - asp: 送气噪声幅度（与浊音共用共振峰滤波）
+// - fric: 摩擦噪声幅度（经独立带通滤波）
+// - fricHz: 摩擦噪声中心频率（Hz）
+type voiceSeg struct {
+    ms     float64
+    f, to  [3]float64
+    voice  float64
+    asp    float64
+    fric   float64
+    fricHz float64
+}
+
+// digitVoice 数字的音段序列与声调（内部使用）
+// 结构体字段解释：
+// - segs: 音段序列
+// - tone: 声调（1 阴平、2 阳平、3 上声、4 去声）
+type digitVoice struct {
+    segs []voiceSeg
+    tone int
+}
+
+// 元音与响音的共振峰目标（成年男性声道，合成时按随机声道长度缩放）
+var (
+    fmtA    = [3]float64{800, 1250, 2600} // a
+    fmtI    = [3]float64{290, 2250, 3000} // i
+    fmtU    = [3]float64{320, 700, 2400}  // u
+    fmtO    = [3]float64{480, 920, 2500}  // o（ou 的起点）
+    fmtEr   = [3]float64{520, 1350, 1650} // er（卷舌，F3 显著降低）
+    fmtZi   = [3]float64{380, 1400, 2700} // 舌尖元音（si 的韵母）
+    fmtL    = [3]float64{350, 1100, 2700} // 边音 l
+    fmtN    = [3]float64{250, 1600, 2600} // 鼻尾 n
+    fmtNg   = [3]float64{250, 1100, 2500} // 鼻尾 ng
+    fmtLab  = [3]float64{400, 900, 2300}  // 双唇塞音除阻后的过渡
+    fmtNone = [3]float64{}
+)
+
+// digitVoices 各数字的普通话发音描述
+var digitVoices = map[rune]digitVoice{
+    '0': {tone: 2, segs: []voiceSeg{{ms: 60, f: fmtL, voice: 0.5}, {ms: 170, f: fmtI, voice: 1}, {ms: 130, f: fmtNg, voice: 0.35}}},
+    '1': {tone: 1, segs: []voiceSeg{{ms: 380, f: fmtI, voice: 1}}},
+    '2': {tone: 4, segs: []voiceSeg{{ms: 80, f: [3]float64{620, 1250, 1950}, to: fmtEr, voice: 1}, {ms: 260, f: fmtEr, voice: 1}}},
+    '3': {tone: 1, segs: []voiceSeg{{ms: 140, f: fmtA, fric: 1, fricHz: 5500}, {ms: 230, f: fmtA, voice: 1}, {ms: 110, f: fmtN, voice: 0.35}}},
+    '4': {tone: 4, segs: []voiceSeg{{ms: 150, f: fmtZi, fric: 1, fricHz: 5500}, {ms: 260, f: fmtZi, voice: 1}}},
+    '5': {tone: 3, segs: []voiceSeg{{ms: 60, f: [3]float64{300, 600, 2300}, to: fmtU, voice: 0.8}, {ms: 340, f: fmtU, voice: 1}}},
+    '6': {tone: 4, segs: []voiceSeg{{ms: 60, f: fmtL, voice: 0.5}, {ms: 60, f: fmtI, voice: 1}, {ms: 110, f: fmtO, voice: 1}, {ms: 150, f: fmtO, to: fmtU, voice: 1}}},
+    '7': {tone: 1, segs: []voiceSeg{{ms: 110, f: fmtI, asp: 0.3, fric: 0.8, fricHz: 3200}, {ms: 270, f: fmtI, voice: 1}}},
+    '8': {tone: 1, segs: []voiceSeg{{ms: 40, f: fmtNone}, {ms: 10, f: fmtLab, fric: 0.6, fricHz: 1200}, {ms: 30, f: fmtLab, to: fmtA, voice: 1}, {ms: 270, f: fmtA, voice: 1}}},
+    '9': {tone: 3, segs: []voiceSeg{{ms: 45, f: fmtI, fric: 0.7, fricHz: 3200}, {ms: 60, f: fmtI, voice: 1}, {ms: 140, f: fmtO, voice: 1}, {ms: 160, f: fmtO, to: fmtU, voice: 1}}},
+}
+
+// fricGain 摩擦噪声相对浊音的增益（使擦音比元音低约 10dB）
+const fricGain = 0.12
+
+// preEmphasis 浊音输出的一阶预加重系数
+const preEmphasis = 0.8
+
+// toneContours 各声调的五度值曲线（按浊音段时长均匀分布的折线）
+var toneContours = map[int][]float64{
+    1: {5, 5},
+    2: {3, 5},
+    3: {2, 1, 4},
+    4: {5, 1},
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// resonator 二阶数字共振器（Klatt 形式，直流增益为1）
+// 结构体字段解释：
+// - y1, y2: 前两个输出样本
+type resonator struct {
+    y1, y2 float64
+}
+
+// step 以给定中心频率与带宽处理一个样本
+// 参数 x: 输入样本
+// 参数 f: 中心频率（Hz）
+// 参数 bw: 带宽（Hz）
+// 参数 rate: 采样率
+// 返回值: 输出样本
+func (r *resonator) step(x, f, bw float64, rate int) float64 {
+    t := 1 / float64(rate)
+    c := -math.Exp(-2 * math.Pi * bw * t)
+    b := 2 * math.Exp(-math.Pi*bw*t) * math.Cos(2*math.Pi*f*t)
+    y := (1-b-c)*x + b*r.y1 + c*r.y2
+    r.y2, r.y1 = r.y1, y
+    return y
+}
+
+// synthDigit 合成一个数字的普通话语音
+// 参数 rng: 随机源（决定基频、声道长度、语速与噪声）
+// 参数 digit: 数字字符（'0'-'9'）
+// 参数 rate: 采样率
+// 返回值: 16位PCM样本（峰值约为满幅的 85%）
+// 关键步骤：随机选取嗓音参数→逐样本插值音段目标并平滑（避免爆音）→声门脉冲与送气噪声经级联共振峰，
+// 摩擦噪声经独立带通→叠加后归一化。
+func synthDigit(rng *mrand.Rand, digit rune, rate int) []int16 {
+    dv := digitVoices[digit]
+    base := 100 + rng.Float64()*90    // 基频：约覆盖男声到女声
+    tract := 0.92 + rng.Float64()*0.2 // 声道长度缩放：影响共振峰位置
+    tempo := 0.85 + rng.Float64()*0.3 // 语速缩放
+    nyq := float64(rate) / 2
+
+    // 关键步骤：展开音段，末尾追加 40ms 静音供包络衰减
+    segs := append(append([]voiceSeg(nil), dv.segs...), voiceSeg{ms: 40 / tempo})
+    bounds := make([]int, len(segs)+1)
+    voicedStart, voicedEnd := -1, 0
+    for i, s := range segs {
+        bounds[i+1] = bounds[i] + int(s.ms*tempo*float64(rate)/1000)
+        if s.voice > 0 {
+            if voicedStart < 0 { voicedStart = bounds[i] }
+            voicedEnd = bounds[i+1]
+        }
+    }
+    n := bounds[len(segs)]
+    out := make([]float64, n)
+
+    var res [4]resonator
+    var fricRes resonator
+    var cur [3]float64
+    var voice, asp, fric, fricHz float64
+    started := false
+    ampAlpha := 1 - math.Exp(-1/(0.008*float64(rate)))
+    fmtAlpha := 1 - math.Exp(-1/(0.012*float64(rate)))
+    phase, prevFlow, prevNoise, prevX := 0.0, 0.0, 0.0, 0.0
+    periodJitter := 1.0
+    seg := 0
+    for i := 0; i < n; i++ {
+        for seg < len(segs)-1 && i >= bounds[seg+1] {
+            seg++
+        }
+        s := segs[seg]
+        frac := float64(i-bounds[seg]) / float64(maxInt(1, bounds[seg+1]-bounds[seg]))
+        to := s.to
+        if to == fmtNone { to = s.f }
+        var target [3]float64
+        for k := range target {
+            target[k] = (s.f[k] + (to[k]-s.f[k])*frac) * tract
+        }
+        // 关键步骤：静音段保持上一音段的共振峰，仅让幅度衰减
+        if s.f != fmtNone {
+            if !started {
+                cur, started = target, true
+            }
+            for k := range cur {
+                cur[k] += (target[k] - cur[k]) * fmtAlpha
+            }
+        }
+        voice += (s.voice - voice) * ampAlpha
+        asp += (s.asp - asp) * ampAlpha
+        fric += (s.fric - fric) * ampAlpha
+        if s.fricHz > 0 { fricHz = s.fricHz }
+
+        // 关键步骤：按声调曲线计算基频（五度值每级约两个半音），叠加微小的周期抖动
+        u := 0.0
+        if voicedEnd > voicedStart {
+            u = math.Max(0, math.Min(1, float64(i-voicedStart)/float64(voicedEnd-voicedStart)))
+        }
+        f0 := base * math.Pow(2, (toneLevel(dv.tone, u)-3)/6) * periodJitter
+        phase += f0 / float64(rate)
+        if phase >= 1 {
+            phase -= 1
+            periodJitter = 1 + (rng.Float64()*2-1)*0.01
+        }
+        flow := rosenbergFlow(phase)
+        glottal := (flow - prevFlow) * float64(rate) / f0 / 4
+        prevFlow = flow
+
+        x := voice*glottal + asp*(rng.Float64()*2-1)*0.5
+        bws := [4]float64{80, 100, 150, 250}
+        freqs := [4]float64{cur[0], cur[1], cur[2], 3500 * tract}
+        for k := range res {
+            if freqs[k] > 0 && freqs[k] < nyq*0.9 {
+                x = res[k].step(x, freqs[k], bws[k], rate)
+            }
+        }
+        // 关键步骤：预加重补偿声门脉冲的高频衰减，使 F2/F3 更清晰；摩擦噪声先做一阶差分（抑制低频）再经带通，集中在高频区
+        y := x - preEmphasis*prevX
+        prevX = x
+        noise := rng.Float64()*2 - 1
+        if fric > 1e-4 {
+            fc := math.Min(fricHz, nyq*0.85)
+            y += fric * fricRes.step(noise-prevNoise, fc, fc/2.5, rate) * fricGain
+        }
+        prevNoise = noise
+        out[i] = y
+    }
+
+    // 关键步骤：归一化到满幅的 85%
+    peak := 0.0
+    for _, v := range out {
+        peak = math.Max(peak, math.Abs(v))
+    }
+    pcm := make([]int16, n)
+    if peak == 0 {
+        return pcm
+    }
+    for i, v := range out {
+        pcm[i] = int16(math.Round(v / peak * 0.85 * 32767))
+    }
+    return pcm
+}
+
+// toneLevel 计算声调曲线在相对位置 u 处的五度值
+// 参数 tone: 声调（1-4）
+// 参数 u: 浊音段内的相对位置（0~1）
+// 返回值: 五度值（1 最低，5 最高）
+func toneLevel(tone int, u float64) float64 {
+    pts := toneContours[tone]
+    if len(pts) == 0 {
+        return 3
+    }
+    pos := u * float64(len(pts)-1)
+    i := int(pos)
+    if i >= len(pts)-1 {
+        return pts[len(pts)-1]
+    }
+    return pts[i] + (pts[i+1]-pts[i])*(pos-float64(i))
+}
+
+// rosenbergFlow Rosenberg 声门气流脉冲
+// 参数 phase: 周期内相位（0~1）
+// 返回值: 归一化气流（0~1）
+// 关键步骤：开启相占 40%（升余弦），关闭相占 16%（余弦下降），其余为闭合。
+func rosenbergFlow(phase float64) float64 {
+    const open, closing = 0.4, 0.16
+    switch {
+    case phase < open:
+        return 0.5 * (1 - math.Cos(math.Pi*phase/open))
+    case phase < open+closing:
+        return math.Cos(math.Pi / 2 * (phase - open) / closing)
+    }
+    return 0
+}
@@ -0,0 +1,150 @@
+package captchahttp
+
+import (
+    "encoding/json"
+    "net/http"
+    "path"
+
+    "github.com/QinWeisWord/go_utils/captcha"
+)
+
+// 本文件提供验证码的 HTTP 接入：签发验证码的 Handler（图片接口与 JSON 接口）与校验中间件。
+// 答案保存在 captcha.Manager 的存储中，前端只拿到验证码ID与图片；提交表单时携带ID与答案，
+// 由中间件一次性校验（无论成功与否都作废该ID）后再调用业务处理器。
+
+// Options 处理器与中间件选项
+// 结构体字段解释：
+// - IDField, AnswerField: 表单/查询参数中的验证码ID与答案字段名；默认 "captcha_id" 与 "captcha_answer"
+// - IDHeader, AnswerHeader: 请求头中的验证码ID与答案（优先于表单字段，适合 JSON 请求体）；
+//   图片接口也通过 IDHeader 响应头返回ID；默认 "X-Captcha-Id" 与 "X-Captcha-Answer"
+// - OnFailure: 校验失败时的处理器；为空时返回 403 与纯文本提示
+type Options struct {
+    IDField      string
+    AnswerField  string
+    IDHeader     string
+    AnswerHeader string
+    OnFailure    http.Handler
+}
+
+// Handler 签发验证码的 HTTP 处理器
+// 结构体字段解释：
+// - m: 验证码管理器
+// - opt: 归一化后的选项
+type Handler struct {
+    m   *captcha.Manager
+    opt Options
+}
+
+// NewHandler 创建签发验证码的处理器
+// 参数 m: 验证码管理器（负责生成图片与保存答案）
+// 参数 opt: 选项（零值字段使用默认值）
+// 返回值: 处理器指针
+// 关键步骤：按请求路径的最后一段分发：".../image" 返回图片，".../new" 返回 JSON，其余返回 404；
+// 通常挂载为 mux.Handle("/captcha/", h)
+func NewHandler(m *captcha.Manager, opt Options) *Handler {
+    return &Handler{m: m, opt: normalizeOptions(opt)}
+}
+
+// ServeHTTP 按路径分发到图片接口或 JSON 接口
+// 参数 w: 响应写入器
+// 参数 r: HTTP 请求
+// 返回值: 无
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    switch path.Base(r.URL.Path) {
+    case "image":
+        h.ServeImage(w, r)
+    case "new":
+        h.ServeJSON(w, r)
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+// ServeImage 图片接口：生成验证码并直接返回图片
+// 参数 w: 响应写入器
+// 参数 r: HTTP 请求
+// 返回值: 无
+// 关键步骤：验证码ID写入 IDHeader 响应头（并通过 Access-Control-Expose-Headers 暴露给跨域脚本）；
+// 写出禁止缓存的响应头，避免浏览器或代理复用旧图片
+func (h *Handler) ServeImage(w http.ResponseWriter, r *http.Request) {
+    id, img, err := h.m.Generate()
+    if err != nil {
+        http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+        return
+    }
+    hd := w.Header()
+    noStore(hd)
+    hd.Set(h.opt.IDHeader, id)
+    hd.Set("Access-Control-Expose-Headers", h.opt.IDHeader)
+    hd.Set("Content-Type", http.DetectContentType(img))
+    _, _ = w.Write(img)
+}
+
+// ServeJSON JSON 接口：生成验证码并返回ID与 base64 数据URI
+// 参数 w: 响应写入器
+// 参数 r: HTTP 请求
+// 返回值: 无
+// 关键步骤：响应体为 {"id": "...", "image": "data:image/png;base64,..."}，前端可直接用作 <img src>；
+// MIME 随 Manager 的渲染格式变化（如 Render 输出 JPEG 时为 image/jpeg）
+func (h *Handler) ServeJSON(w http.ResponseWriter, r *http.Request) {
+    id, img, err := h.m.Generate()
+    if err != nil {
+        http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+        return
+    }
+    noStore(w.Header())
+    w.Header().Set("Content-Type", "application/json; charset=utf-8")
+    _ = json.NewEncoder(w).Encode(struct {
+        ID    string `json:"id"`
+        Image string `json:"image"`
+    }{id, captcha.DataURI(img)})
+}
+
+// Middleware 创建验证码校验中间件
+// 参数 m: 验证码管理器
+// 参数 opt: 选项（零值字段使用默认值）
+// 返回值: 包装 http.Handler 的中间件函数
+// 关键步骤：先读请求头再读表单字段取得ID与答案→一次性校验（作废该ID，杜绝重放）→通过时调用下游处理器，失败交给 OnFailure
+func Middleware(m *captcha.Manager, opt Options) func(next http.Handler) http.Handler {
+    opt = normalizeOptions(opt)
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            id := r.Header.Get(opt.IDHeader)
+            if id == "" { id = r.FormValue(opt.IDField) }
+            answer := r.Header.Get(opt.AnswerHeader)
+            if answer == "" { answer = r.FormValue(opt.AnswerField) }
+            if !m.Verify(id, answer, true) {
+                opt.OnFailure.ServeHTTP(w, r)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// normalizeOptions 填充默认选项
+// 参数 opt: 选项
+// 返回值: 归一化后的选项
+func normalizeOptions(opt Options) Options {
+    if opt.IDField == "" { opt.IDField = "captcha_id" }
+    if opt.AnswerField == "" { opt.AnswerField = "captcha_answer" }
+    if opt.IDHeader == "" { opt.IDHeader = "X-Captcha-Id" }
+    if opt.AnswerHeader == "" { opt.AnswerHeader = "X-Captcha-Answer" }
+    if opt.OnFailure == nil {
+        opt.OnFailure = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            http.Error(w, "验证码错误或已过期", http.StatusForbidden)
+        })
+    }
+    return opt
+}
+
+// noStore 写出禁止缓存的响应头
+// 参数 h: 响应头
+// 返回值: 无
+func noStore(h http.Header) {
+    h.Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+    h.Set("Pragma", "no-cache")
+    h.Set("Expires", "0")
+}
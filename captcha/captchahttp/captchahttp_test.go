@@ -0,0 +1,87 @@
+package captchahttp
+
+import (
+    "bytes"
+    "encoding/base64"
+    "encoding/json"
+    "image/png"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/QinWeisWord/go_utils/captcha"
+)
+
+// newTestManager 创建答案固定为 "K7PX" 的管理器
+// 参数 t: 测试上下文
+// 返回值: 管理器（测试结束时自动关闭）
+func newTestManager(t *testing.T) *captcha.Manager {
+    m := captcha.NewManager(captcha.ManagerOptions{Challenge: func() (string, string, error) { return "K7PX", "K7PX", nil }})
+    t.Cleanup(m.Close)
+    return m
+}
+
+// TestHandler_Image 测试：图片接口返回PNG、禁止缓存与ID响应头
+// 参数 t: 测试上下文
+// 返回值: 无
+func TestHandler_Image(t *testing.T) {
+    h := NewHandler(newTestManager(t), Options{})
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/captcha/image", nil))
+    if rec.Code != http.StatusOK { t.Fatalf("status mismatch: %d", rec.Code) }
+    if ct := rec.Header().Get("Content-Type"); ct != "image/png" { t.Fatalf("content type mismatch: %q", ct) }
+    if cc := rec.Header().Get("Cache-Control"); !strings.Contains(cc, "no-store") { t.Fatalf("cache control mismatch: %q", cc) }
+    if len(rec.Header().Get("X-Captcha-Id")) != 32 { t.Fatalf("missing captcha id header") }
+    if _, err := png.Decode(rec.Body); err != nil { t.Fatalf("png decode error: %v", err) }
+
+    rec = httptest.NewRecorder()
+    h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/captcha/other", nil))
+    if rec.Code != http.StatusNotFound { t.Fatalf("expected 404 for unknown path, got %d", rec.Code) }
+}
+
+// TestHandler_JSONAndMiddleware 测试：JSON 接口返回数据URI，中间件一次性校验表单与请求头
+// 参数 t: 测试上下文
+// 返回值: 无
+// 关键步骤：签发→表单提交正确答案通过→同一ID重放失败→请求头方式通过→错误答案返回403
+func TestHandler_JSONAndMiddleware(t *testing.T) {
+    m := newTestManager(t)
+    h := NewHandler(m, Options{})
+    issue := func() string {
+        rec := httptest.NewRecorder()
+        h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/captcha/new", nil))
+        var body struct{ ID, Image string }
+        if err := json.NewDecoder(rec.Body).Decode(&body); err != nil { t.Fatalf("json decode error: %v", err) }
+        const prefix = "data:image/png;base64,"
+        if !strings.HasPrefix(body.Image, prefix) { t.Fatalf("data uri prefix mismatch: %.40q", body.Image) }
+        raw, err := base64.StdEncoding.DecodeString(body.Image[len(prefix):])
+        if err != nil { t.Fatalf("base64 decode error: %v", err) }
+        if _, err := png.Decode(bytes.NewReader(raw)); err != nil { t.Fatalf("png decode error: %v", err) }
+        return body.ID
+    }
+
+    calls := 0
+    protected := Middleware(m, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+    post := func(form url.Values, header http.Header) int {
+        req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+        req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+        for k, v := range header {
+            req.Header[k] = v
+        }
+        rec := httptest.NewRecorder()
+        protected.ServeHTTP(rec, req)
+        return rec.Code
+    }
+
+    id := issue()
+    if code := post(url.Values{"captcha_id": {id}, "captcha_answer": {"K7PX"}}, nil); code != http.StatusOK || calls != 1 { t.Fatalf("form verify failed: code=%d calls=%d", code, calls) }
+    if code := post(url.Values{"captcha_id": {id}, "captcha_answer": {"K7PX"}}, nil); code != http.StatusForbidden || calls != 1 { t.Fatalf("replay should be rejected: code=%d", code) }
+
+    id = issue()
+    if code := post(nil, http.Header{"X-Captcha-Id": {id}, "X-Captcha-Answer": {"K7PX"}}); code != http.StatusOK || calls != 2 { t.Fatalf("header verify failed: code=%d calls=%d", code, calls) }
+
+    id = issue()
+    if code := post(url.Values{"captcha_id": {id}, "captcha_answer": {"XXXX"}}, nil); code != http.StatusForbidden || calls != 2 { t.Fatalf("wrong answer should be rejected: code=%d", code) }
+    if code := post(nil, nil); code != http.StatusForbidden { t.Fatalf("missing fields should be rejected: code=%d", code) }
+}
@@ -2,7 +2,6 @@ module github.com/QinWeisWord/go_utils
 
 go 1.25.3
 
-require (
-	golang.org/x/image v0.32.0 // indirect
-	golang.org/x/text v0.30.0 // indirect
-)
+require golang.org/x/image v0.32.0
+
+require golang.org/x/text v0.30.0 // indirect
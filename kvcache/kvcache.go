@@ -1,14 +1,99 @@
 package kvcache
 
-import "sync"
+import (
+    "container/list"
+    "sync"
+    "sync/atomic"
+    "time"
+)
 
 // KVCache 一个简易的并发安全键值缓存器（泛型）
 // 参数：无
 // 返回值：无
-// 关键步骤：使用读写锁保护内部map，提供基础的Set/Get/Delete/Has等操作
+// 关键步骤：使用读写锁保护内部map，提供基础的Set/Get/Delete/Has等操作；
+// 支持按条目设置过期时间（TTL），读取时惰性淘汰，并可选启动后台清理协程；
+// 可按条目数或总成本限制容量，超限时依据 LRU/LFU/FIFO 策略淘汰
 type KVCache[K comparable, V any] struct {
-    mu    sync.RWMutex      // 关键步骤：并发读写保护
-    store map[K]V           // 关键步骤：底层存储使用map
+    mu    sync.RWMutex       // 关键步骤：并发读写保护
+    store map[K]*entry[K, V] // 关键步骤：底层存储使用map，值为带元数据的条目
+
+    defaultTTL time.Duration    // 关键步骤：Set 使用的默认过期时长（<=0 表示永不过期）
+    clock      func() time.Time // 关键步骤：时间来源（便于测试注入），为空时使用 time.Now
+
+    policy     EvictionPolicy   // 关键步骤：容量淘汰策略
+    evict      evictor[K, V]    // 关键步骤：淘汰策略实现；未限制容量时为nil
+    maxEntries int              // 关键步骤：最大条目数（<=0 不限制）
+    maxCost    int64            // 关键步骤：最大总成本（<=0 不限制）
+    sizer      func(K, V) int64 // 关键步骤：条目成本计算函数
+    cost       int64            // 关键步骤：当前总成本
+
+    negativeTTL time.Duration        // 关键步骤：加载失败结果的缓存时长（<=0 不缓存）
+    negatives   map[K]*negativeEntry // 关键步骤：加载失败结果（受 mu 保护）
+    loadMu      sync.Mutex           // 关键步骤：保护进行中的加载调用表
+    loads       map[K]*loadCall[V]   // 关键步骤：按键去重的进行中加载调用
+
+    hooks   atomic.Pointer[hooks[K, V]] // 关键步骤：事件回调（写入时整体替换，读取无需加锁）
+    pending []event[K, V]               // 关键步骤：持锁期间累积的待派发事件（受 mu 保护）
+
+    counters counters // 关键步骤：命中/未命中/写入/删除/淘汰原子计数
+
+    tagIndex map[string]map[K]struct{} // 关键步骤：标签→键集合的反向索引（受 mu 保护）
+
+    codec           Codec       // 关键步骤：快照编解码器（为空时使用 gob）
+    snapshotPath    string      // 关键步骤：自动快照文件路径
+    onSnapshotError func(error) // 关键步骤：自动快照失败回调
+
+    stopOnce sync.Once     // 关键步骤：保证 Close 幂等
+    stop     chan struct{} // 关键步骤：通知后台协程退出
+    done     chan struct{} // 关键步骤：后台协程退出完成信号
+}
+
+// Options 缓存器构造选项
+// 结构体字段解释：
+// - DefaultTTL: Set 写入条目的默认过期时长；<=0 表示永不过期
+// - CleanupInterval: 后台清理协程的执行间隔；<=0 表示不启动，仅在读取时惰性淘汰
+// - MaxEntries: 最大条目数；<=0 表示不限制
+// - MaxCost: 最大总成本；<=0 表示不限制（单条成本由 Sizer 计算）
+// - Sizer: 条目成本计算函数；为空时每个条目成本记为1
+// - Policy: 超出容量时的淘汰策略（默认 PolicyLRU）
+// - NegativeTTL: GetOrLoad 加载失败时缓存错误结果的时长；<=0 表示不缓存
+// - Codec: 快照编解码器；为空时使用 GobCodec
+// - SnapshotPath: 自动快照文件路径；与 SnapshotInterval 同时设置时启用
+// - SnapshotInterval: 自动快照间隔；<=0 表示不自动快照（Close 时会再写一次最终快照）
+// - OnSnapshotError: 自动快照失败时的回调；为空时忽略错误
+type Options[K comparable, V any] struct {
+    DefaultTTL       time.Duration
+    CleanupInterval  time.Duration
+    MaxEntries       int
+    MaxCost          int64
+    Sizer            func(key K, value V) int64
+    Policy           EvictionPolicy
+    NegativeTTL      time.Duration
+    Codec            Codec
+    SnapshotPath     string
+    SnapshotInterval time.Duration
+    OnSnapshotError  func(err error)
+}
+
+// entry 缓存条目（内部使用）
+// 结构体字段解释：
+// - key: 条目所属键（便于淘汰时反查）
+// - value: 条目值
+// - expireAt: 过期时间（UnixNano）；0 表示永不过期
+// - cost: 条目成本（用于总成本限制）
+// - elem: LRU/FIFO 链表节点
+// - freq, seq, index: LFU 访问频次、访问序号与堆下标
+// - tags: 条目标签（用于按标签批量失效）
+type entry[K comparable, V any] struct {
+    key      K
+    value    V
+    expireAt int64
+    cost     int64
+    elem     *list.Element
+    freq     uint64
+    seq      uint64
+    index    int
+    tags     []string
 }
 
 // New 创建一个新的键值缓存器实例
@@ -16,64 +101,146 @@ type KVCache[K comparable, V any] struct {
 // 返回值：缓存器指针
 // 关键步骤：初始化内部map
 func New[K comparable, V any]() *KVCache[K, V] {
-    return &KVCache[K, V]{
-        store: make(map[K]V),
+    return NewWithOptions(Options[K, V]{})
+}
+
+// NewWithOptions 按选项创建键值缓存器实例
+// 参数 opt: 构造选项（默认TTL、后台清理间隔等）
+// 返回值：缓存器指针
+// 关键步骤：初始化内部map与淘汰策略；当 CleanupInterval>0 或启用自动快照时启动后台协程，需调用 Close 释放
+func NewWithOptions[K comparable, V any](opt Options[K, V]) *KVCache[K, V] {
+    c := &KVCache[K, V]{
+        store:           make(map[K]*entry[K, V]),
+        defaultTTL:      opt.DefaultTTL,
+        policy:          opt.Policy,
+        maxEntries:      opt.MaxEntries,
+        maxCost:         opt.MaxCost,
+        sizer:           opt.Sizer,
+        negativeTTL:     opt.NegativeTTL,
+        negatives:       make(map[K]*negativeEntry),
+        loads:           make(map[K]*loadCall[V]),
+        tagIndex:        make(map[string]map[K]struct{}),
+        codec:           opt.Codec,
+        snapshotPath:    opt.SnapshotPath,
+        onSnapshotError: opt.OnSnapshotError,
+        stop:            make(chan struct{}),
+        done:            make(chan struct{}),
+    }
+    if c.maxEntries > 0 || c.maxCost > 0 {
+        c.evict = newEvictor[K, V](c.policy)
+    }
+    snapshotInterval := opt.SnapshotInterval
+    if opt.SnapshotPath == "" {
+        snapshotInterval = 0
+    }
+    if opt.CleanupInterval > 0 || snapshotInterval > 0 {
+        go c.janitor(opt.CleanupInterval, snapshotInterval)
+    } else {
+        // 关键步骤：未启动后台协程时直接标记完成，Close 无需等待
+        close(c.done)
     }
+    return c
 }
 
 // Set 设置键的值（覆盖同名键）
 // 参数 key: 键（必须可比较类型）
 // 参数 value: 值
 // 返回值：无
-// 关键步骤：写入时加写锁，确保并发安全
+// 关键步骤：写入时加写锁，确保并发安全；过期时长使用构造时的默认TTL
 func (c *KVCache[K, V]) Set(key K, value V) {
+    c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithTTL 设置键的值并指定过期时长（覆盖同名键）
+// 参数 key: 键
+// 参数 value: 值
+// 参数 ttl: 过期时长；<=0 表示永不过期
+// 返回值：无
+// 关键步骤：计算绝对过期时间后在写锁下覆盖写入，超出容量时按策略淘汰
+func (c *KVCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+    expireAt := c.expireAtFrom(c.nowNano(), ttl)
     c.mu.Lock()
-    // 关键步骤：直接覆盖写入
-    c.store[key] = value
-    c.mu.Unlock()
+    c.setLocked(key, value, expireAt, nil)
+    c.unlockAndNotify()
 }
 
 // Get 获取键对应的值
 // 参数 key: 键
-// 返回值 value: 键对应的值（不存在时为零值）
+// 返回值 value: 键对应的值（不存在或已过期时为零值）
 // 返回值 ok: 是否存在该键
-// 关键步骤：读取时加读锁，避免阻塞写操作的同时提升并发读性能
+// 关键步骤：读取时加读锁，避免阻塞写操作的同时提升并发读性能；命中已过期条目时升级为写锁惰性删除；
+// LRU/LFU 策略需记录访问，此时直接使用写锁
 func (c *KVCache[K, V]) Get(key K) (value V, ok bool) {
+    now := c.nowNano()
+    if c.evict != nil && c.policy != PolicyFIFO {
+        c.mu.Lock()
+        e, ok := c.store[key]
+        if ok && e.expired(now) {
+            c.removeLocked(e, ReasonExpired)
+            ok = false
+        }
+        if ok {
+            c.evict.access(e)
+            value = e.value
+        }
+        c.unlockAndNotify()
+        c.countAccess(ok)
+        return value, ok
+    }
     c.mu.RLock()
-    v, ok := c.store[key]
+    e, ok := c.store[key]
+    if ok && !e.expired(now) {
+        v := e.value
+        c.mu.RUnlock()
+        c.countAccess(true)
+        return v, true
+    }
     c.mu.RUnlock()
-    return v, ok
+    if ok {
+        c.removeExpired(key, now)
+    }
+    c.countAccess(false)
+    return value, false
 }
 
 // Delete 删除指定键
 // 参数 key: 键
-// 返回值 deleted: 删除是否成功（键是否存在）
+// 返回值 deleted: 删除是否成功（键是否存在且未过期）
 // 关键步骤：写入时加写锁，使用多值删除语义判断是否存在
 func (c *KVCache[K, V]) Delete(key K) (deleted bool) {
+    now := c.nowNano()
     c.mu.Lock()
-    _, ok := c.store[key]
+    delete(c.negatives, key)
+    e, ok := c.store[key]
     if ok {
-        // 关键步骤：存在时执行删除
-        delete(c.store, key)
+        // 关键步骤：存在时执行删除；已过期条目视为不存在
+        ok = !e.expired(now)
+        if ok {
+            c.removeLocked(e, ReasonDeleted)
+        } else {
+            c.removeLocked(e, ReasonExpired)
+        }
     }
-    c.mu.Unlock()
+    c.unlockAndNotify()
     return ok
 }
 
 // Has 判断是否存在指定键
 // 参数 key: 键
-// 返回值 exists: 存在返回true
-// 关键步骤：读锁保护并使用多值获取判断
+// 返回值 exists: 存在且未过期返回true
+// 关键步骤：读锁保护的只读查询；不计入命中统计、不调整淘汰顺序，已过期条目交由 Get 或后台清理回收
 func (c *KVCache[K, V]) Has(key K) (exists bool) {
+    now := c.nowNano()
     c.mu.RLock()
-    _, ok := c.store[key]
+    e, ok := c.store[key]
+    exists = ok && !e.expired(now)
     c.mu.RUnlock()
-    return ok
+    return exists
 }
 
 // Len 返回当前缓存中的键数量
 // 参数：无
-// 返回值 n: 键的数量
+// 返回值 n: 键的数量（可能包含已过期但尚未被回收的条目）
 // 关键步骤：读锁保护并返回map长度
 func (c *KVCache[K, V]) Len() (n int) {
     c.mu.RLock()
@@ -85,21 +252,49 @@ func (c *KVCache[K, V]) Len() (n int) {
 // Clear 清空缓存中的所有键
 // 参数：无
 // 返回值：无
-// 关键步骤：用写锁将store替换为新的空map，避免逐个删除开销
+// 关键步骤：用写锁将store替换为新的空map，避免逐个删除开销；同时重置淘汰策略与成本；
+// 注册了回调时为每个条目派发 ReasonCleared 事件
 func (c *KVCache[K, V]) Clear() {
     c.mu.Lock()
-    c.store = make(map[K]V)
-    c.mu.Unlock()
+    if c.hooks.Load() != nil {
+        for _, e := range c.store {
+            c.pending = append(c.pending, event[K, V]{key: e.key, value: e.value, reason: ReasonCleared})
+        }
+    }
+    c.countRemoval(ReasonCleared, len(c.store))
+    c.store = make(map[K]*entry[K, V])
+    c.negatives = make(map[K]*negativeEntry)
+    c.tagIndex = make(map[string]map[K]struct{})
+    if c.evict != nil {
+        c.evict = newEvictor[K, V](c.policy)
+    }
+    c.cost = 0
+    c.unlockAndNotify()
+}
+
+// Cost 返回当前缓存的总成本
+// 参数：无
+// 返回值 cost: 全部条目的成本之和（未配置 Sizer 时等于条目数）
+// 关键步骤：读锁保护读取累计值
+func (c *KVCache[K, V]) Cost() (cost int64) {
+    c.mu.RLock()
+    cost = c.cost
+    c.mu.RUnlock()
+    return cost
 }
 
 // Keys 返回当前所有键的切片
 // 参数：无
-// 返回值 keys: 键切片（顺序不保证）
+// 返回值 keys: 键切片（顺序不保证，不含已过期条目）
 // 关键步骤：在读锁下遍历map构建切片
 func (c *KVCache[K, V]) Keys() (keys []K) {
+    now := c.nowNano()
     c.mu.RLock()
     keys = make([]K, 0, len(c.store))
-    for k := range c.store {
+    for k, e := range c.store {
+        if e.expired(now) {
+            continue
+        }
         keys = append(keys, k)
     }
     c.mu.RUnlock()
@@ -108,14 +303,238 @@ func (c *KVCache[K, V]) Keys() (keys []K) {
 
 // Values 返回当前所有值的切片
 // 参数：无
-// 返回值 values: 值切片（顺序不保证）
+// 返回值 values: 值切片（顺序不保证，不含已过期条目）
 // 关键步骤：在读锁下遍历map构建切片
 func (c *KVCache[K, V]) Values() (values []V) {
+    now := c.nowNano()
     c.mu.RLock()
     values = make([]V, 0, len(c.store))
-    for _, v := range c.store {
-        values = append(values, v)
+    for _, e := range c.store {
+        if e.expired(now) {
+            continue
+        }
+        values = append(values, e.value)
     }
     c.mu.RUnlock()
     return values
-}
\ No newline at end of file
+}
+
+// TTL 返回键的剩余存活时长
+// 参数 key: 键
+// 返回值 ttl: 剩余时长；永不过期时为0
+// 返回值 ok: 键是否存在且未过期
+// 关键步骤：读锁下读取过期时间并与当前时间求差
+func (c *KVCache[K, V]) TTL(key K) (ttl time.Duration, ok bool) {
+    now := c.nowNano()
+    c.mu.RLock()
+    e, ok := c.store[key]
+    if ok && !e.expired(now) && e.expireAt > 0 {
+        ttl = time.Duration(e.expireAt - now)
+    }
+    if ok && e.expired(now) {
+        ok = false
+    }
+    c.mu.RUnlock()
+    return ttl, ok
+}
+
+// DeleteExpired 立即回收所有已过期条目
+// 参数：无
+// 返回值 n: 本次回收的条目数量
+// 关键步骤：写锁下遍历map删除过期条目与过期的负缓存（后台清理协程亦调用此方法）
+func (c *KVCache[K, V]) DeleteExpired() (n int) {
+    now := c.nowNano()
+    c.mu.Lock()
+    for _, e := range c.store {
+        if e.expired(now) {
+            c.removeLocked(e, ReasonExpired)
+            n++
+        }
+    }
+    for k, ne := range c.negatives {
+        if now >= ne.expireAt {
+            delete(c.negatives, k)
+        }
+    }
+    c.unlockAndNotify()
+    return n
+}
+
+// Close 停止后台协程并等待其退出
+// 参数：无
+// 返回值：无
+// 关键步骤：通过 sync.Once 保证幂等；启用自动快照时退出前写入最终快照；关闭后缓存仍可继续读写，仅不再后台回收
+func (c *KVCache[K, V]) Close() {
+    c.stopOnce.Do(func() { close(c.stop) })
+    <-c.done
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// janitor 后台协程主循环（过期清理与自动快照）
+// 参数 cleanup: 清理间隔（<=0 不清理）
+// 参数 snapshot: 自动快照间隔（<=0 不快照）
+// 返回值：无
+// 关键步骤：未启用的定时器使用nil通道永不触发；收到停止信号后写最终快照并关闭 done
+func (c *KVCache[K, V]) janitor(cleanup, snapshot time.Duration) {
+    defer close(c.done)
+    var cleanupC, snapshotC <-chan time.Time
+    if cleanup > 0 {
+        t := time.NewTicker(cleanup)
+        defer t.Stop()
+        cleanupC = t.C
+    }
+    if snapshot > 0 {
+        t := time.NewTicker(snapshot)
+        defer t.Stop()
+        snapshotC = t.C
+    }
+    for {
+        select {
+        case <-cleanupC:
+            c.DeleteExpired()
+        case <-snapshotC:
+            c.autoSnapshot()
+        case <-c.stop:
+            if snapshotC != nil {
+                c.autoSnapshot()
+            }
+            return
+        }
+    }
+}
+
+// removeExpired 在写锁下再次确认并删除已过期的键
+// 参数 key: 键
+// 参数 now: 当前时间（UnixNano）
+// 返回值：无
+// 关键步骤：升级锁期间条目可能已被覆盖，需重新判断过期状态
+func (c *KVCache[K, V]) removeExpired(key K, now int64) {
+    c.mu.Lock()
+    if e, ok := c.store[key]; ok && e.expired(now) {
+        c.removeLocked(e, ReasonExpired)
+    }
+    c.unlockAndNotify()
+}
+
+// setLocked 写入或覆盖条目并执行容量淘汰（调用方需持有写锁）
+// 参数 key: 键
+// 参数 value: 值
+// 参数 expireAt: 绝对过期时间（UnixNano，0 表示永不过期）
+// 参数 tags: 条目标签（覆盖时替换原有标签）
+// 返回值 stored: 是否写入成功（单条成本超过 MaxCost 时为false）
+// 关键步骤：覆盖时原地更新条目并视为一次访问；单条成本超过 MaxCost 时不写入；
+// 写入后循环淘汰策略选出的条目直至满足容量约束；被覆盖的旧值与被淘汰条目均记录事件
+func (c *KVCache[K, V]) setLocked(key K, value V, expireAt int64, tags []string) (stored bool) {
+    delete(c.negatives, key)
+    notify := c.hooks.Load() != nil
+    cost := int64(1)
+    if c.sizer != nil {
+        cost = c.sizer(key, value)
+    }
+    if c.maxCost > 0 && cost > c.maxCost {
+        // 关键步骤：条目本身超出容量上限，放弃写入；新值从未存入，既不派发事件也不计数；
+        // 同名旧值因容量不足被挤出，按容量淘汰（已过期则按过期）派发事件并计入淘汰统计
+        if old, ok := c.store[key]; ok {
+            reason := ReasonCapacity
+            if old.expired(c.nowNano()) {
+                reason = ReasonExpired
+            }
+            c.removeLocked(old, reason)
+        }
+        return false
+    }
+    if e, ok := c.store[key]; ok {
+        if notify {
+            c.pending = append(c.pending, event[K, V]{key: key, value: e.value, reason: c.replaceReason(e)})
+        }
+        c.cost += cost - e.cost
+        e.value, e.expireAt, e.cost = value, expireAt, cost
+        c.untagLocked(e)
+        e.tags = tags
+        c.tagLocked(e)
+        if c.evict != nil {
+            c.evict.access(e)
+        }
+    } else {
+        e = &entry[K, V]{key: key, value: value, expireAt: expireAt, cost: cost, tags: tags}
+        c.store[key] = e
+        c.tagLocked(e)
+        c.cost += cost
+        if c.evict != nil {
+            c.evict.add(e)
+        }
+    }
+    c.counters.sets.Add(1)
+    if notify {
+        c.pending = append(c.pending, event[K, V]{key: key, value: value, set: true})
+    }
+    if c.evict == nil {
+        return true
+    }
+    for (c.maxEntries > 0 && len(c.store) > c.maxEntries) || (c.maxCost > 0 && c.cost > c.maxCost) {
+        victim := c.evict.victim()
+        if victim == nil {
+            break
+        }
+        c.removeLocked(victim, ReasonCapacity)
+    }
+    return true
+}
+
+// removeLocked 从存储与淘汰策略中移除条目（调用方需持有写锁）
+// 参数 e: 待移除条目
+// 参数 reason: 移除原因（用于事件回调）
+// 返回值：无
+// 关键步骤：同步维护map、标签索引、策略结构与总成本；注册了回调时记录待派发事件
+func (c *KVCache[K, V]) removeLocked(e *entry[K, V], reason EvictReason) {
+    if c.hooks.Load() != nil {
+        c.pending = append(c.pending, event[K, V]{key: e.key, value: e.value, reason: reason})
+    }
+    c.countRemoval(reason, 1)
+    delete(c.store, e.key)
+    c.untagLocked(e)
+    c.cost -= e.cost
+    if c.evict != nil {
+        c.evict.remove(e)
+    }
+}
+
+// countAccess 累计命中或未命中次数
+// 参数 hit: 是否命中
+// 返回值：无
+func (c *KVCache[K, V]) countAccess(hit bool) {
+    if hit {
+        c.counters.hits.Add(1)
+    } else {
+        c.counters.misses.Add(1)
+    }
+}
+
+// expireAtFrom 根据TTL计算绝对过期时间
+// 参数 now: 当前时间（UnixNano）
+// 参数 ttl: 过期时长；<=0 表示永不过期
+// 返回值：绝对过期时间（0 表示永不过期）
+func (c *KVCache[K, V]) expireAtFrom(now int64, ttl time.Duration) int64 {
+    if ttl > 0 {
+        return now + int64(ttl)
+    }
+    return 0
+}
+
+// nowNano 返回当前时间（UnixNano）
+// 参数：无
+// 返回值：当前时间的纳秒时间戳
+func (c *KVCache[K, V]) nowNano() int64 {
+    if c.clock != nil {
+        return c.clock().UnixNano()
+    }
+    return time.Now().UnixNano()
+}
+
+// expired 判断条目在给定时间是否已过期
+// 参数 now: 当前时间（UnixNano）
+// 返回值：已过期返回true
+func (e *entry[K, V]) expired(now int64) bool {
+    return e.expireAt > 0 && now >= e.expireAt
+}
@@ -0,0 +1,146 @@
+package kvcache
+
+import "time"
+
+// 本文件提供原子复合操作：在一次加锁内完成“读取-判断-写入”，调用方无需额外加锁。
+// 注意：传入的回调函数在缓存写锁内执行，回调内不得再访问同一缓存，否则会死锁；
+// 回调 panic 时通过延迟解锁释放写锁并派发已收集的事件，panic 照常传播给调用方，缓存仍可继续使用。
+
+// Integer 整数类型约束（用于 Increment）
+type Integer interface {
+    ~int | ~int8 | ~int16 | ~int32 | ~int64 |
+        ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// GetOrSet 键存在时返回现有值，否则写入给定值
+// 参数 key: 键
+// 参数 value: 键不存在时写入的值
+// 返回值 actual: 现有值或新写入的值
+// 返回值 loaded: 是否为已存在的值
+// 关键步骤：写锁内判断存在性（已过期视为不存在），新值使用默认TTL
+func (c *KVCache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+    now := c.nowNano()
+    c.mu.Lock()
+    if e, ok := c.liveLocked(key, now); ok {
+        if c.evict != nil {
+            c.evict.access(e)
+        }
+        actual = e.value
+        c.unlockAndNotify()
+        c.countAccess(true)
+        return actual, true
+    }
+    c.setLocked(key, value, c.expireAtFrom(now, c.defaultTTL), nil)
+    c.unlockAndNotify()
+    c.countAccess(false)
+    return value, false
+}
+
+// CompareAndSwap 当前值与旧值相等时替换为新值
+// 参数 key: 键
+// 参数 old: 期望的当前值
+// 参数 new: 替换后的值
+// 参数 eq: 值相等判断函数；为nil时使用 == 比较（V 的动态类型不可比较时会 panic，panic 传播给调用方且不会遗留锁）
+// 返回值 swapped: 是否完成替换（键不存在或值不相等时为false）
+// 关键步骤：写锁内比较并替换（延迟解锁），保留条目原有的过期时间与标签
+func (c *KVCache[K, V]) CompareAndSwap(key K, old, new V, eq func(a, b V) bool) (swapped bool) {
+    if eq == nil {
+        eq = func(a, b V) bool { return any(a) == any(b) }
+    }
+    now := c.nowNano()
+    c.mu.Lock()
+    defer c.unlockAndNotify()
+    if e, ok := c.liveLocked(key, now); ok && eq(e.value, old) {
+        swapped = c.setLocked(key, new, e.expireAt, e.tags)
+    }
+    return swapped
+}
+
+// Update 以回调函数原子地更新键的值
+// 参数 key: 键
+// 参数 fn: 更新函数，入参为当前值与是否存在，返回新值与是否保留；返回false时删除该键
+// 返回值 value: 更新后的值（删除时为零值）
+// 返回值 ok: 键在更新后是否存在
+// 关键步骤：写锁内执行回调；已存在的条目保留原过期时间与标签，新建条目使用默认TTL
+func (c *KVCache[K, V]) Update(key K, fn func(old V, ok bool) (V, bool)) (value V, ok bool) {
+    return c.update(key, fn, false, 0)
+}
+
+// UpdateWithTTL 以回调函数原子地更新键的值，并将过期时间重置为 ttl 之后
+// 参数 key: 键
+// 参数 ttl: 新的过期时长；<=0 表示永不过期
+// 参数 fn: 更新函数，语义同 Update
+// 返回值 value: 更新后的值（删除时为零值）
+// 返回值 ok: 键在更新后是否存在
+// 关键步骤：适合“空闲一段时间后自动清理”的场景（如限流计数），每次更新都会顺延过期时间；保留原有标签
+func (c *KVCache[K, V]) UpdateWithTTL(key K, ttl time.Duration, fn func(old V, ok bool) (V, bool)) (value V, ok bool) {
+    return c.update(key, fn, true, ttl)
+}
+
+// Increment 对整数值缓存的键原子地加上增量
+// 参数 c: 整数值缓存
+// 参数 key: 键
+// 参数 delta: 增量（可为负数）；键不存在时以 delta 作为初始值
+// 返回值：加法后的新值
+// 关键步骤：基于 Update 在一次加锁内完成读取与写入
+func Increment[K comparable, V Integer](c *KVCache[K, V], key K, delta V) V {
+    v, _ := c.Update(key, func(old V, _ bool) (V, bool) { return old + delta, true })
+    return v
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// update Update 与 UpdateWithTTL 的共同实现
+// 参数 key: 键
+// 参数 fn: 更新函数
+// 参数 refresh: 是否重置过期时间
+// 参数 ttl: refresh 为true时使用的过期时长
+// 返回值：更新后的值与键是否存在
+// 关键步骤：延迟解锁，fn panic 时同样释放写锁并派发已收集的事件
+func (c *KVCache[K, V]) update(key K, fn func(old V, ok bool) (V, bool), refresh bool, ttl time.Duration) (value V, ok bool) {
+    now := c.nowNano()
+    c.mu.Lock()
+    defer c.unlockAndNotify()
+    e, exists := c.liveLocked(key, now)
+    var cur V
+    if exists {
+        cur = e.value
+    }
+    nv, keep := fn(cur, exists)
+    expireAt := c.expireAtFrom(now, c.defaultTTL)
+    if refresh {
+        expireAt = c.expireAtFrom(now, ttl)
+    }
+    switch {
+    case keep && exists:
+        if !refresh {
+            expireAt = e.expireAt
+        }
+        ok = c.setLocked(key, nv, expireAt, e.tags)
+    case keep:
+        ok = c.setLocked(key, nv, expireAt, nil)
+    case exists:
+        c.removeLocked(e, ReasonDeleted)
+    }
+    // 关键步骤：单条成本超限时写入会被拒绝，以实际存储结果为准
+    if ok {
+        value = nv
+    }
+    return value, ok
+}
+
+// liveLocked 查找未过期的条目（调用方需持有写锁）
+// 参数 key: 键
+// 参数 now: 当前时间（UnixNano）
+// 返回值：条目与是否存在；已过期条目会被顺带移除
+func (c *KVCache[K, V]) liveLocked(key K, now int64) (*entry[K, V], bool) {
+    e, ok := c.store[key]
+    if !ok {
+        return nil, false
+    }
+    if e.expired(now) {
+        c.removeLocked(e, ReasonExpired)
+        return nil, false
+    }
+    return e, true
+}
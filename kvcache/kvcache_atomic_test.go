@@ -0,0 +1,136 @@
+package kvcache
+
+import (
+    "sync"
+    "testing"
+    "time"
+)
+
+// TestGetOrSet 测试 GetOrSet 的存在与不存在分支
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：首次写入返回loaded=false，再次调用返回已有值；过期后视为不存在
+func TestGetOrSet(t *testing.T) {
+    clk := newFakeClock()
+    c := NewWithOptions(Options[string, int]{DefaultTTL: time.Second})
+    c.clock = clk.Now
+    if v, loaded := c.GetOrSet("a", 1); loaded || v != 1 { t.Fatalf("首次应写入: v=%d loaded=%v", v, loaded) }
+    if v, loaded := c.GetOrSet("a", 2); !loaded || v != 1 { t.Fatalf("再次应返回已有值: v=%d loaded=%v", v, loaded) }
+    clk.Advance(time.Second)
+    if v, loaded := c.GetOrSet("a", 3); loaded || v != 3 { t.Fatalf("过期后应重新写入: v=%d loaded=%v", v, loaded) }
+}
+
+// TestCompareAndSwap 测试比较并替换
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：值匹配时替换并保留TTL；不匹配或键不存在时不替换；自定义相等函数支持切片值
+func TestCompareAndSwap(t *testing.T) {
+    clk := newFakeClock()
+    c := New[string, int]()
+    c.clock = clk.Now
+    c.SetWithTTL("a", 1, time.Minute)
+    if c.CompareAndSwap("a", 2, 3, nil) { t.Fatalf("值不匹配时不应替换") }
+    if !c.CompareAndSwap("a", 1, 3, nil) { t.Fatalf("值匹配时应替换") }
+    if v, _ := c.Get("a"); v != 3 { t.Fatalf("替换后值应为3: %d", v) }
+    if ttl, _ := c.TTL("a"); ttl != time.Minute { t.Fatalf("替换应保留TTL: %v", ttl) }
+    if c.CompareAndSwap("missing", 0, 1, nil) { t.Fatalf("键不存在时不应替换") }
+
+    s := New[string, []int]()
+    s.Set("k", []int{1, 2})
+    eq := func(a, b []int) bool {
+        if len(a) != len(b) { return false }
+        for i := range a {
+            if a[i] != b[i] { return false }
+        }
+        return true
+    }
+    if !s.CompareAndSwap("k", []int{1, 2}, []int{3}, eq) { t.Fatalf("自定义相等函数应生效") }
+}
+
+// TestUpdateAndIncrement 测试 Update 与 Increment
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：Update 可新建、修改与删除；并发 Increment 结果应精确
+func TestUpdateAndIncrement(t *testing.T) {
+    c := New[string, int]()
+    v, ok := c.Update("a", func(old int, ok bool) (int, bool) {
+        if ok { t.Fatalf("键不存在时ok应为false") }
+        return 5, true
+    })
+    if !ok || v != 5 { t.Fatalf("Update新建失败: v=%d ok=%v", v, ok) }
+    v, ok = c.Update("a", func(old int, _ bool) (int, bool) { return old * 2, true })
+    if !ok || v != 10 { t.Fatalf("Update修改失败: v=%d ok=%v", v, ok) }
+    if _, ok = c.Update("a", func(int, bool) (int, bool) { return 0, false }); ok || c.Has("a") {
+        t.Fatalf("Update返回false时应删除键")
+    }
+
+    const workers = 16
+    const loops = 500
+    var wg sync.WaitGroup
+    wg.Add(workers)
+    for i := 0; i < workers; i++ {
+        go func() {
+            defer wg.Done()
+            for j := 0; j < loops; j++ { Increment(c, "n", 1) }
+        }()
+    }
+    wg.Wait()
+    if v, _ := c.Get("n"); v != workers*loops { t.Fatalf("并发自增结果错误: %d", v) }
+    if Increment(c, "n", -8000) != 0 { t.Fatalf("负增量结果错误") }
+}
+
+// TestAtomicCallbackPanic 测试回调 panic 时不遗留写锁
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：Update 的 fn panic、CompareAndSwap 默认比较遇到不可比较的动态类型 panic，
+// panic 均应传播给调用方，之后读写与事件回调照常工作
+func TestAtomicCallbackPanic(t *testing.T) {
+    mustPanic := func(name string, fn func()) {
+        defer func() {
+            if recover() == nil { t.Errorf("%s应向调用方传播panic", name) }
+        }()
+        fn()
+    }
+    c := New[string, any]()
+    sets := 0
+    c.OnSet(func(string, any) { sets++ })
+    c.Set("a", 1)
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        mustPanic("Update", func() { c.Update("a", func(any, bool) (any, bool) { panic("boom") }) })
+        mustPanic("UpdateWithTTL", func() { c.UpdateWithTTL("a", time.Minute, func(any, bool) (any, bool) { panic("boom") }) })
+        c.Set("s", []int{1})
+        mustPanic("CompareAndSwap", func() { c.CompareAndSwap("s", []int{1}, 2, nil) })
+        c.Set("b", 2)
+        if v, ok := c.Get("a"); !ok || v != 1 { t.Errorf("panic后原值应保留: %v %v", v, ok) }
+        if v, _ := c.Update("a", func(old any, _ bool) (any, bool) { return old.(int) + 1, true }); v != 2 { t.Errorf("panic后Update应可用: %v", v) }
+    }()
+    select {
+    case <-done:
+    case <-time.After(2 * time.Second):
+        t.Fatalf("回调panic后缓存仍被锁住")
+    }
+    if sets != 4 { t.Fatalf("panic后事件回调应照常派发: %d", sets) }
+}
+
+// TestUpdateWithTTL 测试 UpdateWithTTL 顺延过期时间
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：每次更新都把过期时间重置为 ttl 之后；持续更新的键不过期，停止更新后按 ttl 过期；标签保留
+func TestUpdateWithTTL(t *testing.T) {
+    clk := newFakeClock()
+    c := New[string, int]()
+    c.clock = clk.Now
+    c.SetWithTTLAndTags("a", 1, time.Second, "t")
+    for i := 0; i < 3; i++ {
+        clk.Advance(800 * time.Millisecond)
+        if _, ok := c.UpdateWithTTL("a", time.Second, func(old int, ok bool) (int, bool) { return old + 1, ok }); !ok { t.Fatalf("持续更新的键不应过期") }
+    }
+    if ttl, _ := c.TTL("a"); ttl != time.Second { t.Fatalf("更新后TTL应重置: %v", ttl) }
+    if tags, _ := c.Tags("a"); len(tags) != 1 { t.Fatalf("更新应保留标签: %v", tags) }
+    clk.Advance(time.Second)
+    if c.Has("a") { t.Fatalf("停止更新后应按ttl过期") }
+    if v, ok := c.UpdateWithTTL("b", 0, func(int, bool) (int, bool) { return 7, true }); !ok || v != 7 { t.Fatalf("新建失败: v=%d ok=%v", v, ok) }
+    if _, ok := c.TTL("b"); !ok { t.Fatalf("ttl<=0 的键应永不过期") }
+}
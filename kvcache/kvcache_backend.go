@@ -0,0 +1,160 @@
+package kvcache
+
+import (
+    "bytes"
+    "encoding/hex"
+    "errors"
+    "io"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// 本文件定义二级存储（Backend）抽象，并提供两个实现：内存 map 与文件系统目录。
+// 二者主要用于测试与轻量场景；接入 Redis 等外部存储时实现 Backend 接口即可。
+
+// Backend 二级存储接口
+// 方法说明：
+// - Get: 读取键；不存在时返回 ok=false 且 err=nil
+// - Set: 写入键
+// - Delete: 删除键；键不存在不视为错误
+type Backend[K comparable, V any] interface {
+    Get(key K) (value V, ok bool, err error)
+    Set(key K, value V) error
+    Delete(key K) error
+}
+
+// MemoryBackend 基于内存 map 的二级存储（并发安全）
+// 结构体字段解释：
+// - mu: 读写锁
+// - data: 底层数据
+type MemoryBackend[K comparable, V any] struct {
+    mu   sync.RWMutex
+    data map[K]V
+}
+
+// NewMemoryBackend 创建内存二级存储
+// 参数：无
+// 返回值：内存存储指针
+func NewMemoryBackend[K comparable, V any]() *MemoryBackend[K, V] {
+    return &MemoryBackend[K, V]{data: make(map[K]V)}
+}
+
+// Get 读取键
+// 参数 key: 键
+// 返回值 value: 值；ok: 是否存在；err: 恒为nil
+func (m *MemoryBackend[K, V]) Get(key K) (value V, ok bool, err error) {
+    m.mu.RLock()
+    value, ok = m.data[key]
+    m.mu.RUnlock()
+    return value, ok, nil
+}
+
+// Set 写入键
+// 参数 key: 键
+// 参数 value: 值
+// 返回值：恒为nil
+func (m *MemoryBackend[K, V]) Set(key K, value V) error {
+    m.mu.Lock()
+    m.data[key] = value
+    m.mu.Unlock()
+    return nil
+}
+
+// Delete 删除键
+// 参数 key: 键
+// 返回值：恒为nil
+func (m *MemoryBackend[K, V]) Delete(key K) error {
+    m.mu.Lock()
+    delete(m.data, key)
+    m.mu.Unlock()
+    return nil
+}
+
+// Len 返回存储的键数量
+// 参数：无
+// 返回值：键数量
+func (m *MemoryBackend[K, V]) Len() int {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return len(m.data)
+}
+
+// DirBackend 基于文件系统目录的二级存储（字符串键，每个键一个文件）
+// 结构体字段解释：
+// - dir: 存储目录
+// - codec: 值编解码器
+type DirBackend[V any] struct {
+    dir   string
+    codec Codec
+}
+
+// NewDirBackend 创建目录二级存储
+// 参数 dir: 存储目录（不存在时自动创建）
+// 参数 codec: 值编解码器；为nil时使用 GobCodec
+// 返回值：目录存储指针与错误
+// 关键步骤：文件名为键的十六进制编码，避免路径分隔符等特殊字符（键过长超出文件名上限时写入失败）；写入使用临时文件+重命名
+func NewDirBackend[V any](dir string, codec Codec) (*DirBackend[V], error) {
+    if dir == "" {
+        return nil, errors.New("kvcache: 存储目录不能为空")
+    }
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, err
+    }
+    if codec == nil {
+        codec = GobCodec
+    }
+    return &DirBackend[V]{dir: dir, codec: codec}, nil
+}
+
+// Get 读取键
+// 参数 key: 键
+// 返回值 value: 值；ok: 文件是否存在；err: 读取或解码错误
+func (d *DirBackend[V]) Get(key string) (value V, ok bool, err error) {
+    b, err := os.ReadFile(d.path(key))
+    if errors.Is(err, os.ErrNotExist) {
+        return value, false, nil
+    }
+    if err != nil {
+        return value, false, err
+    }
+    if err := d.codec.Decode(bytes.NewReader(b), &value); err != nil {
+        return value, false, err
+    }
+    return value, true, nil
+}
+
+// Set 写入键
+// 参数 key: 键
+// 参数 value: 值
+// 返回值：编码或写入错误
+func (d *DirBackend[V]) Set(key string, value V) error {
+    var buf bytes.Buffer
+    if err := d.codec.Encode(&buf, &value); err != nil {
+        return err
+    }
+    return writeFileAtomic(d.path(key), func(w io.Writer) error {
+        _, err := w.Write(buf.Bytes())
+        return err
+    })
+}
+
+// Delete 删除键
+// 参数 key: 键
+// 返回值：删除错误（文件不存在不视为错误）
+func (d *DirBackend[V]) Delete(key string) error {
+    err := os.Remove(d.path(key))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    return err
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// path 计算键对应的文件路径
+// 参数 key: 键
+// 返回值：文件路径
+func (d *DirBackend[V]) path(key string) string {
+    return filepath.Join(d.dir, hex.EncodeToString([]byte(key))+".entry")
+}
@@ -0,0 +1,327 @@
+package kvcache
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "sync"
+)
+
+// 本文件提供分布式失效：多个服务实例各自持有 KVCache，某实例更新或删除键时，
+// 通过可插拔的消息总线（Bus）广播失效事件，其他实例收到后删除本地副本，避免读到旧值。
+// 内置进程内通道实现 ChanBus（测试/单进程多缓存）与 UDP 实现 UDPBus（见 kvcache_bus_udp.go）。
+
+// InvalidationOp 失效操作类型
+type InvalidationOp int
+
+const (
+    // OpDelete 删除单个键
+    OpDelete InvalidationOp = iota + 1
+    // OpClear 清空缓存
+    OpClear
+    // OpInvalidateTag 按标签失效
+    OpInvalidateTag
+)
+
+// InvalidationEvent 失效事件（可JSON序列化，便于跨进程传输）
+// 结构体字段解释：
+// - Source: 发送方节点ID（接收方据此忽略自己发出的事件）
+// - Op: 操作类型
+// - Key: JSON编码的键（仅 OpDelete 使用）
+// - Tag: 标签（仅 OpInvalidateTag 使用）
+type InvalidationEvent struct {
+    Source string          `json:"source"`
+    Op     InvalidationOp  `json:"op"`
+    Key    json.RawMessage `json:"key,omitempty"`
+    Tag    string          `json:"tag,omitempty"`
+}
+
+// Bus 失效事件总线
+// 方法说明：
+// - Publish: 广播事件（发送方自身的订阅者也可能收到，由 Source 过滤）
+// - Subscribe: 注册事件处理函数，返回取消订阅函数（返回前等待正在执行的回调结束，因此不可在回调内调用）
+// - Close: 关闭总线并释放资源
+type Bus interface {
+    Publish(ev InvalidationEvent) error
+    Subscribe(fn func(ev InvalidationEvent)) (unsubscribe func())
+    Close() error
+}
+
+// ErrBusClosed 总线已关闭
+var ErrBusClosed = errors.New("kvcache: 总线已关闭")
+
+// ChanBus 进程内通道总线
+// 结构体字段解释：
+// - mu: 保护订阅者表
+// - subs: 订阅者（每个订阅者一个事件队列与派发协程）
+// - nextID: 订阅者编号
+// - buffer: 每个订阅者队列的初始容量
+// - closed: 是否已关闭
+type ChanBus struct {
+    mu     sync.RWMutex
+    subs   map[int]*chanSub
+    nextID int
+    buffer int
+    closed bool
+}
+
+// chanSub 通道订阅者（内部使用）
+// 结构体字段解释：
+// - mu: 保护事件队列与关闭标记
+// - queue: 待处理事件（不设上限，投递从不阻塞）
+// - closed: 是否已退订（之后的投递被丢弃）
+// - notify: 唤醒派发协程（容量1，多次投递合并为一次唤醒）
+// - done: 派发协程退出信号
+type chanSub struct {
+    mu     sync.Mutex
+    queue  []InvalidationEvent
+    closed bool
+    notify chan struct{}
+    done   chan struct{}
+}
+
+// NewChanBus 创建进程内通道总线
+// 参数 buffer: 每个订阅者队列的初始容量（<=0 时默认64；队列按需增长，不限制长度）
+// 返回值：总线指针
+func NewChanBus(buffer int) *ChanBus {
+    if buffer <= 0 {
+        buffer = 64
+    }
+    return &ChanBus{subs: make(map[int]*chanSub), buffer: buffer}
+}
+
+// Publish 向全部订阅者投递事件
+// 参数 ev: 事件
+// 返回值：总线已关闭时返回 ErrBusClosed
+// 关键步骤：读锁下复制订阅者后在锁外投递；投递只追加到订阅者队列而从不阻塞，
+// 因此回调内再次发布（包括发给自己）或并发订阅/退订都不会死锁，事件不丢失且按发布顺序处理
+func (b *ChanBus) Publish(ev InvalidationEvent) error {
+    b.mu.RLock()
+    if b.closed {
+        b.mu.RUnlock()
+        return ErrBusClosed
+    }
+    subs := make([]*chanSub, 0, len(b.subs))
+    for _, s := range b.subs {
+        subs = append(subs, s)
+    }
+    b.mu.RUnlock()
+    for _, s := range subs {
+        s.push(ev)
+    }
+    return nil
+}
+
+// Subscribe 注册事件处理函数
+// 参数 fn: 处理函数（在独立协程中按顺序调用）
+// 返回值：取消订阅函数（幂等，返回前等待派发协程退出，不可在回调内调用）
+func (b *ChanBus) Subscribe(fn func(ev InvalidationEvent)) (unsubscribe func()) {
+    b.mu.Lock()
+    if b.closed {
+        b.mu.Unlock()
+        return func() {}
+    }
+    s := &chanSub{queue: make([]InvalidationEvent, 0, b.buffer), notify: make(chan struct{}, 1), done: make(chan struct{})}
+    id := b.nextID
+    b.nextID++
+    b.subs[id] = s
+    b.mu.Unlock()
+    go s.dispatch(fn)
+    return func() {
+        b.mu.Lock()
+        delete(b.subs, id)
+        b.mu.Unlock()
+        s.stop()
+    }
+}
+
+// Close 关闭总线，结束全部订阅者的派发协程
+// 参数：无
+// 返回值：恒为nil
+func (b *ChanBus) Close() error {
+    b.mu.Lock()
+    if b.closed {
+        b.mu.Unlock()
+        return nil
+    }
+    b.closed = true
+    subs := b.subs
+    b.subs = make(map[int]*chanSub)
+    b.mu.Unlock()
+    for _, s := range subs {
+        s.stop()
+    }
+    return nil
+}
+
+// Invalidator 将 KVCache 接入失效总线
+// 结构体字段解释：
+// - cache: 本地缓存
+// - bus: 失效总线
+// - node: 本节点ID
+// - unsub: 取消订阅函数
+type Invalidator[K comparable, V any] struct {
+    cache *KVCache[K, V]
+    bus   Bus
+    node  string
+    unsub func()
+}
+
+// NewInvalidator 创建失效同步器并订阅总线
+// 参数 c: 本地缓存
+// 参数 bus: 失效总线
+// 参数 nodeID: 本节点ID（为空时随机生成）
+// 返回值：同步器指针
+// 关键步骤：收到其他节点的事件时直接作用于本地缓存，不再转发，避免广播风暴
+func NewInvalidator[K comparable, V any](c *KVCache[K, V], bus Bus, nodeID string) *Invalidator[K, V] {
+    if nodeID == "" {
+        var b [8]byte
+        rand.Read(b[:])
+        nodeID = hex.EncodeToString(b[:])
+    }
+    inv := &Invalidator[K, V]{cache: c, bus: bus, node: nodeID}
+    inv.unsub = bus.Subscribe(inv.apply)
+    return inv
+}
+
+// NodeID 返回本节点ID
+// 参数：无
+// 返回值：节点ID
+func (inv *Invalidator[K, V]) NodeID() string { return inv.node }
+
+// Cache 返回本地缓存（读取直接使用缓存即可）
+// 参数：无
+// 返回值：本地缓存指针
+func (inv *Invalidator[K, V]) Cache() *KVCache[K, V] { return inv.cache }
+
+// Set 写入本地缓存并通知其他节点删除旧副本
+// 参数 key: 键
+// 参数 value: 值
+// 返回值：键编码或广播错误（本地写入总是生效）
+func (inv *Invalidator[K, V]) Set(key K, value V) error {
+    inv.cache.Set(key, value)
+    return inv.publishDelete(key)
+}
+
+// Delete 删除本地键并广播删除事件
+// 参数 key: 键
+// 返回值 deleted: 本地是否存在该键；err: 广播错误
+func (inv *Invalidator[K, V]) Delete(key K) (deleted bool, err error) {
+    deleted = inv.cache.Delete(key)
+    return deleted, inv.publishDelete(key)
+}
+
+// Clear 清空本地缓存并广播清空事件
+// 参数：无
+// 返回值：广播错误
+func (inv *Invalidator[K, V]) Clear() error {
+    inv.cache.Clear()
+    return inv.bus.Publish(InvalidationEvent{Source: inv.node, Op: OpClear})
+}
+
+// InvalidateTag 按标签失效本地条目并广播
+// 参数 tag: 标签
+// 返回值 n: 本地删除数量；err: 广播错误
+func (inv *Invalidator[K, V]) InvalidateTag(tag string) (n int, err error) {
+    n = inv.cache.InvalidateTag(tag)
+    return n, inv.bus.Publish(InvalidationEvent{Source: inv.node, Op: OpInvalidateTag, Tag: tag})
+}
+
+// Close 取消订阅总线（不关闭总线与缓存）
+// 参数：无
+// 返回值：无
+func (inv *Invalidator[K, V]) Close() { inv.unsub() }
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// push 追加事件并唤醒派发协程
+// 参数 ev: 事件
+// 返回值：无
+func (s *chanSub) push(ev InvalidationEvent) {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        return
+    }
+    s.queue = append(s.queue, ev)
+    s.mu.Unlock()
+    s.wake()
+}
+
+// dispatch 订阅者派发协程主循环
+// 参数 fn: 处理函数
+// 返回值：无
+// 关键步骤：每次取走整批事件在锁外按序处理；退订后继续处理已入队的事件，队列为空时退出
+func (s *chanSub) dispatch(fn func(ev InvalidationEvent)) {
+    defer close(s.done)
+    for {
+        s.mu.Lock()
+        batch, closed := s.queue, s.closed
+        s.queue = nil
+        s.mu.Unlock()
+        for _, ev := range batch {
+            fn(ev)
+        }
+        if len(batch) == 0 {
+            if closed {
+                return
+            }
+            <-s.notify
+        }
+    }
+}
+
+// stop 标记退订并等待派发协程退出（幂等）
+// 参数：无
+// 返回值：无
+func (s *chanSub) stop() {
+    s.mu.Lock()
+    s.closed = true
+    s.mu.Unlock()
+    s.wake()
+    <-s.done
+}
+
+// wake 非阻塞地唤醒派发协程
+// 参数：无
+// 返回值：无
+func (s *chanSub) wake() {
+    select {
+    case s.notify <- struct{}{}:
+    default:
+    }
+}
+
+// publishDelete 广播单键删除事件
+// 参数 key: 键
+// 返回值：编码或广播错误
+func (inv *Invalidator[K, V]) publishDelete(key K) error {
+    raw, err := json.Marshal(key)
+    if err != nil {
+        return err
+    }
+    return inv.bus.Publish(InvalidationEvent{Source: inv.node, Op: OpDelete, Key: raw})
+}
+
+// apply 处理收到的失效事件
+// 参数 ev: 事件
+// 返回值：无
+// 关键步骤：忽略本节点发出的事件；键解码失败的事件直接丢弃
+func (inv *Invalidator[K, V]) apply(ev InvalidationEvent) {
+    if ev.Source == inv.node {
+        return
+    }
+    switch ev.Op {
+    case OpDelete:
+        var key K
+        if err := json.Unmarshal(ev.Key, &key); err != nil {
+            return
+        }
+        inv.cache.Delete(key)
+    case OpClear:
+        inv.cache.Clear()
+    case OpInvalidateTag:
+        inv.cache.InvalidateTag(ev.Tag)
+    }
+}
@@ -0,0 +1,176 @@
+package kvcache
+
+import (
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// waitUntil 轮询等待条件成立（用于异步投递的事件）
+// 参数 cond: 条件函数
+// 返回值：超时前条件是否成立
+func waitUntil(cond func() bool) bool {
+    deadline := time.Now().Add(2 * time.Second)
+    for time.Now().Before(deadline) {
+        if cond() {
+            return true
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    return cond()
+}
+
+// TestInvalidatorChanBus 测试进程内总线上的失效同步
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：节点A写入或删除键后节点B的旧副本被删除；Clear 与标签失效同样传播；A 自身的值不受自身事件影响
+func TestInvalidatorChanBus(t *testing.T) {
+    bus := NewChanBus(0)
+    defer bus.Close()
+    a := NewInvalidator(New[string, int](), Bus(bus), "a")
+    b := NewInvalidator(New[string, int](), Bus(bus), "b")
+    defer a.Close()
+    defer b.Close()
+
+    b.Cache().Set("k", 1)
+    if err := a.Set("k", 2); err != nil { t.Fatalf("Set失败: %v", err) }
+    if !waitUntil(func() bool { return !b.Cache().Has("k") }) { t.Fatalf("A更新后B的旧副本应被删除") }
+    if v, ok := a.Cache().Get("k"); !ok || v != 2 { t.Fatalf("A不应处理自己发出的事件: v=%d ok=%v", v, ok) }
+
+    b.Cache().Set("x", 1)
+    b.Cache().Set("y", 1)
+    if err := a.Clear(); err != nil { t.Fatalf("Clear失败: %v", err) }
+    if !waitUntil(func() bool { return b.Cache().Len() == 0 }) { t.Fatalf("Clear应传播到B") }
+
+    b.Cache().SetWithTags("u1", 1, "user")
+    b.Cache().Set("other", 1)
+    a.InvalidateTag("user")
+    if !waitUntil(func() bool { return !b.Cache().Has("u1") }) || !b.Cache().Has("other") { t.Fatalf("标签失效应只删除带标签的键") }
+
+    a.Close()
+    a.Cache().Set("k", 1)
+    b.Delete("k")
+    time.Sleep(20 * time.Millisecond)
+    if !a.Cache().Has("k") { t.Fatalf("取消订阅后不应再处理事件") }
+}
+
+// TestInvalidatorUDPBusLoopback 测试 UDP 总线在回环地址上的失效同步
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：两个节点监听随机端口并互为对端；整数键经JSON编码传输后正确删除；关闭后发布返回 ErrBusClosed，订阅不再注册
+func TestInvalidatorUDPBusLoopback(t *testing.T) {
+    busA, err := NewUDPBus("127.0.0.1:0", nil)
+    if err != nil { t.Fatalf("创建总线A失败: %v", err) }
+    busB, err := NewUDPBus("127.0.0.1:0", []string{busA.Addr().String()})
+    if err != nil { t.Fatalf("创建总线B失败: %v", err) }
+    if err := busA.AddPeer(busB.Addr().String()); err != nil { t.Fatalf("添加对端失败: %v", err) }
+    defer busB.Close()
+
+    a := NewInvalidator(New[int, string](), Bus(busA), "")
+    b := NewInvalidator(New[int, string](), Bus(busB), "")
+    if a.NodeID() == "" || a.NodeID() == b.NodeID() { t.Fatalf("应生成不同的随机节点ID") }
+
+    b.Cache().Set(42, "stale")
+    if err := a.Set(42, "fresh"); err != nil { t.Fatalf("发布失败: %v", err) }
+    if !waitUntil(func() bool { return !b.Cache().Has(42) }) { t.Fatalf("UDP事件应删除B的旧副本") }
+
+    a.Cache().Set(1, "x")
+    if _, err := b.Delete(1); err != nil { t.Fatalf("发布失败: %v", err) }
+    if !waitUntil(func() bool { return !a.Cache().Has(1) }) { t.Fatalf("B的删除应传播到A") }
+
+    if err := busA.Close(); err != nil { t.Fatalf("关闭失败: %v", err) }
+    if err := busA.Publish(InvalidationEvent{Op: OpClear}); err != ErrBusClosed { t.Fatalf("关闭后发布应返回ErrBusClosed: %v", err) }
+    busA.Subscribe(func(InvalidationEvent) {})
+    if n := len(busA.subs); n != 1 { t.Fatalf("关闭后订阅不应注册: %d", n) }
+}
+
+// TestChanBusNestedPublish 测试回调内再次发布与并发订阅/退订不会死锁
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：回调收到删除事件时再向同一总线（含自己）发布清空事件，同时另一协程反复订阅与退订；
+// 初始容量为1，队列需按需增长
+func TestChanBusNestedPublish(t *testing.T) {
+    bus := NewChanBus(1)
+    defer bus.Close()
+    var cleared atomic.Int32
+    unsub := bus.Subscribe(func(ev InvalidationEvent) {
+        if ev.Op == OpDelete {
+            bus.Publish(InvalidationEvent{Op: OpClear})
+        } else {
+            cleared.Add(1)
+        }
+    })
+    defer unsub()
+    stop := make(chan struct{})
+    churned := make(chan struct{})
+    go func() {
+        defer close(churned)
+        for {
+            select {
+            case <-stop:
+                return
+            default:
+                bus.Subscribe(func(InvalidationEvent) {})()
+            }
+        }
+    }()
+    published := make(chan struct{})
+    go func() {
+        defer close(published)
+        for i := 0; i < 200; i++ { bus.Publish(InvalidationEvent{Op: OpDelete}) }
+    }()
+    select {
+    case <-published:
+    case <-time.After(5 * time.Second):
+        t.Fatalf("回调内发布与并发订阅发生死锁")
+    }
+    close(stop)
+    <-churned
+    if !waitUntil(func() bool { return cleared.Load() == 200 }) { t.Fatalf("嵌套发布的事件应全部送达: %d", cleared.Load()) }
+}
+
+// TestUDPBusSelfFilterAndUnsubscribeWait 测试 UDP 总线丢弃自己发出的事件，且取消订阅等待进行中的回调
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：A 的对端列表包含自身，A 的订阅者不应收到自己的事件而 B 应收到；
+// B 的回调阻塞期间取消订阅不应返回，放行后才返回，之后不再回调
+func TestUDPBusSelfFilterAndUnsubscribeWait(t *testing.T) {
+    busA, err := NewUDPBus("127.0.0.1:0", nil)
+    if err != nil { t.Fatalf("创建总线A失败: %v", err) }
+    defer busA.Close()
+    busB, err := NewUDPBus("127.0.0.1:0", nil)
+    if err != nil { t.Fatalf("创建总线B失败: %v", err) }
+    defer busB.Close()
+    busA.AddPeer(busA.Addr().String())
+    busA.AddPeer(busB.Addr().String())
+
+    var self atomic.Int32
+    busA.Subscribe(func(InvalidationEvent) { self.Add(1) })
+    entered := make(chan struct{}, 1)
+    release := make(chan struct{})
+    var calls atomic.Int32
+    unsub := busB.Subscribe(func(InvalidationEvent) {
+        calls.Add(1)
+        entered <- struct{}{}
+        <-release
+    })
+    if err := busA.Publish(InvalidationEvent{Op: OpClear}); err != nil { t.Fatalf("发布失败: %v", err) }
+    select {
+    case <-entered:
+    case <-time.After(2 * time.Second):
+        t.Fatalf("B应收到A的事件")
+    }
+    returned := make(chan struct{})
+    go func() { unsub(); close(returned) }()
+    select {
+    case <-returned:
+        t.Fatalf("回调执行期间取消订阅不应返回")
+    case <-time.After(20 * time.Millisecond):
+    }
+    close(release)
+    <-returned
+    busA.Publish(InvalidationEvent{Op: OpClear})
+    time.Sleep(50 * time.Millisecond)
+    if self.Load() != 0 { t.Fatalf("A不应收到自己发出的事件: %d", self.Load()) }
+    if calls.Load() != 1 { t.Fatalf("取消订阅后不应再回调: %d", calls.Load()) }
+}
@@ -0,0 +1,255 @@
+package kvcache
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "net"
+    "sync"
+    "time"
+)
+
+// 本文件提供基于 UDP 的失效总线：每个节点监听一个 UDP 地址，发布时把事件以 JSON 数据报发送给全部对端。
+// 监听地址为组播地址时自动加入组播组，并默认把事件发往该组播组，适合同网段多实例；
+// 否则按对端列表逐个单播，适合跨网段或本机回环测试。UDP 不保证送达，失效事件仅用于尽力降低旧值窗口，
+// 仍需配合 TTL 兜底。数据报携带总线实例ID，组播回环或对端列表包含自身时，本总线发出的事件在接收端被丢弃。
+// 本包只提供 UDP（单播/组播）传输，不提供 TCP 传输；需要可靠送达时可基于 Bus 接口接入消息队列等自行实现。
+
+// maxDatagram 单个事件数据报的最大字节数（超出时发布失败）
+const maxDatagram = 64 * 1024
+
+// 接收出错时的退避时长范围（连续出错时逐次翻倍，成功读取后复位）
+const (
+    minReadBackoff = 10 * time.Millisecond
+    maxReadBackoff = time.Second
+)
+
+// UDPBus 基于 UDP 的失效总线
+// 结构体字段解释：
+// - id: 总线实例ID（随机生成，用于丢弃自己发出的数据报）
+// - conn: 监听连接（接收事件）
+// - send: 发送连接（组播模式下独立创建，单播模式与 conn 相同）
+// - mu: 保护对端与订阅者表
+// - peers: 对端地址列表
+// - subs: 订阅者
+// - nextID: 订阅者编号
+// - closed: 是否已关闭
+// - stop: 关闭信号（打断接收出错后的退避等待）
+// - done: 接收协程退出信号
+type UDPBus struct {
+    id   string
+    conn *net.UDPConn
+    send *net.UDPConn
+
+    mu     sync.RWMutex
+    peers  []*net.UDPAddr
+    subs   map[int]*udpSub
+    nextID int
+    closed bool
+    stop   chan struct{}
+    done   chan struct{}
+}
+
+// udpSub UDP 总线订阅者（内部使用）
+// 结构体字段解释：
+// - mu: 回调执行期间持有，取消订阅时借此等待正在执行的回调结束
+// - fn: 处理函数
+// - removed: 是否已取消订阅（受 mu 保护）
+type udpSub struct {
+    mu      sync.Mutex
+    fn      func(ev InvalidationEvent)
+    removed bool
+}
+
+// udpDatagram 数据报格式：事件字段与发送方总线ID平铺在同一JSON对象中
+// 结构体字段解释：
+// - Bus: 发送方总线实例ID
+type udpDatagram struct {
+    Bus string `json:"bus"`
+    InvalidationEvent
+}
+
+// NewUDPBus 创建 UDP 失效总线并启动接收协程
+// 参数 listenAddr: 监听地址（如 "127.0.0.1:0"、"239.1.2.3:7946"；组播地址时加入该组）
+// 参数 peers: 对端地址列表；组播模式下为空时默认发往监听的组播地址
+// 返回值：总线指针与错误（地址解析或监听失败）
+// 关键步骤：接收到的数据报解码失败时直接丢弃；订阅者在接收协程中按到达顺序调用
+func NewUDPBus(listenAddr string, peers []string) (*UDPBus, error) {
+    laddr, err := net.ResolveUDPAddr("udp", listenAddr)
+    if err != nil {
+        return nil, err
+    }
+    var id [8]byte
+    rand.Read(id[:])
+    b := &UDPBus{id: hex.EncodeToString(id[:]), subs: make(map[int]*udpSub), stop: make(chan struct{}), done: make(chan struct{})}
+    if laddr.IP != nil && laddr.IP.IsMulticast() {
+        if b.conn, err = net.ListenMulticastUDP("udp", nil, laddr); err != nil {
+            return nil, err
+        }
+        if b.send, err = net.ListenUDP("udp", nil); err != nil {
+            b.conn.Close()
+            return nil, err
+        }
+        if len(peers) == 0 {
+            b.peers = append(b.peers, laddr)
+        }
+    } else {
+        if b.conn, err = net.ListenUDP("udp", laddr); err != nil {
+            return nil, err
+        }
+        b.send = b.conn
+    }
+    for _, p := range peers {
+        if err := b.AddPeer(p); err != nil {
+            b.closeConns()
+            return nil, err
+        }
+    }
+    go b.receive()
+    return b, nil
+}
+
+// Addr 返回实际监听地址（监听端口为0时可用于获取系统分配的端口）
+// 参数：无
+// 返回值：监听地址
+func (b *UDPBus) Addr() net.Addr { return b.conn.LocalAddr() }
+
+// AddPeer 追加对端地址
+// 参数 addr: 对端地址（host:port）
+// 返回值：地址解析错误
+func (b *UDPBus) AddPeer(addr string) error {
+    ua, err := net.ResolveUDPAddr("udp", addr)
+    if err != nil {
+        return err
+    }
+    b.mu.Lock()
+    b.peers = append(b.peers, ua)
+    b.mu.Unlock()
+    return nil
+}
+
+// Publish 将事件发送给全部对端
+// 参数 ev: 事件
+// 返回值：编码错误、数据报过大、总线已关闭或合并后的发送错误
+// 关键步骤：数据报携带本总线ID，即使组播回环或对端列表包含自身，本总线的订阅者也不会收到自己发布的事件
+func (b *UDPBus) Publish(ev InvalidationEvent) error {
+    payload, err := json.Marshal(udpDatagram{Bus: b.id, InvalidationEvent: ev})
+    if err != nil {
+        return err
+    }
+    if len(payload) > maxDatagram {
+        return errors.New("kvcache: 失效事件过大")
+    }
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+    if b.closed {
+        return ErrBusClosed
+    }
+    var errs []error
+    for _, p := range b.peers {
+        if _, err := b.send.WriteToUDP(payload, p); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// Subscribe 注册事件处理函数
+// 参数 fn: 处理函数（在接收协程中调用，不宜长时间阻塞）
+// 返回值：取消订阅函数（幂等；返回前等待正在执行的回调结束，返回后不再调用 fn，不可在回调内调用）；
+// 总线已关闭时不注册，返回空操作函数
+func (b *UDPBus) Subscribe(fn func(ev InvalidationEvent)) (unsubscribe func()) {
+    s := &udpSub{fn: fn}
+    b.mu.Lock()
+    if b.closed {
+        b.mu.Unlock()
+        return func() {}
+    }
+    id := b.nextID
+    b.nextID++
+    b.subs[id] = s
+    b.mu.Unlock()
+    return func() {
+        b.mu.Lock()
+        delete(b.subs, id)
+        b.mu.Unlock()
+        s.mu.Lock()
+        s.removed = true
+        s.mu.Unlock()
+    }
+}
+
+// Close 关闭连接并等待接收协程退出
+// 参数：无
+// 返回值：关闭连接的错误（重复关闭返回nil）
+func (b *UDPBus) Close() error {
+    b.mu.Lock()
+    if b.closed {
+        b.mu.Unlock()
+        return nil
+    }
+    b.closed = true
+    b.mu.Unlock()
+    close(b.stop)
+    err := b.closeConns()
+    <-b.done
+    return err
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// receive 接收协程主循环
+// 参数：无
+// 返回值：无
+// 关键步骤：连接关闭后读取返回错误，协程退出；其他读取错误按指数退避后重试，避免持续出错时空转占满CPU；
+// 丢弃本总线发出的数据报；回调在订阅者锁内执行并检查是否已取消订阅，保证取消订阅返回后不再调用
+func (b *UDPBus) receive() {
+    defer close(b.done)
+    buf := make([]byte, maxDatagram)
+    backoff := minReadBackoff
+    for {
+        n, _, err := b.conn.ReadFromUDP(buf)
+        if err != nil {
+            if errors.Is(err, net.ErrClosed) {
+                return
+            }
+            select {
+            case <-b.stop:
+                return
+            case <-time.After(backoff):
+            }
+            backoff = min(backoff*2, maxReadBackoff)
+            continue
+        }
+        backoff = minReadBackoff
+        var dg udpDatagram
+        if err := json.Unmarshal(buf[:n], &dg); err != nil || dg.Bus == b.id {
+            continue
+        }
+        b.mu.RLock()
+        subs := make([]*udpSub, 0, len(b.subs))
+        for _, s := range b.subs {
+            subs = append(subs, s)
+        }
+        b.mu.RUnlock()
+        for _, s := range subs {
+            s.mu.Lock()
+            if !s.removed {
+                s.fn(dg.InvalidationEvent)
+            }
+            s.mu.Unlock()
+        }
+    }
+}
+
+// closeConns 关闭监听与发送连接
+// 参数：无
+// 返回值：合并后的关闭错误
+func (b *UDPBus) closeConns() error {
+    err := b.conn.Close()
+    if b.send != b.conn {
+        err = errors.Join(err, b.send.Close())
+    }
+    return err
+}
@@ -0,0 +1,141 @@
+package kvcache
+
+// 本文件提供缓存事件回调：条目写入、删除与被淘汰时通知调用方（如关闭文件句柄、上报指标）。
+// 事件在持锁期间收集，释放锁之后再依次派发，因此回调内可以安全地再次访问缓存。
+
+// EvictReason 条目离开缓存的原因
+type EvictReason int
+
+const (
+    // ReasonExpired 条目过期（惰性淘汰、后台清理或覆盖已过期条目）
+    ReasonExpired EvictReason = iota + 1
+    // ReasonCapacity 超出容量上限被淘汰（含写入单条成本超限的新值时被挤出的同名旧值；新值本身不派发事件）
+    ReasonCapacity
+    // ReasonDeleted 调用 Delete 显式删除
+    ReasonDeleted
+    // ReasonCleared 调用 Clear 清空
+    ReasonCleared
+    // ReasonReplaced 旧值被同名键的新值覆盖
+    ReasonReplaced
+)
+
+// String 返回原因名称
+// 参数：无
+// 返回值：原因的可读名称
+func (r EvictReason) String() string {
+    switch r {
+    case ReasonExpired:
+        return "expired"
+    case ReasonCapacity:
+        return "capacity"
+    case ReasonDeleted:
+        return "deleted"
+    case ReasonCleared:
+        return "cleared"
+    case ReasonReplaced:
+        return "replaced"
+    }
+    return "unknown"
+}
+
+// hooks 已注册的回调集合（内部使用，整体替换以避免加锁读取）
+type hooks[K comparable, V any] struct {
+    onEvict  func(key K, value V, reason EvictReason)
+    onSet    func(key K, value V)
+    onDelete func(key K, value V, reason EvictReason)
+}
+
+// event 待派发事件（内部使用）
+// 结构体字段解释：
+// - key, value: 事件涉及的键与值
+// - reason: 移除原因（写入事件为0）
+// - set: 是否为写入事件
+type event[K comparable, V any] struct {
+    key    K
+    value  V
+    reason EvictReason
+    set    bool
+}
+
+// OnEvict 注册条目离开缓存时的回调
+// 参数 fn: 回调函数，接收键、值与原因；传nil取消注册
+// 返回值：无
+// 关键步骤：任何原因导致值离开缓存（过期、容量淘汰、删除、清空、被覆盖）都会触发，适合释放值持有的资源
+func (c *KVCache[K, V]) OnEvict(fn func(key K, value V, reason EvictReason)) {
+    c.updateHooks(func(h *hooks[K, V]) { h.onEvict = fn })
+}
+
+// OnSet 注册条目写入时的回调
+// 参数 fn: 回调函数，接收写入的键与值；传nil取消注册
+// 返回值：无
+func (c *KVCache[K, V]) OnSet(fn func(key K, value V)) {
+    c.updateHooks(func(h *hooks[K, V]) { h.onSet = fn })
+}
+
+// OnDelete 注册条目被显式删除时的回调
+// 参数 fn: 回调函数，接收键、值与原因（ReasonDeleted 或 ReasonCleared）；传nil取消注册
+// 返回值：无
+func (c *KVCache[K, V]) OnDelete(fn func(key K, value V, reason EvictReason)) {
+    c.updateHooks(func(h *hooks[K, V]) { h.onDelete = fn })
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// updateHooks 复制并修改回调集合
+// 参数 mutate: 修改函数
+// 返回值：无
+// 关键步骤：写锁下复制旧集合再原子替换，全部回调为空时置nil以跳过事件收集
+func (c *KVCache[K, V]) updateHooks(mutate func(h *hooks[K, V])) {
+    c.mu.Lock()
+    h := &hooks[K, V]{}
+    if old := c.hooks.Load(); old != nil {
+        *h = *old
+    }
+    mutate(h)
+    if h.onEvict == nil && h.onSet == nil && h.onDelete == nil {
+        h = nil
+    }
+    c.hooks.Store(h)
+    c.mu.Unlock()
+}
+
+// unlockAndNotify 释放写锁并派发持锁期间收集的事件
+// 参数：无
+// 返回值：无
+// 关键步骤：先取出待派发事件再解锁，保证回调执行时不持有内部锁
+func (c *KVCache[K, V]) unlockAndNotify() {
+    evs := c.pending
+    c.pending = nil
+    c.mu.Unlock()
+    if len(evs) == 0 {
+        return
+    }
+    h := c.hooks.Load()
+    if h == nil {
+        return
+    }
+    for _, ev := range evs {
+        if ev.set {
+            if h.onSet != nil {
+                h.onSet(ev.key, ev.value)
+            }
+            continue
+        }
+        if h.onEvict != nil {
+            h.onEvict(ev.key, ev.value, ev.reason)
+        }
+        if h.onDelete != nil && (ev.reason == ReasonDeleted || ev.reason == ReasonCleared) {
+            h.onDelete(ev.key, ev.value, ev.reason)
+        }
+    }
+}
+
+// replaceReason 判断被覆盖条目的移除原因
+// 参数 e: 被覆盖的条目
+// 返回值：已过期为 ReasonExpired，否则为 ReasonReplaced
+func (c *KVCache[K, V]) replaceReason(e *entry[K, V]) EvictReason {
+    if e.expired(c.nowNano()) {
+        return ReasonExpired
+    }
+    return ReasonReplaced
+}
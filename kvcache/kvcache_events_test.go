@@ -0,0 +1,89 @@
+package kvcache
+
+import (
+    "testing"
+    "time"
+)
+
+// recordedEvent 测试中记录的事件
+type recordedEvent struct {
+    kind   string
+    key    string
+    value  int
+    reason EvictReason
+}
+
+// newRecordingCache 创建注册了全部回调的缓存并返回事件记录
+// 参数 opt: 构造选项
+// 返回值：缓存与事件切片指针
+func newRecordingCache(opt Options[string, int]) (*KVCache[string, int], *[]recordedEvent) {
+    c := NewWithOptions(opt)
+    var evs []recordedEvent
+    c.OnSet(func(k string, v int) { evs = append(evs, recordedEvent{"set", k, v, 0}) })
+    c.OnEvict(func(k string, v int, r EvictReason) { evs = append(evs, recordedEvent{"evict", k, v, r}) })
+    c.OnDelete(func(k string, v int, r EvictReason) { evs = append(evs, recordedEvent{"delete", k, v, r}) })
+    return c, &evs
+}
+
+// TestEventsReasons 测试各类事件及原因
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：覆盖、容量淘汰、删除、清空、过期分别触发对应回调与原因
+func TestEventsReasons(t *testing.T) {
+    clk := newFakeClock()
+    c, evs := newRecordingCache(Options[string, int]{MaxEntries: 2})
+    c.clock = clk.Now
+    c.Set("a", 1)
+    c.Set("a", 2)
+    c.Set("b", 3)
+    c.Set("c", 4)
+    c.Delete("b")
+    c.SetWithTTL("d", 5, time.Second)
+    clk.Advance(time.Second)
+    c.Get("d")
+    c.Set("e", 6)
+    c.Clear()
+    want := []recordedEvent{
+        {"set", "a", 1, 0},
+        {"evict", "a", 1, ReasonReplaced},
+        {"set", "a", 2, 0},
+        {"set", "b", 3, 0},
+        {"set", "c", 4, 0},
+        {"evict", "a", 2, ReasonCapacity},
+        {"evict", "b", 3, ReasonDeleted},
+        {"delete", "b", 3, ReasonDeleted},
+        {"set", "d", 5, 0},
+        {"evict", "d", 5, ReasonExpired},
+        {"set", "e", 6, 0},
+    }
+    got := *evs
+    if len(got) < len(want) { t.Fatalf("事件数量不足: %v", got) }
+    for i, w := range want {
+        if got[i] != w { t.Fatalf("第%d个事件不匹配: got=%v want=%v", i, got[i], w) }
+    }
+    // 关键步骤：Clear 为剩余的 c、e 各派发 evict 与 delete 两个事件（顺序不保证）
+    rest := got[len(want):]
+    if len(rest) != 4 { t.Fatalf("Clear应派发4个事件: %v", rest) }
+    for _, ev := range rest {
+        if ev.reason != ReasonCleared { t.Fatalf("Clear事件原因应为cleared: %v", ev) }
+    }
+}
+
+// TestEventsCallbackReentrant 测试回调内可再次访问缓存
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：淘汰回调中写入另一个缓存键不会死锁；取消注册后不再触发
+func TestEventsCallbackReentrant(t *testing.T) {
+    c := NewWithOptions(Options[string, int]{MaxEntries: 1})
+    evicted := 0
+    c.OnEvict(func(k string, v int, r EvictReason) {
+        evicted++
+        if r == ReasonCapacity { c.Get(k) }
+    })
+    c.Set("a", 1)
+    c.Set("b", 2)
+    if evicted != 1 { t.Fatalf("应触发一次淘汰回调: %d", evicted) }
+    c.OnEvict(nil)
+    c.Set("c", 3)
+    if evicted != 1 { t.Fatalf("取消注册后不应再触发: %d", evicted) }
+}
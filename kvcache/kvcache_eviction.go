@@ -0,0 +1,170 @@
+package kvcache
+
+import (
+    "container/heap"
+    "container/list"
+)
+
+// 本文件提供容量受限时的淘汰策略实现：LRU（最近最少使用）、LFU（最不经常使用）与 FIFO（先进先出）。
+// 所有策略方法均由 KVCache 在持有写锁时调用，自身不做并发保护。
+
+// EvictionPolicy 容量淘汰策略
+type EvictionPolicy int
+
+const (
+    // PolicyLRU 淘汰最久未被访问的条目（默认）
+    PolicyLRU EvictionPolicy = iota
+    // PolicyLFU 淘汰访问次数最少的条目（次数相同时淘汰最久未访问者）
+    PolicyLFU
+    // PolicyFIFO 淘汰最早写入的条目（读取与覆盖不改变顺序）
+    PolicyFIFO
+)
+
+// String 返回策略名称
+// 参数：无
+// 返回值：策略的可读名称
+func (p EvictionPolicy) String() string {
+    switch p {
+    case PolicyLRU:
+        return "lru"
+    case PolicyLFU:
+        return "lfu"
+    case PolicyFIFO:
+        return "fifo"
+    }
+    return "unknown"
+}
+
+// evictor 淘汰策略抽象（内部使用）
+// 方法说明：
+// - add: 新条目写入
+// - access: 条目被读取或覆盖
+// - remove: 条目被移除
+// - victim: 选出下一个待淘汰条目（为空返回nil）
+type evictor[K comparable, V any] interface {
+    add(e *entry[K, V])
+    access(e *entry[K, V])
+    remove(e *entry[K, V])
+    victim() *entry[K, V]
+}
+
+// newEvictor 按策略创建淘汰器
+// 参数 p: 淘汰策略
+// 返回值：淘汰器实现（未知策略回退为LRU）
+func newEvictor[K comparable, V any](p EvictionPolicy) evictor[K, V] {
+    switch p {
+    case PolicyLFU:
+        return &lfuEvictor[K, V]{}
+    case PolicyFIFO:
+        return &listEvictor[K, V]{l: list.New(), fifo: true}
+    }
+    return &listEvictor[K, V]{l: list.New()}
+}
+
+// listEvictor 基于双向链表的 LRU/FIFO 淘汰器
+// 结构体字段解释：
+// - l: 链表，头部为最新条目、尾部为淘汰候选
+// - fifo: 为true时访问不调整顺序
+type listEvictor[K comparable, V any] struct {
+    l    *list.List
+    fifo bool
+}
+
+// add 新条目插入链表头部
+func (x *listEvictor[K, V]) add(e *entry[K, V]) { e.elem = x.l.PushFront(e) }
+
+// access LRU 模式下将条目移动到链表头部
+func (x *listEvictor[K, V]) access(e *entry[K, V]) {
+    if !x.fifo && e.elem != nil {
+        x.l.MoveToFront(e.elem)
+    }
+}
+
+// remove 从链表中移除条目
+func (x *listEvictor[K, V]) remove(e *entry[K, V]) {
+    if e.elem != nil {
+        x.l.Remove(e.elem)
+        e.elem = nil
+    }
+}
+
+// victim 返回链表尾部条目
+func (x *listEvictor[K, V]) victim() *entry[K, V] {
+    if b := x.l.Back(); b != nil {
+        return b.Value.(*entry[K, V])
+    }
+    return nil
+}
+
+// lfuEvictor 基于最小堆的 LFU 淘汰器
+// 结构体字段解释：
+// - h: 按 (freq, seq) 排序的最小堆
+// - seq: 单调递增的访问序号，用于同频次时淘汰最久未访问者
+type lfuEvictor[K comparable, V any] struct {
+    h   lfuHeap[K, V]
+    seq uint64
+}
+
+// add 新条目以频次1入堆
+func (x *lfuEvictor[K, V]) add(e *entry[K, V]) {
+    x.seq++
+    e.freq, e.seq = 1, x.seq
+    heap.Push(&x.h, e)
+}
+
+// access 频次加一并调整堆位置
+func (x *lfuEvictor[K, V]) access(e *entry[K, V]) {
+    x.seq++
+    e.freq++
+    e.seq = x.seq
+    heap.Fix(&x.h, e.index)
+}
+
+// remove 从堆中移除条目
+func (x *lfuEvictor[K, V]) remove(e *entry[K, V]) {
+    if e.index >= 0 && e.index < len(x.h) && x.h[e.index] == e {
+        heap.Remove(&x.h, e.index)
+    }
+}
+
+// victim 返回堆顶（频次最低）条目
+func (x *lfuEvictor[K, V]) victim() *entry[K, V] {
+    if len(x.h) == 0 {
+        return nil
+    }
+    return x.h[0]
+}
+
+// lfuHeap 实现 heap.Interface 的条目切片
+type lfuHeap[K comparable, V any] []*entry[K, V]
+
+func (h lfuHeap[K, V]) Len() int { return len(h) }
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+    if h[i].freq != h[j].freq {
+        return h[i].freq < h[j].freq
+    }
+    return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+    h[i], h[j] = h[j], h[i]
+    h[i].index = i
+    h[j].index = j
+}
+
+func (h *lfuHeap[K, V]) Push(x any) {
+    e := x.(*entry[K, V])
+    e.index = len(*h)
+    *h = append(*h, e)
+}
+
+func (h *lfuHeap[K, V]) Pop() any {
+    old := *h
+    n := len(old)
+    e := old[n-1]
+    old[n-1] = nil
+    e.index = -1
+    *h = old[:n-1]
+    return e
+}
@@ -0,0 +1,82 @@
+package kvcache
+
+import (
+    "testing"
+)
+
+// TestEvictionLRU 测试LRU淘汰
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：读取a使其变为最近使用，写入d时应淘汰b
+func TestEvictionLRU(t *testing.T) {
+    c := NewWithOptions(Options[string, int]{MaxEntries: 3, Policy: PolicyLRU})
+    c.Set("a", 1)
+    c.Set("b", 2)
+    c.Set("c", 3)
+    c.Get("a")
+    c.Set("d", 4)
+    if c.Len() != 3 { t.Fatalf("长度应保持为3: %d", c.Len()) }
+    if c.Has("b") { t.Fatalf("b应被淘汰") }
+    for _, k := range []string{"a", "c", "d"} {
+        if !c.Has(k) { t.Fatalf("%s不应被淘汰", k) }
+    }
+}
+
+// TestEvictionLFU 测试LFU淘汰
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：a、c被多次访问，写入d时应淘汰访问最少的b
+func TestEvictionLFU(t *testing.T) {
+    c := NewWithOptions(Options[string, int]{MaxEntries: 3, Policy: PolicyLFU})
+    c.Set("a", 1)
+    c.Set("b", 2)
+    c.Set("c", 3)
+    for i := 0; i < 3; i++ { c.Get("a"); c.Get("c") }
+    c.Set("d", 4)
+    if c.Has("b") { t.Fatalf("b应被淘汰") }
+    // 关键步骤：d 频次最低，再写入 e 时应淘汰 d
+    c.Set("e", 5)
+    if _, ok := c.Get("d"); ok { t.Fatalf("d应被淘汰") }
+    if !c.Has("a") || !c.Has("c") || !c.Has("e") { t.Fatalf("高频键不应被淘汰") }
+}
+
+// TestEvictionFIFO 测试FIFO淘汰
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：读取与覆盖不影响顺序，最早写入的a应被淘汰
+func TestEvictionFIFO(t *testing.T) {
+    c := NewWithOptions(Options[string, int]{MaxEntries: 2, Policy: PolicyFIFO})
+    c.Set("a", 1)
+    c.Set("b", 2)
+    c.Get("a")
+    c.Set("a", 10)
+    c.Set("c", 3)
+    if c.Has("a") { t.Fatalf("a应被淘汰") }
+    if !c.Has("b") || !c.Has("c") { t.Fatalf("b、c应保留") }
+}
+
+// TestEvictionMaxCost 测试按成本限制容量
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：以字符串长度为成本，超出总成本时淘汰；单条超限不写入；删除与清空同步成本
+func TestEvictionMaxCost(t *testing.T) {
+    c := NewWithOptions(Options[string, string]{
+        MaxCost: 10,
+        Sizer:   func(_ string, v string) int64 { return int64(len(v)) },
+    })
+    c.Set("a", "aaaa")
+    c.Set("b", "bbbb")
+    if c.Cost() != 8 { t.Fatalf("成本应为8: %d", c.Cost()) }
+    c.Set("c", "cccc")
+    if c.Has("a") || c.Cost() != 8 { t.Fatalf("应淘汰a且成本为8: has=%v cost=%d", c.Has("a"), c.Cost()) }
+    c.Set("b", "bb")
+    if c.Cost() != 6 { t.Fatalf("覆盖后成本应为6: %d", c.Cost()) }
+    c.Set("x", "xxxxxxxxxxxx")
+    if c.Has("x") { t.Fatalf("超出上限的单条不应写入") }
+    c.Delete("b")
+    if c.Cost() != 4 { t.Fatalf("删除后成本应为4: %d", c.Cost()) }
+    c.Clear()
+    if c.Cost() != 0 || c.Len() != 0 { t.Fatalf("清空后成本与长度应为0") }
+    c.Set("y", "yy")
+    if !c.Has("y") { t.Fatalf("清空后应可继续写入") }
+}
@@ -0,0 +1,98 @@
+package kvcache
+
+import (
+    "cmp"
+    "iter"
+    "slices"
+    "strings"
+)
+
+// 本文件提供遍历能力：可提前终止的 Range、Go 1.23 range-over-func 迭代器，
+// 以及字符串键缓存的有序键与前缀扫描（如列出 "user:123:" 命名空间下的条目）。
+// 遍历基于读锁下复制的快照，回调执行时不持有内部锁，因此回调内可以安全地读写缓存。
+
+// Range 依次对每个未过期条目调用 fn，fn 返回false时提前终止
+// 参数 fn: 遍历回调，入参为键与值
+// 返回值：无
+// 关键步骤：读锁下复制条目快照后释放锁再遍历（顺序不保证）
+func (c *KVCache[K, V]) Range(fn func(key K, value V) bool) {
+    for _, e := range c.snapshotEntries() {
+        if !fn(e.Key, e.Value) {
+            return
+        }
+    }
+}
+
+// All 返回可用于 for-range 的键值迭代器
+// 参数：无
+// 返回值：iter.Seq2 迭代器（顺序不保证）
+// 关键步骤：包装 Range，支持 for k, v := range c.All() 与 break 提前终止
+func (c *KVCache[K, V]) All() iter.Seq2[K, V] {
+    return func(yield func(K, V) bool) { c.Range(yield) }
+}
+
+// SortedKeys 返回按升序排列的全部键
+// 参数 c: 键可排序的缓存
+// 返回值：升序键切片（不含已过期条目）
+func SortedKeys[K cmp.Ordered, V any](c *KVCache[K, V]) []K {
+    keys := c.Keys()
+    slices.Sort(keys)
+    return keys
+}
+
+// KeysWithPrefix 返回具有指定前缀的键（升序）
+// 参数 c: 字符串键缓存
+// 参数 prefix: 键前缀（为空时返回全部键）
+// 返回值：升序键切片
+func KeysWithPrefix[K ~string, V any](c *KVCache[K, V], prefix string) []K {
+    var keys []K
+    c.Range(func(k K, _ V) bool {
+        if strings.HasPrefix(string(k), prefix) {
+            keys = append(keys, k)
+        }
+        return true
+    })
+    slices.Sort(keys)
+    return keys
+}
+
+// ScanPrefix 按键升序迭代具有指定前缀的条目
+// 参数 c: 字符串键缓存
+// 参数 prefix: 键前缀
+// 返回值：iter.Seq2 迭代器
+// 关键步骤：基于同一份快照筛选并排序，迭代过程中不持有锁
+func ScanPrefix[K ~string, V any](c *KVCache[K, V], prefix string) iter.Seq2[K, V] {
+    return func(yield func(K, V) bool) {
+        var matched []snapshotEntry[K, V]
+        for _, e := range c.snapshotEntries() {
+            if strings.HasPrefix(string(e.Key), prefix) {
+                matched = append(matched, e)
+            }
+        }
+        slices.SortFunc(matched, func(a, b snapshotEntry[K, V]) int { return cmp.Compare(a.Key, b.Key) })
+        for _, e := range matched {
+            if !yield(e.Key, e.Value) {
+                return
+            }
+        }
+    }
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// snapshotEntries 读锁下复制全部未过期条目
+// 参数：无
+// 返回值：条目快照（含过期时间与标签）
+func (c *KVCache[K, V]) snapshotEntries() []snapshotEntry[K, V] {
+    now := c.nowNano()
+    c.mu.RLock()
+    out := make([]snapshotEntry[K, V], 0, len(c.store))
+    for _, e := range c.store {
+        if e.expired(now) {
+            continue
+        }
+        out = append(out, snapshotEntry[K, V]{Key: e.key, Value: e.value, ExpireAt: e.expireAt, Tags: e.tags})
+    }
+    c.mu.RUnlock()
+    return out
+}
@@ -0,0 +1,63 @@
+package kvcache
+
+import (
+    "slices"
+    "testing"
+)
+
+// TestRangeAndAll 测试 Range 与 range-over-func 迭代
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：完整遍历覆盖全部条目；返回false或break时提前终止；回调内可写缓存
+func TestRangeAndAll(t *testing.T) {
+    c := New[int, int]()
+    for i := 0; i < 10; i++ { c.Set(i, i*i) }
+    sum := 0
+    c.Range(func(k, v int) bool { sum += v; return true })
+    if sum != 285 { t.Fatalf("遍历求和错误: %d", sum) }
+    n := 0
+    c.Range(func(k, v int) bool { n++; return n < 3 })
+    if n != 3 { t.Fatalf("应在第3个条目终止: %d", n) }
+    n = 0
+    for k := range c.All() {
+        c.Delete(k)
+        n++
+        if n == 4 { break }
+    }
+    if c.Len() != 6 { t.Fatalf("迭代中删除后长度应为6: %d", c.Len()) }
+}
+
+// TestSortedKeysAndPrefix 测试有序键与前缀扫描
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：前缀扫描仅返回命名空间内的键并按升序输出
+func TestSortedKeysAndPrefix(t *testing.T) {
+    c := New[string, string]()
+    for _, k := range []string{"user:2:name", "user:1:name", "user:1:mail", "order:9", "user:10:name"} {
+        c.Set(k, "v-"+k)
+    }
+    if got := SortedKeys(c); !slices.IsSorted(got) || len(got) != 5 { t.Fatalf("SortedKeys错误: %v", got) }
+    want := []string{"user:1:mail", "user:1:name"}
+    if got := KeysWithPrefix(c, "user:1:"); !slices.Equal(got, want) { t.Fatalf("KeysWithPrefix错误: %v", got) }
+    var got []string
+    for k, v := range ScanPrefix(c, "user:") {
+        if v != "v-"+k { t.Fatalf("值不匹配: %s=%s", k, v) }
+        got = append(got, k)
+    }
+    if len(got) != 4 || !slices.IsSorted(got) { t.Fatalf("ScanPrefix错误: %v", got) }
+    if len(KeysWithPrefix(c, "none:")) != 0 { t.Fatalf("不存在的前缀应返回空") }
+}
+
+// TestShardedRange 测试分片缓存遍历与提前终止
+// 参数 t: 测试对象
+// 返回值：无
+func TestShardedRange(t *testing.T) {
+    s := NewSharded(ShardedOptions[int, int]{Shards: 4})
+    for i := 0; i < 20; i++ { s.Set(i, i) }
+    n := 0
+    for range s.All() { n++ }
+    if n != 20 { t.Fatalf("应遍历20个条目: %d", n) }
+    n = 0
+    s.Range(func(int, int) bool { n++; return n < 5 })
+    if n != 5 { t.Fatalf("应在第5个条目终止: %d", n) }
+}
@@ -0,0 +1,294 @@
+package kvcache
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// 本文件提供两级缓存：KVCache 作为一级（L1），较慢的 Backend 作为二级（L2）。
+// 读取时 L1 未命中回源 L2 并回填；写入支持直写（同步写 L2）与回写（先写 L1，按间隔批量刷入 L2）。
+
+// WriteMode 写入模式
+type WriteMode int
+
+const (
+    // WriteThrough 直写：先同步写入 L2，成功后再写入 L1
+    WriteThrough WriteMode = iota
+    // WriteBack 回写：仅写入 L1 并记录脏数据，由 Flush 或后台协程批量写入 L2
+    WriteBack
+)
+
+// LayeredOptions 两级缓存构造选项
+// 结构体字段解释：
+// - Mode: 写入模式（默认 WriteThrough）
+// - FlushInterval: 回写模式下后台刷写间隔；<=0 表示仅在调用 Flush/Close 时刷写
+// - OnFlushError: 后台刷写失败回调；为空时忽略（失败的键会保留到下次刷写）
+type LayeredOptions struct {
+    Mode          WriteMode
+    FlushInterval time.Duration
+    OnFlushError  func(err error)
+}
+
+// Layered 两级缓存
+// 结构体字段解释：
+// - l1: 一级缓存
+// - l2: 二级存储
+// - opt: 构造选项
+// - mu: 保护脏数据表、刷写中变更与删除标记
+// - dirty: 回写模式下尚未刷入 L2 的变更
+// - inflight: 正在写入 L2、尚未确认完成的变更（确认后才移除，期间读取仍以其为准）
+// - deleting: 直写模式下正在删除的键（计数），期间回源结果不回填 L1
+// - flushMu: 串行化刷写，避免并发刷写乱序
+type Layered[K comparable, V any] struct {
+    l1  *KVCache[K, V]
+    l2  Backend[K, V]
+    opt LayeredOptions
+
+    mu       sync.Mutex
+    dirty    map[K]pendingWrite[V]
+    inflight map[K]pendingWrite[V]
+    deleting map[K]int
+    flushMu  sync.Mutex
+
+    stopOnce sync.Once
+    stop     chan struct{}
+    done     chan struct{}
+}
+
+// pendingWrite 待刷写变更（内部使用）
+// 结构体字段解释：
+// - value: 待写入的值
+// - deleted: 是否为删除操作
+type pendingWrite[V any] struct {
+    value   V
+    deleted bool
+}
+
+// errBackendMiss L2 未命中的内部哨兵错误（用于复用 GetOrLoad 的去重逻辑）
+var errBackendMiss = errors.New("kvcache: backend miss")
+
+// NewLayered 创建两级缓存
+// 参数 l1: 一级缓存（容量、TTL 等由其自身选项决定）
+// 参数 l2: 二级存储
+// 参数 opt: 构造选项
+// 返回值：两级缓存指针
+// 关键步骤：回写模式且 FlushInterval>0 时启动后台刷写协程，需调用 Close 释放
+func NewLayered[K comparable, V any](l1 *KVCache[K, V], l2 Backend[K, V], opt LayeredOptions) *Layered[K, V] {
+    lc := &Layered[K, V]{
+        l1:       l1,
+        l2:       l2,
+        opt:      opt,
+        dirty:    make(map[K]pendingWrite[V]),
+        inflight: make(map[K]pendingWrite[V]),
+        deleting: make(map[K]int),
+        stop:     make(chan struct{}),
+        done:     make(chan struct{}),
+    }
+    if opt.Mode == WriteBack && opt.FlushInterval > 0 {
+        go lc.flusher(opt.FlushInterval)
+    } else {
+        close(lc.done)
+    }
+    return lc
+}
+
+// Get 读取键，L1 未命中时回源 L2 并回填 L1
+// 参数 key: 键
+// 返回值 value: 值；ok: 是否存在；err: L2 读取错误
+// 关键步骤：回写模式下优先查看未刷写或刷写中的变更，避免读到 L2 中的旧值；同键并发回源去重；
+// 读取 L2 后再次检查变更与删除标记，读取期间发生的写入或删除以其为准，不把旧值回填到 L1
+func (lc *Layered[K, V]) Get(key K) (value V, ok bool, err error) {
+    if v, ok := lc.l1.Get(key); ok {
+        return v, true, nil
+    }
+    lc.mu.Lock()
+    pw, pending := lc.pendingLocked(key)
+    lc.mu.Unlock()
+    if pending {
+        if pw.deleted {
+            return value, false, nil
+        }
+        return pw.value, true, nil
+    }
+    value, err = lc.l1.GetOrLoad(key, func(k K) (V, error) {
+        v, ok, err := lc.l2.Get(k)
+        if err != nil {
+            return v, err
+        }
+        lc.mu.Lock()
+        pw, pending := lc.pendingLocked(k)
+        deleting := lc.deleting[k] > 0
+        lc.mu.Unlock()
+        switch {
+        case deleting || (pending && pw.deleted) || (!pending && !ok):
+            return v, errBackendMiss
+        case pending:
+            return pw.value, nil
+        }
+        return v, nil
+    })
+    if errors.Is(err, errBackendMiss) {
+        return value, false, nil
+    }
+    if err != nil {
+        return value, false, err
+    }
+    return value, true, nil
+}
+
+// Set 写入键
+// 参数 key: 键
+// 参数 value: 值
+// 返回值：直写模式下的 L2 写入错误（失败时 L1 不更新）
+// 关键步骤：回写模式先记录脏数据再写 L1，保证 L1 中的值被淘汰后读取仍能看到该变更
+func (lc *Layered[K, V]) Set(key K, value V) error {
+    if lc.opt.Mode == WriteBack {
+        lc.mu.Lock()
+        lc.dirty[key] = pendingWrite[V]{value: value}
+        lc.mu.Unlock()
+        lc.l1.Set(key, value)
+        return nil
+    }
+    if err := lc.l2.Set(key, value); err != nil {
+        return err
+    }
+    lc.l1.Set(key, value)
+    return nil
+}
+
+// Delete 删除键
+// 参数 key: 键
+// 返回值：直写模式下的 L2 删除错误
+// 关键步骤：先记录删除（回写模式为脏数据，直写模式为删除标记）使新的回源不再回填，
+// 再等待该键进行中的回源结束后删除 L1，避免并发回源把 L2 旧值写回 L1
+func (lc *Layered[K, V]) Delete(key K) error {
+    if lc.opt.Mode == WriteBack {
+        lc.mu.Lock()
+        lc.dirty[key] = pendingWrite[V]{deleted: true}
+        lc.mu.Unlock()
+        lc.l1.waitLoad(key)
+        lc.l1.Delete(key)
+        return nil
+    }
+    lc.mu.Lock()
+    lc.deleting[key]++
+    lc.mu.Unlock()
+    defer func() {
+        lc.mu.Lock()
+        if lc.deleting[key]--; lc.deleting[key] == 0 {
+            delete(lc.deleting, key)
+        }
+        lc.mu.Unlock()
+    }()
+    if err := lc.l2.Delete(key); err != nil {
+        return err
+    }
+    lc.l1.waitLoad(key)
+    lc.l1.Delete(key)
+    return nil
+}
+
+// Pending 返回尚未刷入 L2 的变更数量
+// 参数：无
+// 返回值：脏数据与刷写中尚未确认的变更数量（同一键只计一次；直写模式恒为0）
+func (lc *Layered[K, V]) Pending() int {
+    lc.mu.Lock()
+    defer lc.mu.Unlock()
+    n := len(lc.dirty)
+    for k := range lc.inflight {
+        if _, ok := lc.dirty[k]; !ok {
+            n++
+        }
+    }
+    return n
+}
+
+// Flush 将回写模式下的脏数据写入 L2
+// 参数：无
+// 返回值：合并后的写入错误（失败的键会重新加入脏数据表，除非期间已有更新的变更）
+// 关键步骤：整体交换脏数据表并转入刷写中表后在锁外写 L2，写入期间新的变更不受阻塞；
+// 每个键在 L2 确认写入后才从刷写中表移除，期间 Get 仍返回该变更而不会读到并回填 L2 旧值
+func (lc *Layered[K, V]) Flush() error {
+    lc.flushMu.Lock()
+    defer lc.flushMu.Unlock()
+    lc.mu.Lock()
+    batch := make([]K, 0, len(lc.dirty))
+    for k, pw := range lc.dirty {
+        lc.inflight[k] = pw
+        batch = append(batch, k)
+    }
+    writes := lc.dirty
+    lc.dirty = make(map[K]pendingWrite[V])
+    lc.mu.Unlock()
+
+    var errs []error
+    for _, k := range batch {
+        pw := writes[k]
+        var err error
+        if pw.deleted {
+            err = lc.l2.Delete(k)
+        } else {
+            err = lc.l2.Set(k, pw.value)
+        }
+        lc.mu.Lock()
+        delete(lc.inflight, k)
+        if err != nil {
+            if _, newer := lc.dirty[k]; !newer {
+                lc.dirty[k] = pw
+            }
+        }
+        lc.mu.Unlock()
+        if err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// L1 返回一级缓存（便于查看统计或注册回调）
+// 参数：无
+// 返回值：一级缓存指针
+func (lc *Layered[K, V]) L1() *KVCache[K, V] { return lc.l1 }
+
+// Close 停止后台刷写协程并执行最终刷写
+// 参数：无
+// 返回值：最终刷写错误
+// 关键步骤：幂等；不会关闭 L1 与 L2，由调用方自行管理
+func (lc *Layered[K, V]) Close() error {
+    lc.stopOnce.Do(func() { close(lc.stop) })
+    <-lc.done
+    return lc.Flush()
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// pendingLocked 查找键尚未写入 L2 的变更（调用方需持有 mu）
+// 参数 key: 键
+// 返回值：变更与是否存在（脏数据优先于刷写中变更，因其更新）
+func (lc *Layered[K, V]) pendingLocked(key K) (pendingWrite[V], bool) {
+    if pw, ok := lc.dirty[key]; ok {
+        return pw, true
+    }
+    pw, ok := lc.inflight[key]
+    return pw, ok
+}
+
+// flusher 后台刷写协程主循环
+// 参数 interval: 刷写间隔
+// 返回值：无
+func (lc *Layered[K, V]) flusher(interval time.Duration) {
+    defer close(lc.done)
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ticker.C:
+            if err := lc.Flush(); err != nil && lc.opt.OnFlushError != nil {
+                lc.opt.OnFlushError(err)
+            }
+        case <-lc.stop:
+            return
+        }
+    }
+}
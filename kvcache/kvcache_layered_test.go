@@ -0,0 +1,151 @@
+package kvcache
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+// failingBackend 写入总是失败的二级存储（用于测试错误路径）
+type failingBackend struct{ *MemoryBackend[string, int] }
+
+// Set 模拟写入失败
+func (failingBackend) Set(string, int) error { return errors.New("backend down") }
+
+// TestLayeredWriteThrough 测试直写模式
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：写入同步到L2；L1未命中时从L2回源并回填；L2写失败时L1不更新
+func TestLayeredWriteThrough(t *testing.T) {
+    l2 := NewMemoryBackend[string, int]()
+    l2.Set("cold", 7)
+    lc := NewLayered(New[string, int](), Backend[string, int](l2), LayeredOptions{})
+    defer lc.Close()
+    if err := lc.Set("a", 1); err != nil { t.Fatalf("Set失败: %v", err) }
+    if v, ok, _ := l2.Get("a"); !ok || v != 1 { t.Fatalf("直写应同步写入L2") }
+    if v, ok, err := lc.Get("cold"); err != nil || !ok || v != 7 { t.Fatalf("应从L2回源: v=%d ok=%v err=%v", v, ok, err) }
+    if !lc.L1().Has("cold") { t.Fatalf("回源后应回填L1") }
+    if _, ok, err := lc.Get("missing"); ok || err != nil { t.Fatalf("L2不存在的键应返回ok=false且无错误") }
+    if err := lc.Delete("a"); err != nil || l2.Len() != 1 || lc.L1().Has("a") { t.Fatalf("删除应同时作用于L1与L2") }
+
+    bad := NewLayered(New[string, int](), Backend[string, int](failingBackend{NewMemoryBackend[string, int]()}), LayeredOptions{})
+    if err := bad.Set("x", 1); err == nil || bad.L1().Has("x") { t.Fatalf("L2写失败时应返回错误且不写L1") }
+}
+
+// TestLayeredWriteBack 测试回写模式
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：写入仅进入L1与脏表；L1被淘汰后仍可从脏表读取；Flush 后写入L2；删除标记阻止读取L2旧值
+func TestLayeredWriteBack(t *testing.T) {
+    l2 := NewMemoryBackend[string, int]()
+    l2.Set("old", 1)
+    lc := NewLayered(NewWithOptions(Options[string, int]{MaxEntries: 1}), Backend[string, int](l2), LayeredOptions{Mode: WriteBack})
+    lc.Set("a", 1)
+    lc.Set("b", 2)
+    if l2.Len() != 1 || lc.Pending() != 2 { t.Fatalf("回写模式不应立即写L2: len=%d pending=%d", l2.Len(), lc.Pending()) }
+    if v, ok, _ := lc.Get("a"); !ok || v != 1 { t.Fatalf("L1淘汰后应从脏表读取: v=%d ok=%v", v, ok) }
+    lc.Delete("old")
+    if _, ok, _ := lc.Get("old"); ok { t.Fatalf("待删除的键不应读到L2旧值") }
+    if err := lc.Flush(); err != nil { t.Fatalf("Flush失败: %v", err) }
+    if lc.Pending() != 0 || l2.Len() != 2 { t.Fatalf("Flush后L2应为a、b: len=%d", l2.Len()) }
+    if _, ok, _ := l2.Get("old"); ok { t.Fatalf("Flush后应删除L2中的old") }
+}
+
+// slowBackend 读写可被阻塞的二级存储（用于测试并发时序）
+// 结构体字段解释：
+// - entered: 进入阻塞点时发送信号
+// - release: 关闭后放行
+// - blockGet, blockSet: 是否阻塞 Get（先读出旧值再阻塞）与 Set（阻塞后再写入）
+type slowBackend struct {
+    *MemoryBackend[string, int]
+    entered  chan struct{}
+    release  chan struct{}
+    blockGet bool
+    blockSet bool
+}
+
+// Get 先读取当前值再按需阻塞，模拟读到旧值后返回较慢
+func (b *slowBackend) Get(k string) (int, bool, error) {
+    v, ok, err := b.MemoryBackend.Get(k)
+    if b.blockGet {
+        b.entered <- struct{}{}
+        <-b.release
+    }
+    return v, ok, err
+}
+
+// Set 按需阻塞后再写入，模拟较慢的写入
+func (b *slowBackend) Set(k string, v int) error {
+    if b.blockSet {
+        b.entered <- struct{}{}
+        <-b.release
+    }
+    return b.MemoryBackend.Set(k, v)
+}
+
+// TestLayeredFlushInFlightVisible 测试刷写进行中读取不会回填 L2 旧值
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：L1 容量为1使 a 被淘汰；L2 写入阻塞期间读取 a 应得到新值，刷写完成后仍为新值
+func TestLayeredFlushInFlightVisible(t *testing.T) {
+    l2 := &slowBackend{MemoryBackend: NewMemoryBackend[string, int](), entered: make(chan struct{}, 2), release: make(chan struct{}), blockSet: true}
+    l2.MemoryBackend.Set("a", 1)
+    lc := NewLayered(NewWithOptions(Options[string, int]{MaxEntries: 1}), Backend[string, int](l2), LayeredOptions{Mode: WriteBack})
+    lc.Set("a", 2)
+    lc.Set("b", 3)
+    flushed := make(chan error, 1)
+    go func() { flushed <- lc.Flush() }()
+    <-l2.entered
+    if v, ok, err := lc.Get("a"); err != nil || !ok || v != 2 { t.Fatalf("刷写中读取应为新值: v=%d ok=%v err=%v", v, ok, err) }
+    if lc.Pending() != 2 { t.Fatalf("刷写中的变更应计入Pending: %d", lc.Pending()) }
+    close(l2.release)
+    if err := <-flushed; err != nil { t.Fatalf("Flush失败: %v", err) }
+    if v, ok, _ := lc.Get("a"); !ok || v != 2 { t.Fatalf("刷写后读取应为新值: v=%d ok=%v", v, ok) }
+}
+
+// TestLayeredDeleteDuringLoad 测试直写删除与并发回源的时序
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：回源读到旧值后阻塞，期间执行删除；删除完成后 L1 不应残留旧值
+func TestLayeredDeleteDuringLoad(t *testing.T) {
+    l2 := &slowBackend{MemoryBackend: NewMemoryBackend[string, int](), entered: make(chan struct{}, 1), release: make(chan struct{}), blockGet: true}
+    l2.MemoryBackend.Set("a", 1)
+    lc := NewLayered(New[string, int](), Backend[string, int](l2), LayeredOptions{})
+    loaded := make(chan struct{})
+    go func() { lc.Get("a"); close(loaded) }()
+    <-l2.entered
+    deleted := make(chan error, 1)
+    go func() { deleted <- lc.Delete("a") }()
+    time.Sleep(10 * time.Millisecond)
+    close(l2.release)
+    if err := <-deleted; err != nil { t.Fatalf("Delete失败: %v", err) }
+    <-loaded
+    if lc.L1().Has("a") { t.Fatalf("删除后L1不应残留回源旧值") }
+    l2.blockGet = false
+    if _, ok, _ := lc.Get("a"); ok { t.Fatalf("删除后不应再读到a") }
+}
+
+// TestLayeredBackgroundFlushAndDir 测试后台刷写与目录存储
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：目录存储支持含特殊字符的键；后台协程定期刷写；Close 执行最终刷写；
+// 读取目录存储前调用一次 Flush，借助刷写互斥等待后台刷写完整结束
+func TestLayeredBackgroundFlushAndDir(t *testing.T) {
+    dir, err := NewDirBackend[string](t.TempDir(), JSONCodec)
+    if err != nil { t.Fatalf("创建目录存储失败: %v", err) }
+    lc := NewLayered(New[string, string](), Backend[string, string](dir), LayeredOptions{Mode: WriteBack, FlushInterval: 5 * time.Millisecond})
+    lc.Set("a/b:c", "v1")
+    deadline := time.Now().Add(time.Second)
+    for lc.Pending() != 0 {
+        if time.Now().After(deadline) { t.Fatalf("后台刷写未执行") }
+        time.Sleep(2 * time.Millisecond)
+    }
+    if err := lc.Flush(); err != nil { t.Fatalf("Flush失败: %v", err) }
+    if v, ok, err := dir.Get("a/b:c"); err != nil || !ok || v != "v1" { t.Fatalf("目录存储读取失败: v=%q ok=%v err=%v", v, ok, err) }
+    lc.Set("k", "v2")
+    if err := lc.Close(); err != nil { t.Fatalf("Close失败: %v", err) }
+    if _, ok, _ := dir.Get("k"); !ok { t.Fatalf("Close应执行最终刷写") }
+    if err := dir.Delete("k"); err != nil { t.Fatalf("删除失败: %v", err) }
+    if err := dir.Delete("k"); err != nil { t.Fatalf("重复删除不应报错: %v", err) }
+    if _, err := NewDirBackend[int]("", nil); err == nil { t.Fatalf("空目录应返回错误") }
+}
@@ -0,0 +1,156 @@
+package kvcache
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+)
+
+// 本文件提供回源加载：缓存未命中时调用加载函数，并对同一键的并发加载去重（singleflight 语义），
+// 避免缓存击穿时大量请求同时打到后端；可选缓存失败结果一段时间（负缓存）。
+
+// loadCall 一次进行中的加载调用（内部使用）
+// 结构体字段解释：
+// - wg: 等待加载完成
+// - val, err: 加载结果，由所有等待者共享
+type loadCall[V any] struct {
+    wg  sync.WaitGroup
+    val V
+    err error
+}
+
+// errLoadGoexit 加载函数调用 runtime.Goexit 退出时返回给等待者的错误
+var errLoadGoexit = errors.New("kvcache: 加载函数未返回（调用了 runtime.Goexit）")
+
+// negativeEntry 负缓存条目（内部使用）
+// 结构体字段解释：
+// - err: 加载失败的错误
+// - expireAt: 过期时间（UnixNano）
+type negativeEntry struct {
+    err      error
+    expireAt int64
+}
+
+// GetOrLoad 获取键对应的值，未命中时调用加载函数回源
+// 参数 key: 键
+// 参数 loader: 加载函数，返回值与错误
+// 返回值 value: 命中或加载得到的值
+// 返回值 err: 加载错误（命中负缓存时返回缓存的错误）
+// 关键步骤：
+// 1) 先查缓存与负缓存；
+// 2) 同一键同时仅运行一个加载函数，其余调用等待并共享结果/错误；
+// 3) 加载成功按默认TTL写入缓存，失败且配置了 NegativeTTL 时缓存该错误。
+func (c *KVCache[K, V]) GetOrLoad(key K, loader func(K) (V, error)) (value V, err error) {
+    if v, ok := c.Get(key); ok {
+        return v, nil
+    }
+    now := c.nowNano()
+    c.mu.RLock()
+    ne, ok := c.negatives[key]
+    if ok && now < ne.expireAt {
+        err = ne.err
+        c.mu.RUnlock()
+        return value, err
+    }
+    c.mu.RUnlock()
+
+    c.loadMu.Lock()
+    if call, ok := c.loads[key]; ok {
+        // 关键步骤：已有进行中的加载，等待其结果
+        c.loadMu.Unlock()
+        call.wg.Wait()
+        return call.val, call.err
+    }
+    call := &loadCall[V]{}
+    call.wg.Add(1)
+    c.loads[key] = call
+    c.loadMu.Unlock()
+
+    c.runLoad(key, loader, call)
+    return call.val, call.err
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// runLoad 执行加载函数并发布结果
+// 参数 key: 键
+// 参数 loader: 加载函数
+// 参数 call: 本次加载调用
+// 返回值：无
+// 关键步骤：先写缓存再移除调用记录，保证后续调用要么命中缓存要么等待本次结果；
+// 加载期间若已有其他写入，以该写入为准，避免用回源得到的旧值覆盖新值；
+// 唤醒等待者放在 defer 中，加载函数、Sizer 或 OnSet 回调 panic 时向等待者返回错误并在当前协程继续 panic，
+// 加载函数调用 runtime.Goexit 时同样返回错误（此时 recover 为 nil，不能再次 panic）
+func (c *KVCache[K, V]) runLoad(key K, loader func(K) (V, error), call *loadCall[V]) {
+    completed := false
+    defer func() {
+        if !completed {
+            var zero V
+            call.val = zero
+            if r := recover(); r != nil {
+                call.err = fmt.Errorf("kvcache: 加载函数发生panic: %v", r)
+                c.finishLoad(key, call)
+                panic(r)
+            }
+            call.err = errLoadGoexit
+        }
+        c.finishLoad(key, call)
+    }()
+    call.val, call.err = loader(key)
+    if call.err == nil {
+        call.val = c.storeLoaded(key, call.val)
+    } else if c.negativeTTL > 0 {
+        c.mu.Lock()
+        c.negatives[key] = &negativeEntry{err: call.err, expireAt: c.nowNano() + int64(c.negativeTTL)}
+        c.mu.Unlock()
+    }
+    completed = true
+}
+
+// storeLoaded 写入加载结果（键已被并发写入时保留现有值）
+// 参数 key: 键
+// 参数 value: 加载得到的值
+// 返回值：最终缓存中的值
+// 关键步骤：Sizer 在持锁期间 panic 时释放写锁并丢弃未派发事件，避免缓存永久锁死
+func (c *KVCache[K, V]) storeLoaded(key K, value V) V {
+    now := c.nowNano()
+    c.mu.Lock()
+    locked := true
+    defer func() {
+        if locked {
+            c.pending = nil
+            c.mu.Unlock()
+        }
+    }()
+    if e, ok := c.liveLocked(key, now); ok {
+        value = e.value
+    } else {
+        c.setLocked(key, value, c.expireAtFrom(now, c.defaultTTL), nil)
+    }
+    locked = false
+    c.unlockAndNotify()
+    return value
+}
+
+// waitLoad 等待指定键进行中的加载（含回填）完成
+// 参数 key: 键
+// 返回值：无
+func (c *KVCache[K, V]) waitLoad(key K) {
+    c.loadMu.Lock()
+    call := c.loads[key]
+    c.loadMu.Unlock()
+    if call != nil {
+        call.wg.Wait()
+    }
+}
+
+// finishLoad 移除调用记录并唤醒等待者
+// 参数 key: 键
+// 参数 call: 本次加载调用
+// 返回值：无
+func (c *KVCache[K, V]) finishLoad(key K, call *loadCall[V]) {
+    c.loadMu.Lock()
+    delete(c.loads, key)
+    c.loadMu.Unlock()
+    call.wg.Done()
+}
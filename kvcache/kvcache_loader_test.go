@@ -0,0 +1,141 @@
+package kvcache
+
+import (
+    "errors"
+    "runtime"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// TestGetOrLoadDedup 测试并发未命中时加载函数只执行一次
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：加载函数阻塞直至全部协程发起调用，断言调用次数为1且所有协程拿到相同结果
+func TestGetOrLoadDedup(t *testing.T) {
+    c := New[string, int]()
+    var calls int32
+    release := make(chan struct{})
+    loader := func(k string) (int, error) {
+        atomic.AddInt32(&calls, 1)
+        <-release
+        return len(k), nil
+    }
+    const workers = 16
+    var wg sync.WaitGroup
+    wg.Add(workers)
+    for i := 0; i < workers; i++ {
+        go func() {
+            defer wg.Done()
+            v, err := c.GetOrLoad("hello", loader)
+            if err != nil || v != 5 { t.Errorf("加载结果错误: v=%d err=%v", v, err) }
+        }()
+    }
+    // 关键步骤：等待首个加载开始后再放行，确保其余调用进入等待
+    for atomic.LoadInt32(&calls) == 0 { time.Sleep(time.Millisecond) }
+    time.Sleep(10 * time.Millisecond)
+    close(release)
+    wg.Wait()
+    if n := atomic.LoadInt32(&calls); n != 1 { t.Fatalf("加载函数应只执行1次: %d", n) }
+    if v, ok := c.Get("hello"); !ok || v != 5 { t.Fatalf("加载结果应写入缓存") }
+}
+
+// TestGetOrLoadNegative 测试失败结果的负缓存
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：负缓存有效期内不再回源；Set 后负缓存失效；过期后重新回源
+func TestGetOrLoadNegative(t *testing.T) {
+    clk := newFakeClock()
+    c := NewWithOptions(Options[string, int]{NegativeTTL: time.Minute})
+    c.clock = clk.Now
+    errNotFound := errors.New("not found")
+    calls := 0
+    loader := func(string) (int, error) { calls++; return 0, errNotFound }
+
+    if _, err := c.GetOrLoad("x", loader); !errors.Is(err, errNotFound) { t.Fatalf("应返回加载错误: %v", err) }
+    if _, err := c.GetOrLoad("x", loader); !errors.Is(err, errNotFound) || calls != 1 {
+        t.Fatalf("负缓存期间不应回源: calls=%d err=%v", calls, err)
+    }
+    clk.Advance(2 * time.Minute)
+    c.GetOrLoad("x", loader)
+    if calls != 2 { t.Fatalf("负缓存过期后应重新回源: %d", calls) }
+    c.Set("x", 7)
+    if v, err := c.GetOrLoad("x", loader); err != nil || v != 7 { t.Fatalf("Set后应命中缓存: v=%d err=%v", v, err) }
+
+    // 关键步骤：未配置 NegativeTTL 时每次都回源
+    c2 := New[string, int]()
+    calls = 0
+    c2.GetOrLoad("y", loader)
+    c2.GetOrLoad("y", loader)
+    if calls != 2 { t.Fatalf("未开启负缓存时应每次回源: %d", calls) }
+}
+
+// TestGetOrLoadPanic 测试加载函数panic时不会阻塞后续调用
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：首次调用panic被捕获后，再次调用应能正常加载
+func TestGetOrLoadPanic(t *testing.T) {
+    c := New[int, int]()
+    func() {
+        defer func() {
+            if recover() == nil { t.Fatalf("加载函数panic应向调用方传播") }
+        }()
+        c.GetOrLoad(1, func(int) (int, error) { panic("boom") })
+    }()
+    if v, err := c.GetOrLoad(1, func(int) (int, error) { return 9, nil }); err != nil || v != 9 {
+        t.Fatalf("panic后再次加载失败: v=%d err=%v", v, err)
+    }
+}
+
+// TestGetOrLoadAbnormalExit 测试加载过程异常退出时等待者不会永久阻塞
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：OnSet 回调 panic 与加载函数调用 runtime.Goexit 两种情况下，等待者都应拿到错误，调用记录被移除
+func TestGetOrLoadAbnormalExit(t *testing.T) {
+    run := func(c *KVCache[int, int], loader func(int) (int, error)) {
+        t.Helper()
+        started := make(chan struct{})
+        release := make(chan struct{})
+        wrapped := func(k int) (int, error) {
+            close(started)
+            <-release
+            return loader(k)
+        }
+        callerDone := make(chan struct{})
+        go func() {
+            defer close(callerDone)
+            defer func() { recover() }()
+            c.GetOrLoad(1, wrapped)
+        }()
+        <-started
+        waitErr := make(chan error, 1)
+        go func() {
+            _, err := c.GetOrLoad(1, func(int) (int, error) { return 0, nil })
+            waitErr <- err
+        }()
+        time.Sleep(10 * time.Millisecond)
+        close(release)
+        select {
+        case err := <-waitErr:
+            if err == nil { t.Fatalf("等待者应收到错误") }
+        case <-time.After(time.Second):
+            t.Fatalf("等待者被永久阻塞")
+        }
+        <-callerDone
+        c.loadMu.Lock()
+        n := len(c.loads)
+        c.loadMu.Unlock()
+        if n != 0 { t.Fatalf("调用记录未移除: %d", n) }
+    }
+
+    c := New[int, int]()
+    c.OnSet(func(int, int) { panic("hook") })
+    run(c, func(int) (int, error) { return 1, nil })
+
+    c2 := New[int, int]()
+    run(c2, func(int) (int, error) { runtime.Goexit(); return 0, nil })
+    if v, err := c2.GetOrLoad(1, func(int) (int, error) { return 3, nil }); err != nil || v != 3 {
+        t.Fatalf("Goexit后再次加载失败: v=%d err=%v", v, err)
+    }
+}
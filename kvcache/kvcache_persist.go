@@ -0,0 +1,174 @@
+package kvcache
+
+import (
+    "encoding/gob"
+    "encoding/json"
+    "errors"
+    "io"
+    "os"
+    "path/filepath"
+)
+
+// 本文件提供缓存持久化：将当前条目（含过期时间）编码写出，并在启动时恢复，避免重启后缓存全冷。
+// 编码方式通过 Codec 接口选择，内置 gob 与 JSON 两种实现；写入文件时使用临时文件+重命名保证原子性。
+
+// Codec 快照编解码器
+// 方法说明：
+// - Encode: 将 v 编码写入 w
+// - Decode: 从 r 解码到 v（v 为指针）
+type Codec interface {
+    Encode(w io.Writer, v any) error
+    Decode(r io.Reader, v any) error
+}
+
+// GobCodec 使用 encoding/gob 的编解码器（默认；值为接口类型时需提前 gob.Register 具体类型）
+var GobCodec Codec = gobCodec{}
+
+// JSONCodec 使用 encoding/json 的编解码器（可读性好，便于排查）
+var JSONCodec Codec = jsonCodec{}
+
+// snapshotVersion 快照格式版本号
+const snapshotVersion = 1
+
+// snapshot 快照文件结构（字段需导出以便编码）
+// 结构体字段解释：
+// - Version: 格式版本
+// - Entries: 条目列表
+type snapshot[K comparable, V any] struct {
+    Version int
+    Entries []snapshotEntry[K, V]
+}
+
+// snapshotEntry 快照条目
+// 结构体字段解释：
+// - Key, Value: 键与值
+// - ExpireAt: 绝对过期时间（UnixNano，0 表示永不过期）
+// - Tags: 条目标签
+type snapshotEntry[K comparable, V any] struct {
+    Key      K
+    Value    V
+    ExpireAt int64    `json:",omitempty"`
+    Tags     []string `json:",omitempty"`
+}
+
+// SaveTo 将当前缓存内容写出为快照
+// 参数 w: 输出流
+// 返回值：编码或写入错误
+// 关键步骤：读锁下复制未过期条目（保留绝对过期时间与标签），释放锁后再编码，避免长时间阻塞读写
+func (c *KVCache[K, V]) SaveTo(w io.Writer) error {
+    snap := snapshot[K, V]{Version: snapshotVersion, Entries: c.snapshotEntries()}
+    return c.codecOrDefault().Encode(w, &snap)
+}
+
+// LoadFrom 从快照恢复缓存内容
+// 参数 r: 输入流
+// 返回值：解码错误或版本不支持错误
+// 关键步骤：解码后跳过已过期条目，其余按原过期时间写入（覆盖同名键，遵循容量限制并触发写入事件）
+func (c *KVCache[K, V]) LoadFrom(r io.Reader) error {
+    var snap snapshot[K, V]
+    if err := c.codecOrDefault().Decode(r, &snap); err != nil {
+        return err
+    }
+    if snap.Version != snapshotVersion {
+        return errors.New("kvcache: 不支持的快照版本")
+    }
+    c.loadEntries(snap.Entries)
+    return nil
+}
+
+// SaveToFile 将快照原子写入文件
+// 参数 path: 目标文件路径
+// 返回值：错误信息
+// 关键步骤：在同目录创建临时文件写入并 Sync，成功后重命名覆盖目标文件，失败时清理临时文件
+func (c *KVCache[K, V]) SaveToFile(path string) error {
+    return writeFileAtomic(path, c.SaveTo)
+}
+
+// LoadFromFile 从快照文件恢复缓存内容
+// 参数 path: 快照文件路径
+// 返回值：错误信息（文件不存在时可用 errors.Is(err, os.ErrNotExist) 判断）
+func (c *KVCache[K, V]) LoadFromFile(path string) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    return c.LoadFrom(f)
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// codecOrDefault 返回配置的编解码器（默认 gob）
+// 参数：无
+// 返回值：编解码器
+func (c *KVCache[K, V]) codecOrDefault() Codec {
+    if c.codec != nil {
+        return c.codec
+    }
+    return GobCodec
+}
+
+// loadEntries 写入快照条目（跳过已过期条目）
+// 参数 entries: 快照条目
+// 返回值：无
+func (c *KVCache[K, V]) loadEntries(entries []snapshotEntry[K, V]) {
+    now := c.nowNano()
+    c.mu.Lock()
+    for _, se := range entries {
+        if se.ExpireAt > 0 && now >= se.ExpireAt {
+            continue
+        }
+        c.setLocked(se.Key, se.Value, se.ExpireAt, se.Tags)
+    }
+    c.unlockAndNotify()
+}
+
+// autoSnapshot 执行一次自动快照
+// 参数：无
+// 返回值：无
+// 关键步骤：写入失败时交给 OnSnapshotError 处理（未设置则忽略）
+func (c *KVCache[K, V]) autoSnapshot() {
+    if err := c.SaveToFile(c.snapshotPath); err != nil && c.onSnapshotError != nil {
+        c.onSnapshotError(err)
+    }
+}
+
+// writeFileAtomic 通过临时文件+重命名原子写入文件
+// 参数 path: 目标文件路径
+// 参数 write: 写入内容的函数
+// 返回值：错误信息
+// 关键步骤：临时文件与目标同目录以保证 Rename 原子；失败时关闭并删除临时文件
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+    tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+    if err != nil {
+        return err
+    }
+    defer func() {
+        if err != nil {
+            tmp.Close()
+            os.Remove(tmp.Name())
+        }
+    }()
+    if err = write(tmp); err != nil {
+        return err
+    }
+    if err = tmp.Sync(); err != nil {
+        return err
+    }
+    if err = tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
+
+// gobCodec gob 编解码实现
+type gobCodec struct{}
+
+func (gobCodec) Encode(w io.Writer, v any) error { return gob.NewEncoder(w).Encode(v) }
+func (gobCodec) Decode(r io.Reader, v any) error { return gob.NewDecoder(r).Decode(v) }
+
+// jsonCodec JSON 编解码实现
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+func (jsonCodec) Decode(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) }
@@ -0,0 +1,72 @@
+package kvcache
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+// TestSaveLoadRoundTrip 测试快照写出与恢复（gob 与 JSON）
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：恢复后值与剩余TTL保持一致，已过期条目不写出
+func TestSaveLoadRoundTrip(t *testing.T) {
+    for _, codec := range []Codec{GobCodec, JSONCodec} {
+        clk := newFakeClock()
+        src := NewWithOptions(Options[string, int]{Codec: codec})
+        src.clock = clk.Now
+        src.Set("a", 1)
+        src.SetWithTTL("b", 2, time.Minute)
+        src.SetWithTTL("c", 3, time.Second)
+        clk.Advance(2 * time.Second)
+        var buf bytes.Buffer
+        if err := src.SaveTo(&buf); err != nil { t.Fatalf("SaveTo失败: %v", err) }
+
+        dst := NewWithOptions(Options[string, int]{Codec: codec})
+        dst.clock = clk.Now
+        if err := dst.LoadFrom(&buf); err != nil { t.Fatalf("LoadFrom失败: %v", err) }
+        if dst.Len() != 2 || dst.Has("c") { t.Fatalf("恢复条目不正确: %v", dst.Keys()) }
+        if v, _ := dst.Get("a"); v != 1 { t.Fatalf("a恢复值错误: %d", v) }
+        if ttl, ok := dst.TTL("b"); !ok || ttl != time.Minute-2*time.Second { t.Fatalf("b剩余TTL错误: %v", ttl) }
+    }
+}
+
+// TestSaveToFileAtomic 测试原子写入文件与文件恢复
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：写入后目录中仅保留目标文件；文件不存在时返回 os.ErrNotExist
+func TestSaveToFileAtomic(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "cache.snap")
+    c := New[int, string]()
+    c.Set(1, "one")
+    if err := c.SaveToFile(path); err != nil { t.Fatalf("SaveToFile失败: %v", err) }
+    entries, _ := os.ReadDir(dir)
+    if len(entries) != 1 { t.Fatalf("目录中应仅有快照文件: %d", len(entries)) }
+
+    r := New[int, string]()
+    if err := r.LoadFromFile(path); err != nil { t.Fatalf("LoadFromFile失败: %v", err) }
+    if v, ok := r.Get(1); !ok || v != "one" { t.Fatalf("恢复值错误: %q", v) }
+    if err := r.LoadFromFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) { t.Fatalf("缺失文件应返回不存在错误: %v", err) }
+    if err := r.LoadFrom(bytes.NewReader([]byte("garbage"))); err == nil { t.Fatalf("损坏数据应返回错误") }
+}
+
+// TestAutoSnapshot 测试定时自动快照与关闭时的最终快照
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：Close 后快照文件应包含最新写入
+func TestAutoSnapshot(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "auto.snap")
+    c := NewWithOptions(Options[string, string]{
+        SnapshotPath:     path,
+        SnapshotInterval: time.Hour,
+        OnSnapshotError:  func(err error) { t.Errorf("自动快照失败: %v", err) },
+    })
+    c.Set("k", "v")
+    c.Close()
+    r := New[string, string]()
+    if err := r.LoadFromFile(path); err != nil { t.Fatalf("加载自动快照失败: %v", err) }
+    if v, _ := r.Get("k"); v != "v" { t.Fatalf("自动快照内容错误: %q", v) }
+}
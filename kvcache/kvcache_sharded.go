@@ -0,0 +1,457 @@
+package kvcache
+
+import (
+    "errors"
+    "hash/maphash"
+    "io"
+    "iter"
+    "os"
+    "sync"
+    "time"
+)
+
+// 本文件提供分片缓存：按键哈希将数据分散到多个 KVCache，降低单把读写锁在高并发写入下的争用。
+
+// ShardedCache 分片键值缓存器（泛型）
+// 结构体字段解释：
+// - shards: 分片数组（长度为2的幂）
+// - mask: 分片下标掩码（len(shards)-1）
+// - hasher: 键哈希函数
+// - codec, snapshotPath, onSnapshotError: 快照编解码器与自动快照设置（由分片缓存统一写出单个文件）
+// - stopOnce/stop/done: 后台协程控制
+type ShardedCache[K comparable, V any] struct {
+    shards []*KVCache[K, V]
+    mask   uint64
+    hasher func(K) uint64
+
+    codec           Codec
+    snapshotPath    string
+    onSnapshotError func(error)
+
+    stopOnce sync.Once
+    stop     chan struct{}
+    done     chan struct{}
+}
+
+// ShardedOptions 分片缓存构造选项
+// 结构体字段解释：
+// - Options: 分片通用选项；MaxEntries/MaxCost 为总量，按分片数均分；后台清理与自动快照由分片缓存统一调度
+// - Shards: 分片数量（向上取整到2的幂；<=0 时默认16）
+// - Hasher: 自定义键哈希函数；为空时使用 hash/maphash.Comparable
+type ShardedOptions[K comparable, V any] struct {
+    Options[K, V]
+    Shards int
+    Hasher func(key K) uint64
+}
+
+// NewSharded 创建分片缓存器
+// 参数 opt: 分片构造选项
+// 返回值：分片缓存器指针
+// 关键步骤：分片数取2的幂便于掩码定位；容量上限均分到各分片；后台清理与自动快照由分片缓存统一调度
+// （分片自身不启动后台协程，快照覆盖全部分片写入同一文件），需调用 Close 释放
+func NewSharded[K comparable, V any](opt ShardedOptions[K, V]) *ShardedCache[K, V] {
+    n := 16
+    if opt.Shards > 0 {
+        n = 1
+        for n < opt.Shards {
+            n <<= 1
+        }
+    }
+    hasher := opt.Hasher
+    if hasher == nil {
+        seed := maphash.MakeSeed()
+        hasher = func(k K) uint64 { return maphash.Comparable(seed, k) }
+    }
+    per := opt.Options
+    per.CleanupInterval = 0
+    per.SnapshotPath, per.SnapshotInterval, per.OnSnapshotError = "", 0, nil
+    if per.MaxEntries > 0 {
+        per.MaxEntries = (per.MaxEntries + n - 1) / n
+    }
+    if per.MaxCost > 0 {
+        per.MaxCost = (per.MaxCost + int64(n) - 1) / int64(n)
+    }
+    s := &ShardedCache[K, V]{
+        shards:          make([]*KVCache[K, V], n),
+        mask:            uint64(n - 1),
+        hasher:          hasher,
+        codec:           opt.Codec,
+        snapshotPath:    opt.SnapshotPath,
+        onSnapshotError: opt.OnSnapshotError,
+        stop:            make(chan struct{}),
+        done:            make(chan struct{}),
+    }
+    for i := range s.shards {
+        s.shards[i] = NewWithOptions(per)
+    }
+    snapshotInterval := opt.SnapshotInterval
+    if opt.SnapshotPath == "" {
+        snapshotInterval = 0
+    }
+    if opt.CleanupInterval > 0 || snapshotInterval > 0 {
+        go s.janitor(opt.CleanupInterval, snapshotInterval)
+    } else {
+        close(s.done)
+    }
+    return s
+}
+
+// ShardCount 返回分片数量
+// 参数：无
+// 返回值：分片数量
+func (s *ShardedCache[K, V]) ShardCount() int { return len(s.shards) }
+
+// Set 设置键的值（覆盖同名键）
+// 参数 key: 键
+// 参数 value: 值
+// 返回值：无
+func (s *ShardedCache[K, V]) Set(key K, value V) { s.shard(key).Set(key, value) }
+
+// SetWithTTL 设置键的值并指定过期时长
+// 参数 key: 键
+// 参数 value: 值
+// 参数 ttl: 过期时长；<=0 表示永不过期
+// 返回值：无
+func (s *ShardedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+    s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+// SetWithTags 设置键的值并附加标签
+// 参数 key: 键
+// 参数 value: 值
+// 参数 tags: 标签列表
+// 返回值：无
+func (s *ShardedCache[K, V]) SetWithTags(key K, value V, tags ...string) {
+    s.shard(key).SetWithTags(key, value, tags...)
+}
+
+// InvalidateTag 删除全部分片中带有指定标签的条目
+// 参数 tag: 标签
+// 返回值 n: 删除数量
+func (s *ShardedCache[K, V]) InvalidateTag(tag string) (n int) {
+    for _, sh := range s.shards {
+        n += sh.InvalidateTag(tag)
+    }
+    return n
+}
+
+// Get 获取键对应的值
+// 参数 key: 键
+// 返回值 value: 键对应的值；ok: 是否存在
+func (s *ShardedCache[K, V]) Get(key K) (value V, ok bool) { return s.shard(key).Get(key) }
+
+// GetOrLoad 获取键对应的值，未命中时调用加载函数回源（同键并发加载去重）
+// 参数 key: 键
+// 参数 loader: 加载函数
+// 返回值 value: 命中或加载得到的值；err: 加载错误
+func (s *ShardedCache[K, V]) GetOrLoad(key K, loader func(K) (V, error)) (value V, err error) {
+    return s.shard(key).GetOrLoad(key, loader)
+}
+
+// GetOrSet 键存在时返回现有值，否则写入给定值
+// 参数 key: 键
+// 参数 value: 键不存在时写入的值
+// 返回值 actual: 现有值或新值；loaded: 是否为已存在的值
+func (s *ShardedCache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+    return s.shard(key).GetOrSet(key, value)
+}
+
+// CompareAndSwap 当前值与旧值相等时替换为新值
+// 参数 key: 键
+// 参数 old, new: 期望的当前值与替换后的值
+// 参数 eq: 值相等判断函数（为nil时使用 ==）
+// 返回值 swapped: 是否完成替换
+func (s *ShardedCache[K, V]) CompareAndSwap(key K, old, new V, eq func(a, b V) bool) (swapped bool) {
+    return s.shard(key).CompareAndSwap(key, old, new, eq)
+}
+
+// Update 以回调函数原子地更新键的值
+// 参数 key: 键
+// 参数 fn: 更新函数，返回新值与是否保留
+// 返回值 value: 更新后的值；ok: 键在更新后是否存在
+func (s *ShardedCache[K, V]) Update(key K, fn func(old V, ok bool) (V, bool)) (value V, ok bool) {
+    return s.shard(key).Update(key, fn)
+}
+
+// UpdateWithTTL 以回调函数原子地更新键的值并重置过期时间
+// 参数 key: 键
+// 参数 ttl: 新的过期时长；<=0 表示永不过期
+// 参数 fn: 更新函数，返回新值与是否保留
+// 返回值 value: 更新后的值；ok: 键在更新后是否存在
+func (s *ShardedCache[K, V]) UpdateWithTTL(key K, ttl time.Duration, fn func(old V, ok bool) (V, bool)) (value V, ok bool) {
+    return s.shard(key).UpdateWithTTL(key, ttl, fn)
+}
+
+// Delete 删除指定键
+// 参数 key: 键
+// 返回值 deleted: 键是否存在
+func (s *ShardedCache[K, V]) Delete(key K) (deleted bool) { return s.shard(key).Delete(key) }
+
+// Has 判断是否存在指定键
+// 参数 key: 键
+// 返回值 exists: 存在且未过期返回true
+func (s *ShardedCache[K, V]) Has(key K) (exists bool) { return s.shard(key).Has(key) }
+
+// TTL 返回键的剩余存活时长
+// 参数 key: 键
+// 返回值 ttl: 剩余时长（永不过期为0）；ok: 是否存在
+func (s *ShardedCache[K, V]) TTL(key K) (ttl time.Duration, ok bool) { return s.shard(key).TTL(key) }
+
+// Len 返回全部分片的键数量之和
+// 参数：无
+// 返回值 n: 键数量
+func (s *ShardedCache[K, V]) Len() (n int) {
+    for _, sh := range s.shards {
+        n += sh.Len()
+    }
+    return n
+}
+
+// Cost 返回全部分片的总成本
+// 参数：无
+// 返回值 cost: 总成本
+func (s *ShardedCache[K, V]) Cost() (cost int64) {
+    for _, sh := range s.shards {
+        cost += sh.Cost()
+    }
+    return cost
+}
+
+// Clear 清空全部分片
+// 参数：无
+// 返回值：无
+func (s *ShardedCache[K, V]) Clear() {
+    for _, sh := range s.shards {
+        sh.Clear()
+    }
+}
+
+// Keys 返回全部分片的键
+// 参数：无
+// 返回值 keys: 键切片（顺序不保证）
+func (s *ShardedCache[K, V]) Keys() (keys []K) {
+    for _, sh := range s.shards {
+        keys = append(keys, sh.Keys()...)
+    }
+    return keys
+}
+
+// Values 返回全部分片的值
+// 参数：无
+// 返回值 values: 值切片（顺序不保证）
+func (s *ShardedCache[K, V]) Values() (values []V) {
+    for _, sh := range s.shards {
+        values = append(values, sh.Values()...)
+    }
+    return values
+}
+
+// Range 依次遍历全部分片的未过期条目，fn 返回false时提前终止
+// 参数 fn: 遍历回调
+// 返回值：无
+func (s *ShardedCache[K, V]) Range(fn func(key K, value V) bool) {
+    stopped := false
+    for _, sh := range s.shards {
+        sh.Range(func(k K, v V) bool {
+            stopped = !fn(k, v)
+            return !stopped
+        })
+        if stopped {
+            return
+        }
+    }
+}
+
+// All 返回全部分片的键值迭代器
+// 参数：无
+// 返回值：iter.Seq2 迭代器（顺序不保证）
+func (s *ShardedCache[K, V]) All() iter.Seq2[K, V] {
+    return func(yield func(K, V) bool) { s.Range(yield) }
+}
+
+// DeleteExpired 回收全部分片中的过期条目
+// 参数：无
+// 返回值 n: 回收数量
+func (s *ShardedCache[K, V]) DeleteExpired() (n int) {
+    for _, sh := range s.shards {
+        n += sh.DeleteExpired()
+    }
+    return n
+}
+
+// Stats 返回全部分片合并后的统计快照
+// 参数：无
+// 返回值：统计快照
+func (s *ShardedCache[K, V]) Stats() (st Stats) {
+    for _, sh := range s.shards {
+        st = st.Add(sh.Stats())
+    }
+    return st
+}
+
+// ResetStats 清零全部分片的计数器
+// 参数：无
+// 返回值：无
+func (s *ShardedCache[K, V]) ResetStats() {
+    for _, sh := range s.shards {
+        sh.ResetStats()
+    }
+}
+
+// OnEvict 为全部分片注册条目离开缓存时的回调
+// 参数 fn: 回调函数，接收键、值与原因；传nil取消注册
+// 返回值：无
+// 关键步骤：同一回调注册到每个分片，不同分片的事件可能并发派发，回调需自行保证并发安全
+func (s *ShardedCache[K, V]) OnEvict(fn func(key K, value V, reason EvictReason)) {
+    for _, sh := range s.shards {
+        sh.OnEvict(fn)
+    }
+}
+
+// OnSet 为全部分片注册条目写入时的回调
+// 参数 fn: 回调函数，接收写入的键与值；传nil取消注册
+// 返回值：无
+func (s *ShardedCache[K, V]) OnSet(fn func(key K, value V)) {
+    for _, sh := range s.shards {
+        sh.OnSet(fn)
+    }
+}
+
+// OnDelete 为全部分片注册条目被显式删除时的回调
+// 参数 fn: 回调函数，接收键、值与原因（ReasonDeleted 或 ReasonCleared）；传nil取消注册
+// 返回值：无
+func (s *ShardedCache[K, V]) OnDelete(fn func(key K, value V, reason EvictReason)) {
+    for _, sh := range s.shards {
+        sh.OnDelete(fn)
+    }
+}
+
+// SaveTo 将全部分片的内容写出为单个快照
+// 参数 w: 输出流
+// 返回值：编码或写入错误
+// 关键步骤：逐个分片复制未过期条目后合并编码，格式与 KVCache.SaveTo 相同，两者的快照可互相加载
+func (s *ShardedCache[K, V]) SaveTo(w io.Writer) error {
+    snap := snapshot[K, V]{Version: snapshotVersion}
+    for _, sh := range s.shards {
+        snap.Entries = append(snap.Entries, sh.snapshotEntries()...)
+    }
+    return s.codecOrDefault().Encode(w, &snap)
+}
+
+// LoadFrom 从快照恢复内容
+// 参数 r: 输入流
+// 返回值：解码错误或版本不支持错误
+// 关键步骤：按当前分片数重新分配条目（分片数或哈希函数变化后仍可恢复），每个分片一次加锁写入
+func (s *ShardedCache[K, V]) LoadFrom(r io.Reader) error {
+    var snap snapshot[K, V]
+    if err := s.codecOrDefault().Decode(r, &snap); err != nil {
+        return err
+    }
+    if snap.Version != snapshotVersion {
+        return errors.New("kvcache: 不支持的快照版本")
+    }
+    groups := make([][]snapshotEntry[K, V], len(s.shards))
+    for _, se := range snap.Entries {
+        i := s.hasher(se.Key) & s.mask
+        groups[i] = append(groups[i], se)
+    }
+    for i, entries := range groups {
+        if len(entries) > 0 {
+            s.shards[i].loadEntries(entries)
+        }
+    }
+    return nil
+}
+
+// SaveToFile 将快照原子写入文件
+// 参数 path: 目标文件路径
+// 返回值：错误信息
+func (s *ShardedCache[K, V]) SaveToFile(path string) error {
+    return writeFileAtomic(path, s.SaveTo)
+}
+
+// LoadFromFile 从快照文件恢复内容
+// 参数 path: 快照文件路径
+// 返回值：错误信息（文件不存在时可用 errors.Is(err, os.ErrNotExist) 判断）
+func (s *ShardedCache[K, V]) LoadFromFile(path string) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    return s.LoadFrom(f)
+}
+
+// Close 停止后台协程并关闭全部分片
+// 参数：无
+// 返回值：无
+// 关键步骤：幂等；启用自动快照时退出前写入最终快照；关闭后缓存仍可继续读写
+func (s *ShardedCache[K, V]) Close() {
+    s.stopOnce.Do(func() { close(s.stop) })
+    <-s.done
+    for _, sh := range s.shards {
+        sh.Close()
+    }
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// shard 定位键所属分片
+// 参数 key: 键
+// 返回值：分片缓存器
+func (s *ShardedCache[K, V]) shard(key K) *KVCache[K, V] {
+    return s.shards[s.hasher(key)&s.mask]
+}
+
+// codecOrDefault 返回配置的编解码器（默认 gob）
+// 参数：无
+// 返回值：编解码器
+func (s *ShardedCache[K, V]) codecOrDefault() Codec {
+    if s.codec != nil {
+        return s.codec
+    }
+    return GobCodec
+}
+
+// janitor 后台协程主循环（过期清理与自动快照）
+// 参数 cleanup: 清理间隔（<=0 不清理）
+// 参数 snapshot: 自动快照间隔（<=0 不快照）
+// 返回值：无
+// 关键步骤：未启用的定时器使用nil通道永不触发；收到停止信号后写最终快照并关闭 done
+func (s *ShardedCache[K, V]) janitor(cleanup, snapshot time.Duration) {
+    defer close(s.done)
+    var cleanupC, snapshotC <-chan time.Time
+    if cleanup > 0 {
+        t := time.NewTicker(cleanup)
+        defer t.Stop()
+        cleanupC = t.C
+    }
+    if snapshot > 0 {
+        t := time.NewTicker(snapshot)
+        defer t.Stop()
+        snapshotC = t.C
+    }
+    for {
+        select {
+        case <-cleanupC:
+            s.DeleteExpired()
+        case <-snapshotC:
+            s.autoSnapshot()
+        case <-s.stop:
+            if snapshotC != nil {
+                s.autoSnapshot()
+            }
+            return
+        }
+    }
+}
+
+// autoSnapshot 执行一次自动快照
+// 参数：无
+// 返回值：无
+// 关键步骤：写入失败时交给 OnSnapshotError 处理（未设置则忽略）
+func (s *ShardedCache[K, V]) autoSnapshot() {
+    if err := s.SaveToFile(s.snapshotPath); err != nil && s.onSnapshotError != nil {
+        s.onSnapshotError(err)
+    }
+}
@@ -0,0 +1,186 @@
+package kvcache
+
+import (
+    "path/filepath"
+    "runtime"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+)
+
+// TestShardedBasic 测试分片缓存的基础读写
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：分片数向上取2的幂；读写删除与聚合Len/Keys行为与单锁缓存一致
+func TestShardedBasic(t *testing.T) {
+    s := NewSharded(ShardedOptions[string, int]{Shards: 5})
+    defer s.Close()
+    if s.ShardCount() != 8 { t.Fatalf("分片数应取整为8: %d", s.ShardCount()) }
+    for i := 0; i < 100; i++ { s.Set("k"+strconv.Itoa(i), i) }
+    if s.Len() != 100 || len(s.Keys()) != 100 || len(s.Values()) != 100 {
+        t.Fatalf("聚合长度应为100: %d", s.Len())
+    }
+    if v, ok := s.Get("k42"); !ok || v != 42 { t.Fatalf("读取k42失败: ok=%v v=%d", ok, v) }
+    if !s.Delete("k42") || s.Has("k42") { t.Fatalf("删除k42失败") }
+    s.Clear()
+    if s.Len() != 0 { t.Fatalf("清空后长度应为0: %d", s.Len()) }
+}
+
+// TestShardedHasherAndCapacity 测试自定义哈希与容量均分
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：恒定哈希使所有键落入同一分片，此时生效的容量为单分片上限
+func TestShardedHasherAndCapacity(t *testing.T) {
+    s := NewSharded(ShardedOptions[int, int]{
+        Options: Options[int, int]{MaxEntries: 8},
+        Shards:  4,
+        Hasher:  func(int) uint64 { return 0 },
+    })
+    for i := 0; i < 10; i++ { s.Set(i, i) }
+    if s.Len() != 2 { t.Fatalf("单分片容量应为2: %d", s.Len()) }
+    if !s.Has(9) || !s.Has(8) { t.Fatalf("最近写入的键应保留") }
+}
+
+// TestShardedOversizeNotCounted 测试单条成本超出分片上限时拒绝写入且不计入淘汰
+// 参数 t: 测试对象
+// 返回值：无
+func TestShardedOversizeNotCounted(t *testing.T) {
+    s := NewSharded(ShardedOptions[string, string]{
+        Options: Options[string, string]{MaxCost: 16, Sizer: func(_ string, v string) int64 { return int64(len(v)) }},
+        Shards:  4,
+    })
+    defer s.Close()
+    s.Set("big", "xxxxxxxxxx")
+    if s.Has("big") { t.Fatalf("超出分片上限的单条不应写入") }
+    if st := s.Stats(); st.Evictions != 0 || st.Sets != 0 { t.Fatalf("拒绝写入不应计入淘汰或写入: %+v", st) }
+}
+
+// TestShardedHooks 测试分片缓存的事件回调注册到全部分片
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：键分布在各分片上，写入、删除与清空事件都应送达；传nil后不再派发
+func TestShardedHooks(t *testing.T) {
+    s := NewSharded(ShardedOptions[int, int]{Shards: 4})
+    defer s.Close()
+    var mu sync.Mutex
+    sets, deletes, evicts := 0, 0, 0
+    s.OnSet(func(int, int) { mu.Lock(); sets++; mu.Unlock() })
+    s.OnDelete(func(int, int, EvictReason) { mu.Lock(); deletes++; mu.Unlock() })
+    s.OnEvict(func(int, int, EvictReason) { mu.Lock(); evicts++; mu.Unlock() })
+    for i := 0; i < 64; i++ { s.Set(i, i) }
+    s.Delete(0)
+    s.Clear()
+    if sets != 64 || deletes != 64 || evicts != 64 { t.Fatalf("回调次数不符: set=%d delete=%d evict=%d", sets, deletes, evicts) }
+    s.OnSet(nil)
+    s.Set(1, 1)
+    if sets != 64 { t.Fatalf("取消注册后不应派发: %d", sets) }
+}
+
+// TestShardedJanitor 测试分片缓存的统一后台清理
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：过期条目应被后台回收，Close 后协程退出
+func TestShardedJanitor(t *testing.T) {
+    s := NewSharded(ShardedOptions[int, int]{Options: Options[int, int]{CleanupInterval: 5 * time.Millisecond}})
+    for i := 0; i < 32; i++ { s.SetWithTTL(i, i, time.Millisecond) }
+    deadline := time.Now().Add(time.Second)
+    for s.Len() != 0 {
+        if time.Now().After(deadline) { t.Fatalf("后台清理未回收过期条目: %d", s.Len()) }
+        time.Sleep(2 * time.Millisecond)
+    }
+    s.Close()
+}
+
+// TestShardedSnapshot 测试分片缓存的统一快照与关闭
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：自动快照只由分片缓存写出一个覆盖全部分片的文件；Close 写最终快照且不遗留协程；
+// 快照可按不同分片数恢复，也可由单锁缓存加载
+func TestShardedSnapshot(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "sharded.snap")
+    before := runtime.NumGoroutine()
+    s := NewSharded(ShardedOptions[string, int]{
+        Options: Options[string, int]{SnapshotPath: path, SnapshotInterval: time.Hour},
+        Shards:  8,
+    })
+    for i := 0; i < 100; i++ { s.Set("k"+strconv.Itoa(i), i) }
+    s.Close()
+    if n := runtime.NumGoroutine(); n > before { t.Fatalf("Close后遗留协程: before=%d after=%d", before, n) }
+
+    r := NewSharded(ShardedOptions[string, int]{Shards: 2})
+    defer r.Close()
+    if err := r.LoadFromFile(path); err != nil { t.Fatalf("加载快照失败: %v", err) }
+    if r.Len() != 100 { t.Fatalf("快照应包含全部分片的100个条目: %d", r.Len()) }
+    if v, ok := r.Get("k42"); !ok || v != 42 { t.Fatalf("恢复k42失败: ok=%v v=%d", ok, v) }
+
+    c := New[string, int]()
+    if err := c.LoadFromFile(path); err != nil || c.Len() != 100 { t.Fatalf("单锁缓存加载分片快照失败: err=%v len=%d", err, c.Len()) }
+}
+
+// TestShardedConcurrentSafety 分片缓存并发安全性测试
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：多协程写入不同键空间，最终长度应等于写入总数
+func TestShardedConcurrentSafety(t *testing.T) {
+    s := NewSharded(ShardedOptions[int, int]{})
+    const workers = 16
+    const loops = 500
+    var wg sync.WaitGroup
+    wg.Add(workers)
+    for w := 0; w < workers; w++ {
+        go func(base int) {
+            defer wg.Done()
+            for i := 0; i < loops; i++ {
+                s.Set(base*loops+i, i)
+                s.Get(base*loops + i)
+            }
+        }(w)
+    }
+    wg.Wait()
+    if s.Len() != workers*loops { t.Fatalf("最终长度不匹配: %d", s.Len()) }
+}
+
+// benchCache 基准测试使用的公共方法集合
+type benchCache interface {
+    Set(key int, value int)
+    Get(key int) (int, bool)
+}
+
+// runParallelMixed 并行混合读写基准
+// 参数 b: 基准对象
+// 参数 c: 被测缓存
+// 参数 writeEvery: 每多少次操作执行一次写入（1 表示纯写）
+// 返回值：无
+func runParallelMixed(b *testing.B, c benchCache, writeEvery int) {
+    b.ReportAllocs()
+    b.ResetTimer()
+    b.RunParallel(func(pb *testing.PB) {
+        i := 0
+        for pb.Next() {
+            k := i & 0xffff
+            if i%writeEvery == 0 {
+                c.Set(k, i)
+            } else {
+                c.Get(k)
+            }
+            i++
+        }
+    })
+}
+
+// BenchmarkKVCacheParallelWrite 单锁缓存并行写入
+func BenchmarkKVCacheParallelWrite(b *testing.B) { runParallelMixed(b, New[int, int](), 1) }
+
+// BenchmarkShardedParallelWrite 分片缓存并行写入
+func BenchmarkShardedParallelWrite(b *testing.B) {
+    runParallelMixed(b, NewSharded(ShardedOptions[int, int]{}), 1)
+}
+
+// BenchmarkKVCacheParallelMixed 单锁缓存并行读多写少（1/4写入）
+func BenchmarkKVCacheParallelMixed(b *testing.B) { runParallelMixed(b, New[int, int](), 4) }
+
+// BenchmarkShardedParallelMixed 分片缓存并行读多写少（1/4写入）
+func BenchmarkShardedParallelMixed(b *testing.B) {
+    runParallelMixed(b, NewSharded(ShardedOptions[int, int]{}), 4)
+}
@@ -0,0 +1,156 @@
+package kvcache
+
+import (
+    "sort"
+    "strconv"
+    "strings"
+    "sync/atomic"
+)
+
+// 本文件提供缓存统计：命中、未命中、写入、删除与淘汰计数。
+// 计数器均为原子变量，不占用缓存锁，可在生产环境常开。
+
+// Stats 缓存统计快照
+// 结构体字段解释：
+// - Hits/Misses: Get（含 Has、GetOrLoad）命中与未命中次数
+// - Sets: 成功写入次数（含覆盖）
+// - Deletes: 显式删除的条目数（Delete 与 Clear）
+// - Evictions: 被动淘汰的条目数（过期与容量淘汰）
+// - Entries: 快照时的条目数（可能包含未回收的过期条目）
+// - Cost: 快照时的总成本
+type Stats struct {
+    Hits      uint64
+    Misses    uint64
+    Sets      uint64
+    Deletes   uint64
+    Evictions uint64
+    Entries   int
+    Cost      int64
+}
+
+// counters 原子计数器集合（内部使用）
+type counters struct {
+    hits      atomic.Uint64
+    misses    atomic.Uint64
+    sets      atomic.Uint64
+    deletes   atomic.Uint64
+    evictions atomic.Uint64
+}
+
+// Stats 返回当前统计快照
+// 参数：无
+// 返回值：统计快照
+// 关键步骤：逐个原子读取计数器，各字段之间不保证同一时刻的严格一致
+func (c *KVCache[K, V]) Stats() Stats {
+    c.mu.RLock()
+    n, cost := len(c.store), c.cost
+    c.mu.RUnlock()
+    return Stats{
+        Hits:      c.counters.hits.Load(),
+        Misses:    c.counters.misses.Load(),
+        Sets:      c.counters.sets.Load(),
+        Deletes:   c.counters.deletes.Load(),
+        Evictions: c.counters.evictions.Load(),
+        Entries:   n,
+        Cost:      cost,
+    }
+}
+
+// ResetStats 将全部计数器清零
+// 参数：无
+// 返回值：无
+func (c *KVCache[K, V]) ResetStats() {
+    c.counters.hits.Store(0)
+    c.counters.misses.Store(0)
+    c.counters.sets.Store(0)
+    c.counters.deletes.Store(0)
+    c.counters.evictions.Store(0)
+}
+
+// HitRatio 计算命中率
+// 参数：无
+// 返回值：命中率（0~1）；无访问时返回0
+func (s Stats) HitRatio() float64 {
+    total := s.Hits + s.Misses
+    if total == 0 {
+        return 0
+    }
+    return float64(s.Hits) / float64(total)
+}
+
+// Add 合并另一份统计（用于分片聚合）
+// 参数 o: 另一份统计
+// 返回值：合并后的统计
+func (s Stats) Add(o Stats) Stats {
+    s.Hits += o.Hits
+    s.Misses += o.Misses
+    s.Sets += o.Sets
+    s.Deletes += o.Deletes
+    s.Evictions += o.Evictions
+    s.Entries += o.Entries
+    s.Cost += o.Cost
+    return s
+}
+
+// PrometheusText 以 Prometheus 文本格式渲染统计
+// 参数 namespace: 指标名前缀（为空时使用 "kvcache"）
+// 参数 labels: 附加标签（如 {"cache":"session"}），可为空
+// 返回值：符合 Prometheus text exposition format 的文本
+// 关键步骤：计数类指标使用 _total 后缀与 counter 类型，条目数与成本使用 gauge；标签按名称排序保证输出稳定
+func (s Stats) PrometheusText(namespace string, labels map[string]string) string {
+    if namespace == "" {
+        namespace = "kvcache"
+    }
+    lbl := formatLabels(labels)
+    var sb strings.Builder
+    write := func(name, typ, help, value string) {
+        full := namespace + "_" + name
+        sb.WriteString("# HELP " + full + " " + help + "\n")
+        sb.WriteString("# TYPE " + full + " " + typ + "\n")
+        sb.WriteString(full + lbl + " " + value + "\n")
+    }
+    write("hits_total", "counter", "Number of cache hits.", strconv.FormatUint(s.Hits, 10))
+    write("misses_total", "counter", "Number of cache misses.", strconv.FormatUint(s.Misses, 10))
+    write("sets_total", "counter", "Number of cache writes.", strconv.FormatUint(s.Sets, 10))
+    write("deletes_total", "counter", "Number of explicitly deleted entries.", strconv.FormatUint(s.Deletes, 10))
+    write("evictions_total", "counter", "Number of expired or capacity-evicted entries.", strconv.FormatUint(s.Evictions, 10))
+    write("entries", "gauge", "Current number of entries.", strconv.Itoa(s.Entries))
+    write("cost", "gauge", "Current total cost of entries.", strconv.FormatInt(s.Cost, 10))
+    return sb.String()
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// formatLabels 将标签渲染为 {k="v",...} 形式
+// 参数 labels: 标签集合
+// 返回值：标签文本；为空时返回空字符串
+// 关键步骤：按名称排序，并对反斜杠、双引号与换行进行转义
+func formatLabels(labels map[string]string) string {
+    if len(labels) == 0 {
+        return ""
+    }
+    names := make([]string, 0, len(labels))
+    for k := range labels {
+        names = append(names, k)
+    }
+    sort.Strings(names)
+    escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+    parts := make([]string, 0, len(names))
+    for _, k := range names {
+        parts = append(parts, k+`="`+escaper.Replace(labels[k])+`"`)
+    }
+    return "{" + strings.Join(parts, ",") + "}"
+}
+
+// countRemoval 按移除原因累计删除或淘汰计数
+// 参数 reason: 移除原因
+// 参数 n: 条目数量
+// 返回值：无
+func (c *KVCache[K, V]) countRemoval(reason EvictReason, n int) {
+    switch reason {
+    case ReasonDeleted, ReasonCleared:
+        c.counters.deletes.Add(uint64(n))
+    case ReasonExpired, ReasonCapacity:
+        c.counters.evictions.Add(uint64(n))
+    }
+}
@@ -0,0 +1,68 @@
+package kvcache
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// TestStatsCounters 测试统计计数
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：命中、未命中、写入、删除、容量与过期淘汰分别计数；ResetStats 清零
+func TestStatsCounters(t *testing.T) {
+    clk := newFakeClock()
+    c := NewWithOptions(Options[string, int]{MaxEntries: 2})
+    c.clock = clk.Now
+    c.Set("a", 1)
+    c.Get("a")
+    c.Get("x")
+    c.Set("b", 2)
+    c.Set("c", 3)
+    c.Delete("b")
+    c.SetWithTTL("d", 4, time.Second)
+    clk.Advance(time.Second)
+    c.Get("d")
+    st := c.Stats()
+    want := Stats{Hits: 1, Misses: 2, Sets: 4, Deletes: 1, Evictions: 2, Entries: 1, Cost: 1}
+    if st != want { t.Fatalf("统计不匹配: got=%+v want=%+v", st, want) }
+    if r := st.HitRatio(); r < 0.33 || r > 0.34 { t.Fatalf("命中率应约为1/3: %v", r) }
+    c.Clear()
+    if c.Stats().Deletes != 2 { t.Fatalf("Clear应计入删除: %+v", c.Stats()) }
+    c.ResetStats()
+    if st := c.Stats(); st.Hits+st.Misses+st.Sets+st.Deletes+st.Evictions != 0 { t.Fatalf("重置后计数应为0: %+v", st) }
+}
+
+// TestStatsOversizeMatchesHooks 测试单条成本超限时事件回调与统计一致
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：拒绝写入的新值不派发事件、不计数；被挤出的同名旧值派发一次容量淘汰并计入 Evictions
+func TestStatsOversizeMatchesHooks(t *testing.T) {
+    c := NewWithOptions(Options[string, string]{MaxCost: 10, Sizer: func(_ string, v string) int64 { return int64(len(v)) }})
+    var evicts []string
+    c.OnEvict(func(k string, v string, r EvictReason) { evicts = append(evicts, k+"="+v+":"+r.String()) })
+    c.Set("big", strings.Repeat("x", 12))
+    c.Set("a", "aaaa")
+    c.Set("a", strings.Repeat("y", 12))
+    if c.Has("a") { t.Fatalf("超限写入后旧值应被移除") }
+    if len(evicts) != 1 || evicts[0] != "a=aaaa:capacity" { t.Fatalf("应只为旧值派发一次容量淘汰: %v", evicts) }
+    if st := c.Stats(); st.Evictions != uint64(len(evicts)) || st.Deletes != 0 || st.Sets != 1 { t.Fatalf("统计应与事件一致: %+v events=%v", st, evicts) }
+}
+
+// TestStatsPrometheusText 测试 Prometheus 文本渲染
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：包含 HELP/TYPE 行，标签排序且转义
+func TestStatsPrometheusText(t *testing.T) {
+    st := Stats{Hits: 3, Misses: 1, Entries: 2}
+    out := st.PrometheusText("app_cache", map[string]string{"name": `se"ss`, "env": "prod"})
+    for _, line := range []string{
+        "# TYPE app_cache_hits_total counter",
+        `app_cache_hits_total{env="prod",name="se\"ss"} 3`,
+        `app_cache_misses_total{env="prod",name="se\"ss"} 1`,
+        "# TYPE app_cache_entries gauge",
+    } {
+        if !strings.Contains(out, line+"\n") { t.Fatalf("缺少行 %q:\n%s", line, out) }
+    }
+    if !strings.Contains(Stats{}.PrometheusText("", nil), "kvcache_sets_total 0\n") { t.Fatalf("默认前缀或无标签渲染错误") }
+}
@@ -0,0 +1,118 @@
+package kvcache
+
+import (
+    "time"
+)
+
+// 本文件提供标签（分组）失效：写入时为条目附加标签，之后可按标签一次性删除全部相关条目。
+// 例如按 URL 缓存的页面打上 "product:42" 标签，商品更新时调用 InvalidateTag("product:42")。
+// 标签通过反向索引维护，失效复杂度与该标签下的条目数成正比，而非全表扫描。
+
+// SetWithTags 设置键的值并附加标签（使用默认TTL）
+// 参数 key: 键
+// 参数 value: 值
+// 参数 tags: 标签列表（重复与空字符串会被忽略）
+// 返回值：无
+// 关键步骤：覆盖同名键时以新标签替换旧标签
+func (c *KVCache[K, V]) SetWithTags(key K, value V, tags ...string) {
+    c.SetWithTTLAndTags(key, value, c.defaultTTL, tags...)
+}
+
+// SetWithTTLAndTags 设置键的值、过期时长并附加标签
+// 参数 key: 键
+// 参数 value: 值
+// 参数 ttl: 过期时长；<=0 表示永不过期
+// 参数 tags: 标签列表
+// 返回值：无
+func (c *KVCache[K, V]) SetWithTTLAndTags(key K, value V, ttl time.Duration, tags ...string) {
+    expireAt := c.expireAtFrom(c.nowNano(), ttl)
+    c.mu.Lock()
+    c.setLocked(key, value, expireAt, normalizeTags(tags))
+    c.unlockAndNotify()
+}
+
+// Tags 返回键当前的标签
+// 参数 key: 键
+// 返回值 tags: 标签副本；ok: 键是否存在且未过期
+func (c *KVCache[K, V]) Tags(key K) (tags []string, ok bool) {
+    now := c.nowNano()
+    c.mu.RLock()
+    e, ok := c.store[key]
+    if ok && !e.expired(now) {
+        tags = append([]string(nil), e.tags...)
+    } else {
+        ok = false
+    }
+    c.mu.RUnlock()
+    return tags, ok
+}
+
+// InvalidateTag 删除带有指定标签的全部条目
+// 参数 tag: 标签
+// 返回值 n: 删除的未过期条目数量
+// 关键步骤：通过反向索引定位条目，按 Delete 的语义移除（触发 ReasonDeleted 事件并计入删除统计）
+func (c *KVCache[K, V]) InvalidateTag(tag string) (n int) {
+    now := c.nowNano()
+    c.mu.Lock()
+    for key := range c.tagIndex[tag] {
+        e, ok := c.store[key]
+        if !ok {
+            continue
+        }
+        if e.expired(now) {
+            c.removeLocked(e, ReasonExpired)
+            continue
+        }
+        c.removeLocked(e, ReasonDeleted)
+        n++
+    }
+    c.unlockAndNotify()
+    return n
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// tagLocked 将条目加入标签索引（调用方需持有写锁）
+// 参数 e: 条目
+// 返回值：无
+func (c *KVCache[K, V]) tagLocked(e *entry[K, V]) {
+    for _, t := range e.tags {
+        keys, ok := c.tagIndex[t]
+        if !ok {
+            keys = make(map[K]struct{})
+            c.tagIndex[t] = keys
+        }
+        keys[e.key] = struct{}{}
+    }
+}
+
+// untagLocked 将条目从标签索引移除（调用方需持有写锁）
+// 参数 e: 条目
+// 返回值：无
+// 关键步骤：标签下已无键时删除该标签，避免索引无限增长
+func (c *KVCache[K, V]) untagLocked(e *entry[K, V]) {
+    for _, t := range e.tags {
+        if keys, ok := c.tagIndex[t]; ok {
+            delete(keys, e.key)
+            if len(keys) == 0 {
+                delete(c.tagIndex, t)
+            }
+        }
+    }
+}
+
+// normalizeTags 去重并剔除空标签
+// 参数 tags: 原始标签
+// 返回值：规范化后的标签（无标签时为nil）
+func normalizeTags(tags []string) []string {
+    var out []string
+    seen := make(map[string]bool, len(tags))
+    for _, t := range tags {
+        if t == "" || seen[t] {
+            continue
+        }
+        seen[t] = true
+        out = append(out, t)
+    }
+    return out
+}
@@ -0,0 +1,55 @@
+package kvcache
+
+import (
+    "bytes"
+    "slices"
+    "testing"
+)
+
+// TestInvalidateTag 测试按标签失效
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：仅删除带标签的条目；覆盖写入替换标签；Delete 与 Clear 同步维护索引
+func TestInvalidateTag(t *testing.T) {
+    c := New[string, string]()
+    var deleted []string
+    c.OnDelete(func(k string, _ string, r EvictReason) {
+        if r == ReasonDeleted { deleted = append(deleted, k) }
+    })
+    c.SetWithTags("/p/1", "page1", "product:1", "home")
+    c.SetWithTags("/p/2", "page2", "product:2", "home", "home", "")
+    c.SetWithTags("/p/3", "page3", "product:1")
+    c.Set("/about", "about")
+
+    if tags, ok := c.Tags("/p/2"); !ok || !slices.Equal(tags, []string{"product:2", "home"}) { t.Fatalf("标签应去重: %v", tags) }
+    if n := c.InvalidateTag("product:1"); n != 2 { t.Fatalf("应删除2个条目: %d", n) }
+    slices.Sort(deleted)
+    if !slices.Equal(deleted, []string{"/p/1", "/p/3"}) { t.Fatalf("删除回调不正确: %v", deleted) }
+    if !c.Has("/p/2") || !c.Has("/about") { t.Fatalf("无关条目不应被删除") }
+
+    // 关键步骤：覆盖写入不带标签后，旧标签不再关联该键
+    c.Set("/p/2", "page2-v2")
+    if n := c.InvalidateTag("home"); n != 0 { t.Fatalf("覆盖后旧标签不应再命中: %d", n) }
+    c.SetWithTags("/p/4", "page4", "x")
+    c.Delete("/p/4")
+    if n := c.InvalidateTag("x"); n != 0 { t.Fatalf("删除后标签索引应同步清理: %d", n) }
+    c.SetWithTags("/p/5", "page5", "y")
+    c.Clear()
+    c.Set("/p/5", "again")
+    if n := c.InvalidateTag("y"); n != 0 || !c.Has("/p/5") { t.Fatalf("Clear后标签索引应清空") }
+    if len(c.tagIndex) != 0 { t.Fatalf("标签索引应无残留: %v", c.tagIndex) }
+}
+
+// TestTagsPreservedByUpdateAndSnapshot 测试原子更新与快照保留标签
+// 参数 t: 测试对象
+// 返回值：无
+func TestTagsPreservedByUpdateAndSnapshot(t *testing.T) {
+    c := New[string, int]()
+    c.SetWithTags("n", 1, "counter")
+    Increment(c, "n", 1)
+    var buf bytes.Buffer
+    if err := c.SaveTo(&buf); err != nil { t.Fatalf("SaveTo失败: %v", err) }
+    r := New[string, int]()
+    if err := r.LoadFrom(&buf); err != nil { t.Fatalf("LoadFrom失败: %v", err) }
+    if n := r.InvalidateTag("counter"); n != 1 { t.Fatalf("恢复后标签应保留: %d", n) }
+}
@@ -0,0 +1,114 @@
+package kvcache
+
+import (
+    "sync"
+    "testing"
+    "time"
+)
+
+// fakeClock 可手动推进的测试时钟
+// 结构体字段解释：
+// - mu: 并发保护
+// - t: 当前时间
+type fakeClock struct {
+    mu sync.Mutex
+    t  time.Time
+}
+
+// newFakeClock 创建测试时钟
+// 参数：无
+// 返回值：时钟指针（起点为固定时间）
+func newFakeClock() *fakeClock {
+    return &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now 返回当前模拟时间
+// 参数：无
+// 返回值：当前时间
+func (f *fakeClock) Now() time.Time {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.t
+}
+
+// Advance 推进模拟时间
+// 参数 d: 推进时长
+// 返回值：无
+func (f *fakeClock) Advance(d time.Duration) {
+    f.mu.Lock()
+    f.t = f.t.Add(d)
+    f.mu.Unlock()
+}
+
+// TestSetWithTTLLazyExpire 测试按条目TTL过期与惰性淘汰
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：推进时钟越过TTL后 Get/Has 返回不存在，且条目被惰性删除
+func TestSetWithTTLLazyExpire(t *testing.T) {
+    clk := newFakeClock()
+    c := New[string, int]()
+    c.clock = clk.Now
+    c.SetWithTTL("a", 1, time.Second)
+    c.Set("b", 2)
+
+    if v, ok := c.Get("a"); !ok || v != 1 { t.Fatalf("未过期时应可读取: ok=%v v=%d", ok, v) }
+    if ttl, ok := c.TTL("a"); !ok || ttl != time.Second { t.Fatalf("剩余TTL应为1s: ok=%v ttl=%v", ok, ttl) }
+    clk.Advance(time.Second)
+    if _, ok := c.Get("a"); ok { t.Fatalf("过期后Get不应返回ok=true") }
+    if c.Has("a") { t.Fatalf("过期后Has应返回false") }
+    if c.Len() != 1 { t.Fatalf("惰性淘汰后长度应为1: %d", c.Len()) }
+    if !c.Has("b") { t.Fatalf("未设置TTL的键不应过期") }
+}
+
+// TestHasReadOnly 测试 Has 为只读查询
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：LRU 缓存中 Has 不提升条目，不计入命中统计；过期条目返回false但不被移除
+func TestHasReadOnly(t *testing.T) {
+    clk := newFakeClock()
+    c := NewWithOptions(Options[string, int]{MaxEntries: 2})
+    c.clock = clk.Now
+    c.Set("a", 1)
+    c.Set("b", 2)
+    if !c.Has("a") { t.Fatalf("a应存在") }
+    c.Set("c", 3)
+    if c.Has("a") || !c.Has("b") { t.Fatalf("Has不应提升a的LRU顺序") }
+    if st := c.Stats(); st.Hits != 0 || st.Misses != 0 { t.Fatalf("Has不应计入命中统计: %+v", st) }
+    c.SetWithTTL("d", 4, time.Second)
+    clk.Advance(time.Second)
+    if c.Has("d") || c.Len() != 2 || c.Stats().Evictions != 2 { t.Fatalf("过期条目应返回false且不被Has移除: len=%d %+v", c.Len(), c.Stats()) }
+}
+
+// TestDefaultTTLAndDeleteExpired 测试默认TTL与批量回收
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：默认TTL作用于Set；Keys不返回过期键；DeleteExpired返回回收数量
+func TestDefaultTTLAndDeleteExpired(t *testing.T) {
+    clk := newFakeClock()
+    c := NewWithOptions(Options[int, int]{DefaultTTL: time.Minute})
+    c.clock = clk.Now
+    for i := 0; i < 3; i++ { c.Set(i, i) }
+    c.SetWithTTL(9, 9, 0)
+    clk.Advance(2 * time.Minute)
+    if ks := c.Keys(); len(ks) != 1 || ks[0] != 9 { t.Fatalf("Keys应仅包含永不过期键: %v", ks) }
+    if c.Delete(0) { t.Fatalf("删除已过期键应返回false") }
+    if n := c.DeleteExpired(); n != 2 { t.Fatalf("应回收2个过期条目: %d", n) }
+    if c.Len() != 1 { t.Fatalf("回收后长度应为1: %d", c.Len()) }
+}
+
+// TestJanitorAndClose 测试后台清理协程与关闭
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：后台协程应回收过期条目；Close 可重复调用且不阻塞
+func TestJanitorAndClose(t *testing.T) {
+    c := NewWithOptions(Options[string, string]{CleanupInterval: 5 * time.Millisecond})
+    c.SetWithTTL("x", "X", time.Millisecond)
+    deadline := time.Now().Add(time.Second)
+    for c.Len() != 0 {
+        if time.Now().After(deadline) { t.Fatalf("后台清理未回收过期条目") }
+        time.Sleep(2 * time.Millisecond)
+    }
+    c.Close()
+    c.Close()
+    New[string, string]().Close()
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+    "time"
+
+    "github.com/QinWeisWord/go_utils/kvcache"
+)
+
+// 本文件定义限流器的公共抽象。限流状态保存在 kvcache 分片缓存中，按任意可比较的键（客户端IP、用户ID等）
+// 独立计数；每次判定都会顺延键的过期时间，空闲超过 IdleTTL 的键由缓存后台协程自动清理。
+// 算法实现见 ratelimit_token_bucket.go（令牌桶）与 ratelimit_sliding_window.go（滑动窗口），
+// HTTP 中间件见 ratelimit_http.go。
+
+// Limiter 限流器接口
+// 方法说明：
+// - Allow: 申请1个配额，返回是否放行
+// - AllowN: 申请n个配额，返回详细结果（拒绝时不消耗配额）
+// - Close: 停止后台清理协程
+type Limiter[K comparable] interface {
+    Allow(key K) bool
+    AllowN(key K, n int) Result
+    Close()
+}
+
+// Result 限流判定结果
+// 结构体字段解释：
+// - Allowed: 是否放行
+// - Limit: 配额上限（令牌桶为桶容量，滑动窗口为窗口内请求上限）
+// - Remaining: 本次判定后剩余配额（向下取整，不小于0）
+// - RetryAfter: 被拒绝时建议的重试等待时长（n 超过上限、永远无法满足时为0）
+type Result struct {
+    Allowed    bool
+    Limit      int
+    Remaining  int
+    RetryAfter time.Duration
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// newStateCache 创建保存限流状态的分片缓存
+// 参数 shards: 分片数（<=0 时使用 kvcache 默认值）
+// 参数 cleanup: 过期键清理间隔
+// 返回值：分片缓存指针
+func newStateCache[K comparable, S any](shards int, cleanup time.Duration) *kvcache.ShardedCache[K, S] {
+    return kvcache.NewSharded(kvcache.ShardedOptions[K, S]{
+        Options: kvcache.Options[K, S]{CleanupInterval: cleanup},
+        Shards:  shards,
+    })
+}
+
+// nowFunc 返回时间来源（为空时使用 time.Now）
+// 参数 clock: 注入的时钟
+// 返回值：时间函数
+func nowFunc(clock func() time.Time) func() time.Time {
+    if clock != nil {
+        return clock
+    }
+    return time.Now
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+    "net"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// 本文件提供 HTTP 中间件：按请求提取限流键，超限时返回 429 Too Many Requests，
+// 并写出 X-RateLimit-Limit、X-RateLimit-Remaining 与 Retry-After 响应头。
+
+// MiddlewareOptions 中间件选项
+// 结构体字段解释：
+// - KeyFunc: 从请求中提取限流键；为空时使用 ClientIP（仅取 RemoteAddr，不信任代理头）
+// - OnLimited: 超限时的处理器；为空时返回 429 与纯文本提示（响应头已写入限流信息）
+type MiddlewareOptions struct {
+    KeyFunc   func(r *http.Request) string
+    OnLimited http.Handler
+}
+
+// Middleware 创建限流中间件
+// 参数 l: 限流器（键为字符串）
+// 参数 opt: 中间件选项
+// 返回值：包装 http.Handler 的中间件函数
+// 关键步骤：每个请求申请1个配额；放行时继续调用下游处理器，拒绝时交给 OnLimited
+func Middleware(l Limiter[string], opt MiddlewareOptions) func(next http.Handler) http.Handler {
+    keyFunc := opt.KeyFunc
+    if keyFunc == nil {
+        keyFunc = ClientIP
+    }
+    onLimited := opt.OnLimited
+    if onLimited == nil {
+        onLimited = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+        })
+    }
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            res := l.AllowN(keyFunc(r), 1)
+            h := w.Header()
+            h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+            h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+            if !res.Allowed {
+                if res.RetryAfter > 0 {
+                    h.Set("Retry-After", strconv.FormatInt(int64((res.RetryAfter+time.Second-1)/time.Second), 10))
+                }
+                onLimited.ServeHTTP(w, r)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// ClientIP 从请求的 RemoteAddr 中提取客户端IP
+// 参数 r: HTTP 请求
+// 返回值：IP 字符串（RemoteAddr 不含端口时原样返回）
+// 关键步骤：不读取 X-Forwarded-For 等可被客户端伪造的头；部署在反向代理之后时请自定义 KeyFunc
+func ClientIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+// TestMiddleware 测试限流中间件
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：默认按 RemoteAddr 的IP限流（忽略端口）；超限返回429并带 Retry-After；自定义 KeyFunc 与 OnLimited 生效
+func TestMiddleware(t *testing.T) {
+    clk := newFakeClock()
+    tb, _ := NewTokenBucket[string](TokenBucketOptions{Rate: 1, Burst: 2, Clock: clk.Now})
+    defer tb.Close()
+    ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+    h := Middleware(tb, MiddlewareOptions{})(ok)
+
+    do := func(remote string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodGet, "/captcha", nil)
+        req.RemoteAddr = remote
+        rec := httptest.NewRecorder()
+        h.ServeHTTP(rec, req)
+        return rec
+    }
+    if rec := do("10.0.0.1:1000"); rec.Code != http.StatusNoContent || rec.Header().Get("X-RateLimit-Remaining") != "1" { t.Fatalf("首次请求应放行: %d %v", rec.Code, rec.Header()) }
+    do("10.0.0.1:2000")
+    rec := do("10.0.0.1:3000")
+    if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" || rec.Header().Get("X-RateLimit-Limit") != "2" { t.Fatalf("同IP不同端口应共享配额并返回429: %d %v", rec.Code, rec.Header()) }
+    if rec := do("10.0.0.2:1000"); rec.Code != http.StatusNoContent { t.Fatalf("不同IP应独立计数: %d", rec.Code) }
+
+    custom := Middleware(tb, MiddlewareOptions{
+        KeyFunc:   func(r *http.Request) string { return r.Header.Get("X-User") },
+        OnLimited: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }),
+    })(ok)
+    codes := []int{}
+    for i := 0; i < 3; i++ {
+        req := httptest.NewRequest(http.MethodGet, "/", nil)
+        req.Header.Set("X-User", "u1")
+        rec := httptest.NewRecorder()
+        custom.ServeHTTP(rec, req)
+        codes = append(codes, rec.Code)
+    }
+    if codes[1] != http.StatusNoContent || codes[2] != http.StatusServiceUnavailable { t.Fatalf("自定义键与拒绝处理器应生效: %v", codes) }
+}
+
+// TestClientIP 测试客户端IP提取
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：IPv4/IPv6 去掉端口；无端口时原样返回
+func TestClientIP(t *testing.T) {
+    cases := map[string]string{"1.2.3.4:80": "1.2.3.4", "[::1]:443": "::1", "unix": "unix"}
+    for in, want := range cases {
+        r := &http.Request{RemoteAddr: in}
+        if got := ClientIP(r); got != want { t.Fatalf("ClientIP(%q)=%q，期望%q", in, got, want) }
+    }
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+    "errors"
+    "math"
+    "time"
+
+    "github.com/QinWeisWord/go_utils/kvcache"
+)
+
+// 本文件实现滑动窗口限流（滑动窗口计数法）：按 Window 对齐划分固定窗口，只记录当前与上一窗口的计数，
+// 以“上一窗口计数 × 上一窗口在滑动窗口内的占比 + 当前窗口计数”估算最近 Window 内的请求数。
+// 每个键只占常数内存，且避免了固定窗口在边界处允许两倍突发的问题；估算假设上一窗口内请求均匀分布。
+
+// SlidingWindowOptions 滑动窗口构造选项
+// 结构体字段解释：
+// - Limit: 每个窗口内允许的最大请求数（必须>0）
+// - Window: 窗口长度（必须>0）
+// - IdleTTL: 键空闲多久后清理；<=0 时取 2×Window，此时被清理的键计数已不再影响判定
+// - CleanupInterval: 后台清理间隔；<=0 时与 IdleTTL 相同
+// - Shards: 状态缓存分片数；<=0 时使用 kvcache 默认值
+// - Clock: 时间来源（便于测试注入）；为空时使用 time.Now
+type SlidingWindowOptions struct {
+    Limit           int
+    Window          time.Duration
+    IdleTTL         time.Duration
+    CleanupInterval time.Duration
+    Shards          int
+    Clock           func() time.Time
+}
+
+// SlidingWindow 滑动窗口限流器
+// 结构体字段解释：
+// - states: 各键的窗口计数
+// - limit: 窗口内请求上限
+// - window: 窗口长度（纳秒）
+// - idle: 键的空闲过期时长
+// - now: 时间来源
+type SlidingWindow[K comparable] struct {
+    states *kvcache.ShardedCache[K, windowCount]
+    limit  int
+    window int64
+    idle   time.Duration
+    now    func() time.Time
+}
+
+// windowCount 单个键的窗口计数（内部使用）
+// 结构体字段解释：
+// - start: 当前窗口起点（UnixNano，按窗口长度对齐）
+// - cur: 当前窗口计数
+// - prev: 上一窗口计数
+type windowCount struct {
+    start int64
+    cur   int
+    prev  int
+}
+
+// NewSlidingWindow 创建滑动窗口限流器
+// 参数 opt: 构造选项
+// 返回值：限流器指针与错误（Limit 或 Window 非法时返回错误）
+// 关键步骤：启动状态缓存的后台清理协程，需调用 Close 释放
+func NewSlidingWindow[K comparable](opt SlidingWindowOptions) (*SlidingWindow[K], error) {
+    if opt.Limit <= 0 {
+        return nil, errors.New("ratelimit: Limit 必须为正数")
+    }
+    if opt.Window <= 0 {
+        return nil, errors.New("ratelimit: Window 必须为正数")
+    }
+    idle := opt.IdleTTL
+    if idle <= 0 {
+        idle = 2 * opt.Window
+    }
+    cleanup := opt.CleanupInterval
+    if cleanup <= 0 {
+        cleanup = idle
+    }
+    return &SlidingWindow[K]{
+        states: newStateCache[K, windowCount](opt.Shards, cleanup),
+        limit:  opt.Limit,
+        window: int64(opt.Window),
+        idle:   idle,
+        now:    nowFunc(opt.Clock),
+    }, nil
+}
+
+// Allow 申请1个配额
+// 参数 key: 限流键
+// 返回值：是否放行
+func (sw *SlidingWindow[K]) Allow(key K) bool {
+    return sw.AllowN(key, 1).Allowed
+}
+
+// AllowN 申请n个配额
+// 参数 key: 限流键
+// 参数 n: 配额数（<=0 时视为1）
+// 返回值：判定结果；超限时不计数
+// 关键步骤：在缓存的原子更新内滚动窗口、估算最近一个窗口的请求数并判定，同时顺延键的空闲过期时间
+func (sw *SlidingWindow[K]) AllowN(key K, n int) Result {
+    if n <= 0 {
+        n = 1
+    }
+    now := sw.now().UnixNano()
+    start := now - now%sw.window
+    res := Result{Limit: sw.limit}
+    sw.states.UpdateWithTTL(key, sw.idle, func(w windowCount, ok bool) (windowCount, bool) {
+        switch {
+        case !ok:
+            w = windowCount{start: start}
+        case start == w.start+sw.window:
+            w = windowCount{start: start, prev: w.cur}
+        case start > w.start:
+            w = windowCount{start: start}
+        }
+        // 关键步骤：上一窗口在滑动窗口内的剩余占比
+        weight := 1 - float64(now-w.start)/float64(sw.window)
+        weight = min(max(weight, 0), 1)
+        used := float64(w.prev)*weight + float64(w.cur)
+        if used+float64(n) <= float64(sw.limit) {
+            w.cur += n
+            used += float64(n)
+            res.Allowed = true
+        } else if n <= sw.limit {
+            res.RetryAfter = sw.retryAfter(w, now, n)
+        }
+        res.Remaining = max(0, sw.limit-int(math.Ceil(used)))
+        return w, true
+    })
+    return res
+}
+
+// Reset 清除键的计数
+// 参数 key: 限流键
+// 返回值：无
+func (sw *SlidingWindow[K]) Reset(key K) {
+    sw.states.Delete(key)
+}
+
+// Len 返回当前跟踪的键数量
+// 参数：无
+// 返回值：键数量（含尚未被清理的过期键）
+func (sw *SlidingWindow[K]) Len() int {
+    return sw.states.Len()
+}
+
+// Close 停止后台清理协程
+// 参数：无
+// 返回值：无
+func (sw *SlidingWindow[K]) Close() {
+    sw.states.Close()
+}
+
+// ===================== 以下为私有辅助函数（置于公有方法之后） =====================
+
+// retryAfter 估算再次申请n个配额需要等待的时长
+// 参数 w: 当前窗口计数
+// 参数 now: 当前时间（UnixNano）
+// 参数 n: 申请的配额数
+// 返回值：等待时长
+// 关键步骤：当前窗口剩余额度足够时，等待上一窗口的占比衰减到可容纳n；否则至少等到下一窗口开始
+func (sw *SlidingWindow[K]) retryAfter(w windowCount, now int64, n int) time.Duration {
+    free := sw.limit - w.cur - n
+    if free >= 0 && w.prev > 0 {
+        // 关键步骤：需满足 prev×(1-elapsed/window) <= free
+        target := w.start + int64(math.Ceil((1-float64(free)/float64(w.prev))*float64(sw.window)))
+        if target > now {
+            return time.Duration(target - now)
+        }
+        return 0
+    }
+    return time.Duration(w.start + sw.window - now)
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+    "sync"
+    "testing"
+    "time"
+)
+
+// fakeClock 可手动推进的测试时钟
+type fakeClock struct {
+    mu  sync.Mutex
+    now time.Time
+}
+
+// Now 返回当前模拟时间
+func (c *fakeClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.now
+}
+
+// Advance 推进模拟时间
+func (c *fakeClock) Advance(d time.Duration) {
+    c.mu.Lock()
+    c.now = c.now.Add(d)
+    c.mu.Unlock()
+}
+
+// newFakeClock 创建从固定时刻开始的测试时钟（对齐到整秒，便于计算窗口边界）
+func newFakeClock() *fakeClock {
+    return &fakeClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+// 编译期检查两种算法均实现 Limiter
+var (
+    _ Limiter[string] = (*TokenBucket[string])(nil)
+    _ Limiter[string] = (*SlidingWindow[string])(nil)
+)
+
+// TestTokenBucket 测试令牌桶的突发、补充与重试时间
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：新键允许 Burst 次突发；耗尽后拒绝并给出 RetryAfter；按速率补充且不超过容量；不同键互不影响
+func TestTokenBucket(t *testing.T) {
+    clk := newFakeClock()
+    tb, err := NewTokenBucket[string](TokenBucketOptions{Rate: 2, Burst: 3, Clock: clk.Now})
+    if err != nil { t.Fatalf("创建失败: %v", err) }
+    defer tb.Close()
+    for i := 0; i < 3; i++ {
+        if !tb.Allow("ip") { t.Fatalf("第%d次突发应放行", i+1) }
+    }
+    res := tb.AllowN("ip", 1)
+    if res.Allowed || res.Remaining != 0 || res.RetryAfter != 500*time.Millisecond { t.Fatalf("令牌耗尽应拒绝: %+v", res) }
+    if !tb.Allow("other") { t.Fatalf("不同键应独立计数") }
+    clk.Advance(500 * time.Millisecond)
+    if !tb.Allow("ip") || tb.Allow("ip") { t.Fatalf("0.5秒应恰好补充1个令牌") }
+    clk.Advance(time.Hour)
+    if res := tb.AllowN("ip", 3); !res.Allowed || res.Remaining != 0 || res.Limit != 3 { t.Fatalf("补充不应超过桶容量: %+v", res) }
+    if res := tb.AllowN("ip", 4); res.Allowed || res.RetryAfter != 0 { t.Fatalf("超过容量的申请应拒绝且无重试时间: %+v", res) }
+    tb.Reset("ip")
+    if !tb.AllowN("ip", 3).Allowed { t.Fatalf("Reset后应恢复满桶") }
+
+    if _, err := NewTokenBucket[string](TokenBucketOptions{Rate: 0, Burst: 1}); err == nil { t.Fatalf("Rate为0应返回错误") }
+    if _, err := NewTokenBucket[string](TokenBucketOptions{Rate: 1}); err == nil { t.Fatalf("Burst为0应返回错误") }
+}
+
+// TestSlidingWindow 测试滑动窗口的计数与窗口滑动
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：窗口内达到上限后拒绝；进入下一窗口时上一窗口计数按占比衰减；两个窗口后完全恢复
+func TestSlidingWindow(t *testing.T) {
+    clk := newFakeClock()
+    sw, err := NewSlidingWindow[int](SlidingWindowOptions{Limit: 4, Window: time.Second, Clock: clk.Now})
+    if err != nil { t.Fatalf("创建失败: %v", err) }
+    defer sw.Close()
+    if res := sw.AllowN(1, 4); !res.Allowed || res.Remaining != 0 { t.Fatalf("上限内应放行: %+v", res) }
+    res := sw.AllowN(1, 1)
+    if res.Allowed || res.RetryAfter != time.Second { t.Fatalf("超限应拒绝并等到下一窗口: %+v", res) }
+
+    clk.Advance(time.Second + 250*time.Millisecond)
+    // 上一窗口4次×0.75=3，仅剩1个配额
+    if !sw.Allow(1) || sw.Allow(1) { t.Fatalf("上一窗口计数应按占比衰减") }
+    res = sw.AllowN(1, 1)
+    if res.Allowed || res.RetryAfter != 250*time.Millisecond { t.Fatalf("重试时间应为上一窗口衰减所需时长: %+v", res) }
+    clk.Advance(250 * time.Millisecond)
+    if !sw.Allow(1) { t.Fatalf("衰减后应放行") }
+
+    clk.Advance(2 * time.Second)
+    if res := sw.AllowN(1, 4); !res.Allowed { t.Fatalf("两个窗口后应完全恢复: %+v", res) }
+    if res := sw.AllowN(2, 5); res.Allowed || res.RetryAfter != 0 { t.Fatalf("超过上限的申请应拒绝且无重试时间: %+v", res) }
+
+    if _, err := NewSlidingWindow[int](SlidingWindowOptions{Limit: 1}); err == nil { t.Fatalf("Window为0应返回错误") }
+}
+
+// TestIdleKeysCleanup 测试空闲键自动清理
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：空闲超过 IdleTTL 的键由后台协程清理；活跃键不受影响
+func TestIdleKeysCleanup(t *testing.T) {
+    tb, err := NewTokenBucket[string](TokenBucketOptions{Rate: 1, Burst: 1, IdleTTL: 50 * time.Millisecond, CleanupInterval: 10 * time.Millisecond})
+    if err != nil { t.Fatalf("创建失败: %v", err) }
+    defer tb.Close()
+    tb.Allow("idle")
+    deadline := time.Now().Add(2 * time.Second)
+    for tb.Len() != 0 && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    if tb.Len() != 0 { t.Fatalf("空闲键应被清理: len=%d", tb.Len()) }
+}
+
+// TestTokenBucketConcurrent 测试并发申请不超发
+// 参数 t: 测试对象
+// 返回值：无
+// 关键步骤：时间静止时并发申请的放行总数应恰好等于桶容量
+func TestTokenBucketConcurrent(t *testing.T) {
+    clk := newFakeClock()
+    tb, _ := NewTokenBucket[string](TokenBucketOptions{Rate: 1, Burst: 100, Clock: clk.Now})
+    defer tb.Close()
+    var wg sync.WaitGroup
+    var mu sync.Mutex
+    allowed := 0
+    for i := 0; i < 16; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 20; j++ {
+                if tb.Allow("k") {
+                    mu.Lock()
+                    allowed++
+                    mu.Unlock()
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    if allowed != 100 { t.Fatalf("放行总数应为100: %d", allowed) }
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+    "errors"
+    "math"
+    "time"
+
+    "github.com/QinWeisWord/go_utils/kvcache"
+)
+
+// 本文件实现令牌桶限流：桶以固定速率补充令牌、最多存放 Burst 个，请求消耗令牌，令牌不足时拒绝。
+// 适合允许短时突发、长期平均速率受限的场景。
+
+// TokenBucketOptions 令牌桶构造选项
+// 结构体字段解释：
+// - Rate: 每秒补充的令牌数（必须>0，可为小数，如 0.5 表示每2秒1个）
+// - Burst: 桶容量，即允许的最大突发请求数（必须>0）
+// - IdleTTL: 键空闲多久后清理；<=0 时取桶从空到满所需时长（至少1秒），此时清理不会放宽限制
+// - CleanupInterval: 后台清理间隔；<=0 时与 IdleTTL 相同
+// - Shards: 状态缓存分片数；<=0 时使用 kvcache 默认值
+// - Clock: 时间来源（便于测试注入）；为空时使用 time.Now
+type TokenBucketOptions struct {
+    Rate            float64
+    Burst           int
+    IdleTTL         time.Duration
+    CleanupInterval time.Duration
+    Shards          int
+    Clock           func() time.Time
+}
+
+// TokenBucket 令牌桶限流器
+// 结构体字段解释：
+// - states: 各键的桶状态
+// - rate: 每纳秒补充的令牌数
+// - burst: 桶容量
+// - idle: 键的空闲过期时长
+// - now: 时间来源
+type TokenBucket[K comparable] struct {
+    states *kvcache.ShardedCache[K, bucket]
+    rate   float64
+    burst  float64
+    idle   time.Duration
+    now    func() time.Time
+}
+
+// bucket 单个键的桶状态（内部使用）
+// 结构体字段解释：
+// - tokens: 当前令牌数
+// - last: 上次补充令牌的时间（UnixNano）
+type bucket struct {
+    tokens float64
+    last   int64
+}
+
+// NewTokenBucket 创建令牌桶限流器
+// 参数 opt: 构造选项
+// 返回值：限流器指针与错误（Rate 或 Burst 非法时返回错误）
+// 关键步骤：启动状态缓存的后台清理协程，需调用 Close 释放
+func NewTokenBucket[K comparable](opt TokenBucketOptions) (*TokenBucket[K], error) {
+    if opt.Rate <= 0 || math.IsInf(opt.Rate, 0) || math.IsNaN(opt.Rate) {
+        return nil, errors.New("ratelimit: Rate 必须为正数")
+    }
+    if opt.Burst <= 0 {
+        return nil, errors.New("ratelimit: Burst 必须为正数")
+    }
+    idle := opt.IdleTTL
+    if idle <= 0 {
+        idle = time.Duration(float64(opt.Burst) / opt.Rate * float64(time.Second))
+        if idle < time.Second {
+            idle = time.Second
+        }
+    }
+    cleanup := opt.CleanupInterval
+    if cleanup <= 0 {
+        cleanup = idle
+    }
+    return &TokenBucket[K]{
+        states: newStateCache[K, bucket](opt.Shards, cleanup),
+        rate:   opt.Rate / float64(time.Second),
+        burst:  float64(opt.Burst),
+        idle:   idle,
+        now:    nowFunc(opt.Clock),
+    }, nil
+}
+
+// Allow 申请1个令牌
+// 参数 key: 限流键
+// 返回值：是否放行
+func (tb *TokenBucket[K]) Allow(key K) bool {
+    return tb.AllowN(key, 1).Allowed
+}
+
+// AllowN 申请n个令牌
+// 参数 key: 限流键
+// 参数 n: 令牌数（<=0 时视为1）
+// 返回值：判定结果；令牌不足时不扣减
+// 关键步骤：在缓存的原子更新内按流逝时间补充令牌再判定，并顺延键的空闲过期时间；新键视为满桶
+func (tb *TokenBucket[K]) AllowN(key K, n int) Result {
+    if n <= 0 {
+        n = 1
+    }
+    now := tb.now().UnixNano()
+    need := float64(n)
+    res := Result{Limit: int(tb.burst)}
+    tb.states.UpdateWithTTL(key, tb.idle, func(b bucket, ok bool) (bucket, bool) {
+        if !ok {
+            b = bucket{tokens: tb.burst, last: now}
+        }
+        if now > b.last {
+            b.tokens = math.Min(tb.burst, b.tokens+float64(now-b.last)*tb.rate)
+            b.last = now
+        }
+        if b.tokens >= need {
+            b.tokens -= need
+            res.Allowed = true
+        } else if need <= tb.burst {
+            res.RetryAfter = time.Duration(math.Ceil((need - b.tokens) / tb.rate))
+        }
+        res.Remaining = int(b.tokens)
+        return b, true
+    })
+    return res
+}
+
+// Reset 清除键的状态（恢复为满桶）
+// 参数 key: 限流键
+// 返回值：无
+func (tb *TokenBucket[K]) Reset(key K) {
+    tb.states.Delete(key)
+}
+
+// Len 返回当前跟踪的键数量
+// 参数：无
+// 返回值：键数量（含尚未被清理的过期键）
+func (tb *TokenBucket[K]) Len() int {
+    return tb.states.Len()
+}
+
+// Close 停止后台清理协程
+// 参数：无
+// 返回值：无
+func (tb *TokenBucket[K]) Close() {
+    tb.states.Close()
+}